@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The metadata cache keeps the raw responses of (some) list queries on disk,
+// keyed by tenant and user, so that resolving workspace names, completing object IDs,
+// and re-running rbac-dot in a shell loop don't re-list everything each time.
+// Only queries compiled WithCache() use it, and it's disabled when there is no
+// cache directory configured.
+
+const DefaultCacheTTL = 10 * time.Minute
+
+// DefaultCacheDir returns the directory to use when none is configured, or
+// the empty string (disabling the cache) if the system doesn't have one.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "observe")
+}
+
+type metadataCache struct {
+	fs fileSystem
+	// tenantDir holds the caches of all users of the tenant, and dir the
+	// cache of this one
+	tenantDir string
+	dir       string
+	ttl       time.Duration
+	refresh   bool
+}
+
+// cacheFor returns the cache for the tenant and user in cfg, or nil if
+// caching is off.
+func cacheFor(cfg *Config, fs fileSystem, op Output) (*metadataCache, error) {
+	if fs == nil || cfg.CacheDir == "" || cfg.CustomerIdStr == "" || cfg.SiteStr == "" {
+		return nil, nil
+	}
+	ttl := DefaultCacheTTL
+	if cfg.CacheTTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(cfg.CacheTTL); err != nil {
+			return nil, NewObserveError(err, "bad configured cache_ttl %q", cfg.CacheTTL)
+		}
+	}
+	if ttl <= 0 {
+		return nil, nil
+	}
+	// site may contain a :port, which some file systems don't like
+	tenant := cfg.CustomerIdStr + "_" + strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(cfg.SiteStr)
+	// what a listing returns depends on who asks, so each token has its own
+	// cache, named by a hash that doesn't reveal the token
+	user := sha256.Sum256([]byte(cfg.AuthtokenStr))
+	tenantDir := filepath.Join(cfg.CacheDir, tenant)
+	return &metadataCache{
+		fs:        fs,
+		tenantDir: tenantDir,
+		dir:       filepath.Join(tenantDir, hex.EncodeToString(user[:8])),
+		ttl:       ttl,
+		refresh:   cfg.CacheRefresh,
+	}, nil
+}
+
+func (c *metadataCache) path(query string, args object) string {
+	h := sha256.New()
+	h.Write([]byte(query))
+	// json.Marshal sorts map keys, so this is stable
+	argdata, _ := json.Marshal(args)
+	h.Write(argdata)
+	return filepath.Join(c.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// Read returns the cached response, if there is one that hasn't expired, and
+// a refresh wasn't asked for.
+func (c *metadataCache) Read(op Output, query string, args object) ([]byte, bool) {
+	if c == nil || c.refresh {
+		return nil, false
+	}
+	p := c.path(query, args)
+	st, err := c.fs.Stat(p)
+	if err != nil {
+		return nil, false
+	}
+	if age := time.Since(st.ModTime()); age > c.ttl {
+		op.Debug("cache: expired %s age=%s\n", p, age.Truncate(time.Second))
+		return nil, false
+	}
+	data, err := c.fs.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	op.Debug("cache: hit %s\n", p)
+	return data, true
+}
+
+// Write saves a response. Failing to write the cache is not an error for the
+// command; it just means the next one will be slower.
+func (c *metadataCache) Write(op Output, query string, args object, data []byte) {
+	if c == nil {
+		return
+	}
+	p := c.path(query, args)
+	if err := c.fs.MkdirAll(c.dir, 0700); err != nil {
+		op.Debug("cache: %s\n", err)
+		return
+	}
+	if err := c.fs.WriteFile(p+".tmp", data, 0600); err != nil {
+		op.Debug("cache: %s\n", err)
+		return
+	}
+	if err := c.fs.Rename(p+".tmp", p); err != nil {
+		op.Debug("cache: %s\n", err)
+	}
+}
+
+// invalidateCache forgets everything cached for the tenant in cfg, for all
+// users. Call it after changing objects, so the next listing sees the change.
+func invalidateCache(cfg *Config, fs fileSystem, op Output) {
+	// with a bad cache_ttl, nothing was cached
+	if c, _ := cacheFor(cfg, fs, op); c != nil {
+		if err := c.fs.RemoveAll(c.tenantDir); err != nil {
+			op.Debug("cache: %s\n", err)
+		}
+	}
+}
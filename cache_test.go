@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache(t *testing.T) {
+	const workspaces = `{"data":{"currentUser":{"workspaces":[{"id":"41042069","name":"The Stuff"}]}}}`
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, workspaces},
+		testRequest{"/v1/meta", 200, workspaces},
+		testRequest{"/v1/meta", 200, workspaces},
+	)
+	fix.cfg.CacheDir = "/cache"
+	list := func(i int) {
+		t.Helper()
+		infos, err := ObjectTypeWorkspace.List(fix.cfg, fix.fs, fix.op, fix.hc)
+		if err != nil || len(infos) != 1 || infos[0].Name != "The Stuff" {
+			t.Fatalf("list %d: unexpected result: %v %v", i, infos, err)
+		}
+	}
+	for i := 0; i != 3; i++ {
+		list(i)
+	}
+	if fix.rix != 1 {
+		t.Error("expected one request, then cache hits; got:", fix.rix)
+	}
+	if !strings.Contains(fix.op.DebugBuf.String(), "cache: hit") {
+		t.Error("expected cache hit in debug output:", fix.op.DebugBuf.String())
+	}
+	// an old response isn't used
+	c, err := cacheFor(fix.cfg, fix.fs, fix.op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix.fs.(fakeFs).Touch(c.path(gqlListWorkspace.q, object{}), time.Now().Add(-2*DefaultCacheTTL))
+	list(3)
+	if fix.rix != 2 {
+		t.Error("expected an expired cache to be re-read; got:", fix.rix)
+	}
+	fix.cfg.CacheRefresh = true
+	list(4)
+	fix.Assert()
+
+	// changing anything forgets the tenant's listings
+	invalidateCache(fix.cfg, fix.fs, fix.op)
+	if _, err := fix.fs.Stat(c.path(gqlListWorkspace.q, object{})); err == nil {
+		t.Error("expected the cache to be removed")
+	}
+}
+
+func TestMetadataCacheKeys(t *testing.T) {
+	fs := NewFakeFs()
+	op := NewCaptureOutput()
+	// turning the TTL off turns the cache off, and a bad TTL is an error
+	if c, err := cacheFor(&Config{CustomerIdStr: "1", SiteStr: "x.com", CacheDir: "/tmp", CacheTTL: "0s"}, fs, op); c != nil || err != nil {
+		t.Error("expected no cache with zero ttl:", c, err)
+	}
+	if _, err := cacheFor(&Config{CustomerIdStr: "1", SiteStr: "x.com", CacheDir: "/tmp", CacheTTL: "soon"}, fs, op); err == nil || !strings.Contains(err.Error(), "cache_ttl") {
+		t.Error("expected an error for a bad ttl:", err)
+	}
+	// other tenants and other users don't see each other's listings
+	path := func(customer, token string) string {
+		c, err := cacheFor(&Config{CustomerIdStr: customer, SiteStr: "x.com:4444", AuthtokenStr: token, CacheDir: "/tmp"}, fs, op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.path("q", nil)
+	}
+	if path("1", "a") == path("2", "a") {
+		t.Error("expected different tenants to have different cache paths")
+	}
+	if path("1", "a") == path("1", "b") {
+		t.Error("expected different users to have different cache paths")
+	}
+	if strings.Contains(path("1", "secret-token"), "secret-token") {
+		t.Error("expected the cache path not to contain the token")
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsApi         *pflag.FlagSet
+	flagApiFields    []string
+	flagApiInput     string
+	flagApiHeaders   []string
+	flagApiPaginate  bool
+	flagApiMaxPages  int
+	flagApiRawOutput bool
+)
+
+func init() {
+	flagsApi = pflag.NewFlagSet("api", pflag.ContinueOnError)
+	flagsApi.StringArrayVarP(&flagApiFields, "field", "f", nil, "add key=value to the query string (GET, DELETE) or JSON body (others)")
+	flagsApi.StringVarP(&flagApiInput, "input", "i", "", "file to send as the request body; '-' for stdin")
+	flagsApi.StringArrayVar(&flagApiHeaders, "header", nil, "add 'Name: value' header to the request")
+	flagsApi.BoolVarP(&flagApiPaginate, "paginate", "p", false, "follow Link rel=next headers, printing each page")
+	flagsApi.Lookup("paginate").NoOptDefVal = "true"
+	flagsApi.IntVar(&flagApiMaxPages, "max-pages", 100, "maximum number of pages to fetch with --paginate")
+	flagsApi.BoolVarP(&flagApiRawOutput, "raw", "r", false, "print the response body as received, without pretty-printing")
+	flagsApi.Lookup("raw").NoOptDefVal = "true"
+	RegisterCommand(&Command{
+		Name:  "api",
+		Help:  "Make an authenticated request to any Observe API endpoint.",
+		Flags: flagsApi,
+		Func:  cmdApi,
+	})
+}
+
+var (
+	ErrApiUsage          = ObserveError{Msg: "usage: observe api <method> </v1/path> [-f key=value ...] [--input file]"}
+	ErrApiUnknownMethod  = ObserveError{Msg: "the method must be one of GET, POST, PUT, PATCH, DELETE"}
+	ErrApiBadField       = ObserveError{Msg: "fields must be of the form key=value"}
+	ErrApiDuplicateField = ObserveError{Msg: "each field can only be given once"}
+	ErrApiFieldsAndInput = ObserveError{Msg: "--field cannot be combined with --input for a request with a body"}
+	ErrApiBadHeader      = ObserveError{Msg: "headers must be of the form 'Name: value'"}
+	ErrApiBadNextLink    = ObserveError{Msg: "the next page link points to a different site"}
+)
+
+func cmdApi(fa FuncArgs) error {
+	if len(fa.args) != 3 {
+		return ErrApiUsage
+	}
+	method := strings.ToUpper(fa.args[1])
+	path := fa.args[2]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	hasBody := false
+	switch method {
+	case "GET", "DELETE":
+	case "POST", "PUT", "PATCH":
+		hasBody = true
+	default:
+		return NewObserveError(ErrApiUnknownMethod, "%q", fa.args[1])
+	}
+	fields := map[string]string{}
+	for _, f := range flagApiFields {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || k == "" {
+			return NewObserveError(ErrApiBadField, "%q", f)
+		}
+		if _, has := fields[k]; has {
+			return NewObserveError(ErrApiDuplicateField, "%q", k)
+		}
+		fields[k] = v
+	}
+	hdrs := http.Header{}
+	for _, h := range flagApiHeaders {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(k) == "" {
+			return NewObserveError(ErrApiBadHeader, "%q", h)
+		}
+		hdrs.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	var body []byte
+	if flagApiInput != "" {
+		if hasBody && len(fields) > 0 {
+			return ErrApiFieldsAndInput
+		}
+		inpath := flagApiInput
+		if inpath == "-" {
+			inpath = "/dev/stdin"
+		}
+		var err error
+		if body, err = fa.fs.ReadFile(inpath); err != nil {
+			return ErrFileNotReadable.WithInner(err)
+		}
+	} else if hasBody {
+		obj := object{}
+		for k, v := range fields {
+			obj[k] = v
+		}
+		body = must(json.Marshal(obj))
+	}
+	q := Query(fa.hc).Config(fa.cfg).Output(fa.op).Path(path).Header(hdrs)
+	if !hasBody && len(fields) > 0 {
+		q = q.Args(fields)
+	}
+	for page := 1; ; page++ {
+		if body != nil {
+			q = q.Body(bytes.NewReader(body))
+		}
+		data, rhdr, err := q.Send(method)
+		if err != nil {
+			return err
+		}
+		printApiResponse(fa.op, data, flagApiRawOutput)
+		if !flagApiPaginate {
+			return nil
+		}
+		next := nextPageLink(rhdr.Get("Link"))
+		if next == "" {
+			return nil
+		}
+		if page >= flagApiMaxPages {
+			fa.op.Info("stopping after %d pages; use --max-pages to fetch more\n", page)
+			return nil
+		}
+		nextPath, err := sameSitePath(fa.cfg, next)
+		if err != nil {
+			return err
+		}
+		fa.op.Debug("next=%s\n", nextPath)
+		// the next link carries its own query string
+		q = Query(fa.hc).Config(fa.cfg).Output(fa.op).Path(nextPath).Header(hdrs)
+	}
+}
+
+// JSON is indented for reading; anything else is printed as-is.
+func printApiResponse(op io.Writer, data []byte, raw bool) {
+	if !raw && json.Valid(data) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err == nil {
+			buf.WriteByte('\n')
+			op.Write(buf.Bytes())
+			return
+		}
+	}
+	op.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		op.Write(newline)
+	}
+}
+
+var linkNextRex = regexp.MustCompile(`<([^>]*)>\s*;[^,]*\brel="?next"?`)
+
+// nextPageLink extracts the rel="next" URL from a RFC 8288 Link header.
+func nextPageLink(link string) string {
+	if m := linkNextRex.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// The credentials must not be sent anywhere other than the configured site,
+// so absolute links are only followed to the same host.
+func sameSitePath(cfg *Config, link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", NewObserveError(err, "bad next page link %q", link)
+	}
+	if u.Host != "" && u.Host != SiteUrl(cfg, "/").Host {
+		return "", NewObserveError(ErrApiBadNextLink, "%s", link)
+	}
+	ret := u.EscapedPath()
+	if u.RawQuery != "" {
+		ret = fmt.Sprintf("%s?%s", ret, u.RawQuery)
+	}
+	return ret, nil
+}
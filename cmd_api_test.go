@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdApiGet(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/document/\?name=notes\.md`, 200, `{"ok":true,"data":[{"meta":{"id":"o::1234:document:80000000022"}}]}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"api", "get", "/v1/document/", "-f", "name=notes.md"}, fix.hc)
+	fix.Assert()
+	if len(flagApiFields) != 0 {
+		t.Error("expected the flags to be reset after the command:", flagApiFields)
+	}
+	if !strings.Contains(fix.op.DebugBuf.String(), "Authorization=Bearer 12345 legit-authtoken") {
+		t.Error("unexpected debug output:", fix.op.DebugBuf.String())
+	}
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `{
+  "ok": true,
+  "data": [
+    {
+      "meta": {
+        "id": "o::1234:document:80000000022"
+      }
+    }
+  ]
+}
+`); diff != "" {
+		t.Error("unexpected data output:", diff)
+	}
+}
+
+func TestCmdApiErrors(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/nothing`, 404, `{"ok":false,"message":"no such thing"}`},
+	)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"api", "FETCH", "/v1/nothing"}, fix.hc)
+	})
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"api", "GET", "/v1/nothing"}, fix.hc)
+	})
+	// a repeated field would otherwise replace the earlier one
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"api", "GET", "/v1/nothing", "-f", "a=1", "-f", "a=2"}, fix.hc)
+	})
+	fix.Assert()
+	if !strings.Contains(fix.op.ErrorBuf.String(), "no such thing") {
+		t.Error("expected decoded error message:", fix.op.ErrorBuf.String())
+	}
+	if !strings.Contains(fix.op.ErrorBuf.String(), `"a": `+ErrApiDuplicateField.Msg) {
+		t.Error("expected duplicate field error:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdApiPaginate(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v1/things?page=2>; rel="next"`, srv.URL))
+			w.Write([]byte(`[1,2]`))
+		case "2":
+			w.Write([]byte(`[3]`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer srv.Close()
+	fix := startFixture(t)
+	fix.cfg.SiteStr = strings.Split(srv.URL, "//")[1]
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"api", "GET", "v1/things", "--paginate", "--raw"}, fix.hc)
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "[1,2]\n[3]\n"); diff != "" {
+		t.Error("unexpected data output:", diff)
+	}
+	if _, err := sameSitePath(fix.cfg, "https://elsewhere.example.com/v1/things?page=2"); err == nil {
+		t.Error("expected error following link to another site")
+	}
+}
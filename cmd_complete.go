@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"os"
+	"strings"
 
 	"github.com/posener/complete"
 	"github.com/spf13/pflag"
@@ -53,11 +54,14 @@ func cmdComplete(fa FuncArgs) error {
 		if c.Unlisted {
 			return
 		}
-		cm := complete.Command{
-			Flags: complete.Flags{},
-		}
-		if c.Flags != nil {
-			c.Flags.VisitAll(completeFlagSetter(cm.Flags))
+		cm := completeCommand(c)
+		switch c.Name {
+		case "list":
+			cm.Args = predictObjectArgs(fa, false)
+		case "create":
+			cm.Args = predictObjectArgs(fa, false)
+		case "get", "update", "delete":
+			cm.Args = predictObjectArgs(fa, true)
 		}
 		cmds[c.Name] = cm
 	})
@@ -78,9 +82,63 @@ func cmdComplete(fa FuncArgs) error {
 	return nil
 }
 
+func completeCommand(c *Command) complete.Command {
+	cm := complete.Command{
+		Flags: complete.Flags{},
+	}
+	if c.Flags != nil {
+		c.Flags.VisitAll(completeFlagSetter(cm.Flags))
+	}
+	if len(c.Subcommands) > 0 {
+		cm.Sub = complete.Commands{}
+		for _, sub := range c.Subcommands {
+			cm.Sub[sub.Name] = completeCommand(sub)
+		}
+	}
+	return cm
+}
+
 func completeFlagSetter(flags complete.Flags) func(*pflag.Flag) {
 	return func(pf *pflag.Flag) {
 		pred := complete.PredictAnything
 		flags["--"+pf.Name] = pred
 	}
 }
+
+// The first argument of the object commands is an object type, and the
+// second (for some) is an object ID. The IDs come from listing the objects,
+// which is usually answered by the metadata cache.
+func predictObjectArgs(fa FuncArgs, withId bool) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		var positional []string
+		for _, arg := range a.Completed {
+			if !strings.HasPrefix(arg, "-") {
+				positional = append(positional, arg)
+			}
+		}
+		switch {
+		case len(positional) == 0:
+			var ret []string
+			ForeachObjectType(func(ot ObjectType) {
+				ret = append(ret, ot.TypeName())
+			})
+			return ret
+		case len(positional) == 1 && withId:
+			ot := GetObjectType(positional[0])
+			if ot == nil || !ot.CanList() {
+				return nil
+			}
+			infos, err := ot.List(fa.cfg, fa.fs, fa.op, fa.hc)
+			if err != nil {
+				fa.op.Debug("complete: %s\n", err)
+				return nil
+			}
+			ret := make([]string, 0, len(infos))
+			for _, i := range infos {
+				ret = append(ret, i.Id)
+			}
+			return ret
+		}
+		return nil
+	})
+}
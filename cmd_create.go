@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsCreate          *pflag.FlagSet
+	flagCreateDefinition string
+	flagCreateOpal       string
+)
+
+func init() {
+	flagsCreate = pflag.NewFlagSet("create", pflag.ContinueOnError)
+	flagsCreate.StringVarP(&flagCreateDefinition, "definition", "d", "", "YAML or JSON file with the object definition; '-' for stdin")
+	flagsCreate.StringVar(&flagCreateOpal, "opal", "", "query file to create the object from, for objects like worksheets")
+	RegisterCommand(&Command{
+		Name:            "create",
+		Help:            "Create an object of some type from a definition file.",
+		Flags:           flagsCreate,
+		Func:            cmdCreate,
+		WorkspaceScoped: true,
+	})
+}
+
+var (
+	ErrCreateUsage  = ObserveError{Msg: "usage: observe create <object type> --definition <file> | --opal <file>"}
+	ErrCannotCreate = ObserveError{Msg: "cannot create this object type"}
+	ErrCreateNoOpal = ObserveError{Msg: "this object type can't be created from a query file"}
+)
+
+func cmdCreate(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrCreateUsage
+	}
+	otyp := GetObjectType(fa.args[1])
+	if otyp == nil {
+		return ErrUnknownObjectType
+	}
+	if !otyp.CanCreate() {
+		return ErrCannotCreate
+	}
+	if flagCreateOpal != "" {
+		if flagCreateDefinition != "" {
+			return ErrCreateUsage
+		}
+		return createFromQueryFile(fa, otyp)
+	}
+	in, err := parseInput(fa.fs, fa.op, flagCreateDefinition)
+	if err != nil {
+		return err
+	}
+	config, err := definitionConfig(otyp, in)
+	if err != nil {
+		return err
+	}
+	obj, err := otyp.Create(fa.cfg, fa.op, fa.hc, config)
+	if err != nil {
+		return NewObserveError(err, "create %s", otyp.TypeName())
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	return obj.PrintToYaml(fa.op, otyp, obj)
+}
+
+func createFromQueryFile(fa FuncArgs, otyp ObjectType) error {
+	oot, is := otyp.(opalObjectType)
+	if !is {
+		return ErrCreateNoOpal
+	}
+	text, err := LoadQueryTextFromFile(fa.fs, flagCreateOpal)
+	if err != nil {
+		return NewObserveError(err, "read %s", flagCreateOpal)
+	}
+	qf, err := parseQueryFile(text)
+	if err != nil {
+		return err
+	}
+	obj, err := oot.CreateFromQueryFile(fa, qf)
+	if err != nil {
+		return NewObserveError(err, "create %s", otyp.TypeName())
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	return obj.PrintToYaml(fa.op, otyp, obj)
+}
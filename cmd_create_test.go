@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdCreateUpdate(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"createRbacGroup":{"id":"o::101:rbacgroup:8000001003","name":"auditors","description":"read-only auditors"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"rbacGroup":{"id":"o::101:rbacgroup:8000001003","name":"auditors","description":"read-only auditors"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"updateRbacGroup":{"id":"o::101:rbacgroup:8000001003","name":"auditors","description":"external auditors"}}}`},
+	)
+	fix.fs.WriteFile("group.yaml", []byte("object:\n  type: rbacgroup\n  config:\n    name: auditors\n    description: read-only auditors\n"), 0644)
+	fix.fs.WriteFile("change.yaml", []byte("object:\n  config:\n    description: external auditors\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"create", "rbacgroup", "--definition", "group.yaml"}, fix.hc)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"update", "rbacgroup", "o::101:rbacgroup:8000001003", "--definition", "change.yaml"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `object:
+  type: "rbacgroup"
+  id: "o::101:rbacgroup:8000001003"
+  config:
+    name: "auditors"
+    description: "read-only auditors"
+object:
+  type: "rbacgroup"
+  id: "o::101:rbacgroup:8000001003"
+  config:
+    name: "auditors"
+    description: "external auditors"
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdCreateErrors(t *testing.T) {
+	fix := startFixture(t)
+	fix.fs.WriteFile("bad.yaml", []byte("object:\n  config:\n    id: o::101:rbacgroup:1\n"), 0644)
+	fix.fs.WriteFile("other.yaml", []byte("object:\n  type: workspace\n  config:\n    name: x\n"), 0644)
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"create", "workspace", "--definition", "other.yaml"}, ErrCannotCreate.Msg},
+		{[]string{"create", "rbacgroup", "--definition", "bad.yaml"}, `rbacgroup "id": not a configurable property`},
+		{[]string{"create", "rbacgroup", "--definition", "other.yaml"}, ErrDefinitionWrongType.Msg},
+	} {
+		mustPanic(t, func() {
+			RunCommandWithConfig(fix.cfg, fix.fs, fix.op, tc.args, fix.hc)
+		})
+		if !strings.Contains(fix.op.ErrorBuf.String(), tc.err) {
+			t.Errorf("%v: expected %q in error output: %s", tc.args, tc.err, fix.op.ErrorBuf.String())
+		}
+	}
+}
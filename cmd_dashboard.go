@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	flagsDashboardImport     *pflag.FlagSet
+	flagDashboardDefinition  string
+	flagDashboardDatasetsMap string
+	flagDashboardId          string
+)
+
+func init() {
+	flagsDashboardImport = pflag.NewFlagSet("import", pflag.ContinueOnError)
+	flagsDashboardImport.StringVarP(&flagDashboardDefinition, "definition", "d", "", "YAML or JSON file with the dashboard, as get prints it; '-' for stdin")
+	flagsDashboardImport.StringVar(&flagDashboardDatasetsMap, "map", "", "YAML or JSON file mapping the dataset IDs of the dashboard to IDs or workspace.paths here")
+	flagsDashboardImport.StringVar(&flagDashboardId, "id", "", "dashboard to update, rather than the one with the same name in the workspace")
+	RegisterCommand(&Command{
+		Name: "dashboard",
+		Help: "Move dashboards between workspaces and tenants.",
+		Subcommands: []*Command{
+			{
+				Name:            "import",
+				Help:            "Create or update a dashboard from the output of get, rewriting dataset IDs.",
+				Flags:           flagsDashboardImport,
+				Func:            cmdDashboardImport,
+				WorkspaceScoped: true,
+			},
+		},
+	})
+}
+
+var (
+	ErrDashboardImportUsage = ObserveError{Msg: "usage: observe dashboard import --definition <file> [--map <file>] [--id <dashboard>] [--workspace <workspace>]"}
+	ErrDashboardMapFormat   = ObserveError{Msg: "the --map file must map dataset IDs to dataset IDs or workspace.paths"}
+	ErrDashboardUnmapped    = ObserveError{Msg: "they can't be found here; map them with --map"}
+	ErrDashboardAmbiguous   = ObserveError{Msg: "more than one dashboard in the workspace has the name; choose one with --id"}
+)
+
+// readDatasetMap reads a file like
+//
+//	41007104: 42001234
+//	41007105: Default.kubernetes/Container Logs
+func readDatasetMap(fa FuncArgs, path string) (map[string]string, error) {
+	data, err := fa.fs.ReadFile(path)
+	if err != nil {
+		return nil, NewObserveError(err, "read %s", path)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, NewObserveError(err, "read %s", path)
+	}
+	ret := map[string]string{}
+	for k, v := range raw {
+		switch v.(type) {
+		case string, int:
+			ret[k] = fmt.Sprint(v)
+		default:
+			return nil, NewObserveError(ErrDashboardMapFormat, "%s", k)
+		}
+	}
+	return ret, nil
+}
+
+// datasetTargets works out the ID here of each dataset ID in the dashboard:
+// by --map first, and then by the workspace.path get recorded for it. All of
+// them are found, or none.
+func datasetTargets(fa FuncArgs, ids []string, mapping map[string]string, sourcePaths map[string]string) (map[string]string, error) {
+	var here map[string]string // workspace.path to ID, when needed
+	ret := map[string]string{}
+	var missing []string
+	for _, id := range ids {
+		target, has := mapping[id]
+		if !has {
+			target, has = sourcePaths[id]
+		}
+		if !has {
+			missing = append(missing, id)
+			continue
+		}
+		if _, err := strconv.ParseInt(target, 10, 64); err == nil {
+			ret[id] = target
+			continue
+		}
+		if here == nil {
+			paths, err := datasetPaths(fa.cfg, fa.fs, fa.op, fa.hc)
+			if err != nil {
+				return nil, err
+			}
+			here = map[string]string{}
+			for hid, p := range paths {
+				here[p] = hid
+			}
+		}
+		if hid, has := here[target]; has {
+			ret[id] = hid
+			fa.op.Info("dataset %s is %s (%s)\n", id, hid, target)
+		} else {
+			missing = append(missing, fmt.Sprintf("%s (%s)", id, target))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, NewObserveError(ErrDashboardUnmapped, "datasets %s", strings.Join(missing, ", "))
+	}
+	return ret, nil
+}
+
+func cmdDashboardImport(fa FuncArgs) error {
+	if len(fa.args) != 1 || flagDashboardDefinition == "" {
+		return ErrDashboardImportUsage
+	}
+	in, err := parseInput(fa.fs, fa.op, flagDashboardDefinition)
+	if err != nil {
+		return err
+	}
+	config, err := definitionConfig(ObjectTypeDashboard, in)
+	if err != nil {
+		return err
+	}
+	sourcePaths := map[string]string{}
+	if ds, is := in.Object["datasets"].(object); is {
+		for k, v := range ds {
+			sourcePaths[k] = fmt.Sprint(v)
+		}
+	}
+	var mapping map[string]string
+	if flagDashboardDatasetsMap != "" {
+		if mapping, err = readDatasetMap(fa, flagDashboardDatasetsMap); err != nil {
+			return err
+		}
+	}
+	ws, err := ResolveWorkspace(fa.cfg, fa.fs, fa.op, fa.hc)
+	if err != nil {
+		return err
+	}
+	targets, err := datasetTargets(fa, dashboardDatasetIds(config), mapping, sourcePaths)
+	if err != nil {
+		return err
+	}
+	walkDatasetIds(config, func(id string) string {
+		return targets[id]
+	})
+	config["workspaceId"] = ws.Id
+	// the folder is of the workspace the dashboard came from
+	delete(config, "folderId")
+
+	id := flagDashboardId
+	if id == "" {
+		dashboards, err := ObjectTypeDashboard.List(fa.cfg, fa.fs, fa.op, fa.hc)
+		if err != nil {
+			return NewObserveError(err, "list dashboards")
+		}
+		var ids []string
+		for _, d := range dashboards {
+			if d.Name == fmt.Sprint(config["name"]) && strconv.FormatInt(d.Object.(*objectDashboard).WorkspaceId, 10) == ws.Id {
+				ids = append(ids, d.Id)
+			}
+		}
+		switch len(ids) {
+		case 0:
+		case 1:
+			id = ids[0]
+		default:
+			return NewObserveError(ErrDashboardAmbiguous, "%q is %s", config["name"], strings.Join(sorted(ids), ", "))
+		}
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	var obj ObjectInstance
+	verb := "created"
+	if id == "" {
+		obj, err = ObjectTypeDashboard.Create(fa.cfg, fa.op, fa.hc, config)
+	} else {
+		verb = "updated"
+		obj, err = ObjectTypeDashboard.Update(fa.cfg, fa.op, fa.hc, id, config)
+	}
+	if err != nil {
+		return NewObserveError(err, "import dashboard")
+	}
+	d := obj.(*objectDashboard)
+	fmt.Fprintf(fa.op, "%s dashboard %q (%d) in workspace %q, with %d datasets\n", verb, d.Name, d.Id, ws.Name, len(targets))
+	return nil
+}
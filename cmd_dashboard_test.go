@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testDashboard = `{"id":"41000700","name":"Errors","workspaceId":"41000001","folderId":"41000300","description":null,"createdBy":"3",` +
+	`"layout":{"gridLayout":{"sections":[{"items":[{"card":{"stageId":"errors","cardType":"stage"}}]}]}},` +
+	`"stages":[{"id":"errors","pipeline":"filter status >= 500","layout":null,"input":[{"inputName":"_","inputRole":"Data","datasetId":"41007104","datasetPath":null,"stageId":null}]}],` +
+	`"parameters":[{"id":"svc","name":"Service","defaultValue":null,"valueKind":{"type":"RESOURCE","keyForDatasetId":"41007105"}}]}`
+
+const testDashboardWorkspaces = `{"data":{"currentUser":{"workspaces":[{"id":"41000001","name":"Default"}]}}}`
+
+const testDashboardYaml = `object:
+  type: "dashboard"
+  id: 41000700
+  config:
+    name: "Errors"
+    workspaceId: 41000001
+    folderId: 41000300
+    description: 
+    layout:
+      gridLayout:
+        sections:
+          - items:
+              - card:
+                  cardType: stage
+                  stageId: errors
+    stages:
+      - id: errors
+        input:
+          - datasetId: "41007104"
+            datasetPath: null
+            inputName: _
+            inputRole: Data
+            stageId: null
+        layout: null
+        pipeline: filter status >= 500
+    parameters:
+      - defaultValue: null
+        id: svc
+        name: Service
+        valueKind:
+          keyForDatasetId: "41007105"
+          type: RESOURCE
+  state:
+    createdBy: 3
+  datasets:
+    "41007104": Default.logs
+    "41007105": Default.k8s/Service
+`
+
+func TestCmdGetDashboard(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"dashboard":` + testDashboard + `}}`},
+		testRequest{"/v1/meta", 200, testDashboardWorkspaces},
+		testRequest{"/v1/meta", 200, `{"data":{"datasetSearch":[{"dataset":{"id":"41007104","name":"logs","path":"logs","workspaceId":"41000001"}},{"dataset":{"id":"41007105","name":"Service","path":"k8s/Service","workspaceId":"41000001"}}]}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"get", "dashboard", "41000700"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), testDashboardYaml); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdDashboardImport(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"42000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"42000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"datasetSearch":[{"dataset":{"id":"42000004","name":"logs","path":"logs","workspaceId":"42000001"}}]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"dashboardSearch":{"dashboards":[{"dashboard":{"id":"42000700","name":"Errors","workspaceId":"42000009"}}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"saveDashboard":{"id":"42000701","name":"Errors","workspaceId":"42000001","description":null,"layout":null,"stages":null,"parameters":null}}}`},
+	)
+	fix.fs.WriteFile("errors.yaml", []byte(testDashboardYaml), 0644)
+	fix.fs.WriteFile("map.yaml", []byte("41007105: 42000005\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"dashboard", "import", "-d", "errors.yaml", "--map", "map.yaml"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "created dashboard \"Errors\" (42000701) in workspace \"Default\", with 2 datasets\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "dataset 41007104 is 42000004 (Default.logs)") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+}
+
+func TestCmdDashboardImportUnmapped(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"42000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"42000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"datasetSearch":[]}}`},
+	)
+	fix.fs.WriteFile("errors.yaml", []byte(testDashboardYaml), 0644)
+	// nothing is saved when a dataset can't be found
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"dashboard", "import", "-d", "errors.yaml"}, fix.hc)
+	})
+	fix.Assert()
+	if !strings.Contains(fix.op.ErrorBuf.String(), "datasets 41007104 (Default.logs), 41007105 (Default.k8s/Service): "+ErrDashboardUnmapped.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdDashboardImportAmbiguous(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"42000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"42000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"datasetSearch":[{"dataset":{"id":"42000004","name":"logs","path":"logs","workspaceId":"42000001"}}]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"dashboardSearch":{"dashboards":[{"dashboard":{"id":"42000702","name":"Errors","workspaceId":"42000001"}},{"dashboard":{"id":"42000700","name":"Errors","workspaceId":"42000001"}}]}}}`},
+	)
+	fix.fs.WriteFile("errors.yaml", []byte(testDashboardYaml), 0644)
+	fix.fs.WriteFile("map.yaml", []byte("41007105: 42000005\n"), 0644)
+	// nothing is saved when it's not clear which dashboard to replace
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"dashboard", "import", "-d", "errors.yaml", "--map", "map.yaml"}, fix.hc)
+	})
+	fix.Assert()
+	if !strings.Contains(fix.op.ErrorBuf.String(), `"Errors" is 42000700, 42000702: `+ErrDashboardAmbiguous.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestWalkDatasetIds(t *testing.T) {
+	parts := object{
+		"stages":     array{object{"input": array{object{"datasetId": "1", "stageId": nil}, object{"datasetId": nil, "stageId": "a"}}}},
+		"parameters": array{object{"valueKind": object{"keyForDatasetId": 2}}},
+	}
+	if diff := cmp.Diff(dashboardDatasetIds(parts), []string{"1", "2"}); diff != "" {
+		t.Error("unexpected IDs:", diff)
+	}
+	walkDatasetIds(parts, func(id string) string { return "4" + id })
+	want := object{
+		"stages":     array{object{"input": array{object{"datasetId": "41", "stageId": nil}, object{"datasetId": nil, "stageId": "a"}}}},
+		"parameters": array{object{"valueKind": object{"keyForDatasetId": int64(42)}}},
+	}
+	if diff := cmp.Diff(parts, want); diff != "" {
+		t.Error("unexpected rewrite:", diff)
+	}
+}
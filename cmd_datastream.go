@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsDatastreamDisable        *pflag.FlagSet
+	flagsDatastreamTokenRotate    *pflag.FlagSet
+	flagsDatastreamTokenDisable   *pflag.FlagSet
+	flagDatastreamTokenName       string
+	flagDatastreamTokenDisableOld time.Duration
+)
+
+func init() {
+	flagsDatastreamDisable = pflag.NewFlagSet("disable", pflag.ContinueOnError)
+	flagsDatastreamTokenRotate = pflag.NewFlagSet("rotate", pflag.ContinueOnError)
+	flagsDatastreamTokenRotate.StringVar(&flagDatastreamTokenName, "name", "", "name of the new token; the name of the old one by default")
+	flagsDatastreamTokenRotate.DurationVar(&flagDatastreamTokenDisableOld, "disable-old-after", 0, "wait this long, such as 10m, and then disable the old token")
+	flagsDatastreamTokenDisable = pflag.NewFlagSet("disable", pflag.ContinueOnError)
+	RegisterCommand(&Command{
+		Name: "datastream",
+		Help: "Disable datastreams, and rotate and disable their tokens.",
+		Subcommands: []*Command{
+			{
+				Name:  "disable",
+				Help:  "Stop a datastream from accepting data.",
+				Flags: flagsDatastreamDisable,
+				Func:  cmdDatastreamDisable,
+			},
+			{
+				Name: "token",
+				Help: "Rotate and disable datastream tokens.",
+				Subcommands: []*Command{
+					{
+						Name:  "rotate",
+						Help:  "Create a new token to replace one, and optionally disable the old one after a grace period.",
+						Flags: flagsDatastreamTokenRotate,
+						Func:  cmdDatastreamTokenRotate,
+					},
+					{
+						Name:  "disable",
+						Help:  "Stop a token from being accepted.",
+						Flags: flagsDatastreamTokenDisable,
+						Func:  cmdDatastreamTokenDisable,
+					},
+				},
+			},
+		},
+	})
+}
+
+var (
+	ErrDatastreamDisableUsage      = ObserveError{Msg: "usage: observe datastream disable <datastream>"}
+	ErrDatastreamTokenRotateUsage  = ObserveError{Msg: "usage: observe datastream token rotate <token> [--name <name>] [--disable-old-after <duration>]"}
+	ErrDatastreamTokenDisableUsage = ObserveError{Msg: "usage: observe datastream token disable <token>"}
+	ErrDatastreamTokenGrace        = ObserveError{Msg: "--disable-old-after can't be negative"}
+)
+
+// resolveDatastream takes a datastream ID, or a name, which is looked up.
+func resolveDatastream(fa FuncArgs, idOrName string) (string, error) {
+	if _, err := strconv.ParseInt(idOrName, 10, 64); err == nil {
+		return idOrName, nil
+	}
+	info, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeDatastream, idOrName)
+	if err != nil {
+		return "", err
+	}
+	return info.Id, nil
+}
+
+func cmdDatastreamDisable(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrDatastreamDisableUsage
+	}
+	id, err := resolveDatastream(fa, fa.args[1])
+	if err != nil {
+		return err
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	obj, err := ObjectTypeDatastream.Update(fa.cfg, fa.op, fa.hc, id, object{"disabled": true})
+	if err != nil {
+		return NewObserveError(err, "disable datastream %s", id)
+	}
+	ds := obj.(*objectDatastream)
+	fmt.Fprintf(fa.op, "disabled datastream %q (%d)\n", ds.Name, ds.Id)
+	return nil
+}
+
+func disableDatastreamToken(fa FuncArgs, id string) (*objectDatastreamtoken, error) {
+	obj, err := ObjectTypeDatastreamtoken.Update(fa.cfg, fa.op, fa.hc, id, object{"disabled": true})
+	if err != nil {
+		return nil, NewObserveError(err, "disable token %s", id)
+	}
+	return obj.(*objectDatastreamtoken), nil
+}
+
+func cmdDatastreamTokenDisable(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrDatastreamTokenDisableUsage
+	}
+	info, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeDatastreamtoken, fa.args[1])
+	if err != nil {
+		return err
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	t, err := disableDatastreamToken(fa, info.Id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(fa.op, "disabled token %q (%s)\n", t.Name, t.Id)
+	return nil
+}
+
+// cmdDatastreamTokenRotate creates the new token next to the old one, so that
+// collectors can be moved over before the old one stops working. The secret
+// of the new token goes to the output by itself, so it can be captured by a
+// script; it can't be read back later.
+func cmdDatastreamTokenRotate(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrDatastreamTokenRotateUsage
+	}
+	if flagDatastreamTokenDisableOld < 0 {
+		return ErrDatastreamTokenGrace
+	}
+	info, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeDatastreamtoken, fa.args[1])
+	if err != nil {
+		return err
+	}
+	old := info.Object.(*objectDatastreamtoken)
+	input := object{"datastreamId": old.DatastreamId, "name": old.Name}
+	if flagDatastreamTokenName != "" {
+		input["name"] = flagDatastreamTokenName
+	}
+	if old.Description != nil {
+		input["description"] = *old.Description
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	obj, err := ObjectTypeDatastreamtoken.Create(fa.cfg, fa.op, fa.hc, input)
+	if err != nil {
+		return NewObserveError(err, "create token for datastream %d", old.DatastreamId)
+	}
+	t := obj.(*objectDatastreamtoken)
+	if t.Secret == nil {
+		return NewObserveError(nil, "token %s was created without a secret", t.Id)
+	}
+	fa.op.Info("created token %q (%s) for datastream %d; this is the only time its secret is shown\n", t.Name, t.Id, t.DatastreamId)
+	fmt.Fprintln(fa.op, *t.Secret)
+	if !flagsDatastreamTokenRotate.Lookup("disable-old-after").Changed {
+		fa.op.Info("token %s is still enabled; disable it with 'observe datastream token disable %s' once collectors use the new one\n", old.Id, old.Id)
+		return nil
+	}
+	fa.op.Info("waiting %s for collectors to switch, before disabling token %s\n", flagDatastreamTokenDisableOld, old.Id)
+	time.Sleep(flagDatastreamTokenDisableOld)
+	if _, err := disableDatastreamToken(fa, old.Id); err != nil {
+		return err
+	}
+	fa.op.Info("disabled token %q (%s)\n", old.Name, old.Id)
+	return nil
+}
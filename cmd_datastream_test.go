@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testDatastream      = `{"id":"41000900","name":"kubernetes","workspaceId":"41000001","description":null,"disabled":false}`
+	testDatastreamToken = `{"id":"ds1aBcDeFgHiJk","name":"prod collectors","datastreamId":"41000900","description":"agents in prod","disabled":false}`
+	testDatastreamNew   = `{"id":"ds1LmNoPqRsTuV","name":"prod collectors","datastreamId":"41000900","description":"agents in prod","disabled":false}`
+)
+
+func TestCmdCreateDatastreamtoken(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"createDatastreamToken":` + strings.TrimSuffix(testDatastreamNew, "}") + `,"secret":"ds1LmNoPqRsTuV:s3cr3t"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"datastreamToken":` + testDatastreamNew + `}}`},
+	)
+	fix.fs.WriteFile("token.yaml", []byte("object:\n  type: datastreamtoken\n  config:\n    name: prod collectors\n    datastreamId: 41000900\n    description: agents in prod\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"create", "datastreamtoken", "--definition", "token.yaml"}, fix.hc)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"get", "datastreamtoken", "ds1LmNoPqRsTuV"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `object:
+  type: "datastreamtoken"
+  id: "ds1LmNoPqRsTuV"
+  config:
+    name: "prod collectors"
+    datastreamId: 41000900
+    description: "agents in prod"
+    disabled: false
+  state:
+    secret: "ds1LmNoPqRsTuV:s3cr3t"
+object:
+  type: "datastreamtoken"
+  id: "ds1LmNoPqRsTuV"
+  config:
+    name: "prod collectors"
+    datastreamId: 41000900
+    description: "agents in prod"
+    disabled: false
+  state:
+    secret: 
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdDatastreamDisable(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"datastreams":[` + testDatastream + `]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"datastream":` + testDatastream + `}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"updateDatastream":` + strings.Replace(testDatastream, `"disabled":false`, `"disabled":true`, 1) + `}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"datastream", "disable", "Kubernetes"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "disabled datastream \"kubernetes\" (41000900)\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdDatastreamTokenRotate(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"datastreamTokens":[` + testDatastreamToken + `]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"createDatastreamToken":` + strings.TrimSuffix(testDatastreamNew, "}") + `,"secret":"ds1LmNoPqRsTuV:s3cr3t"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"datastreamToken":` + testDatastreamToken + `}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"updateDatastreamToken":` + strings.Replace(testDatastreamToken, `"disabled":false`, `"disabled":true`, 1) + `}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"datastream", "token", "rotate", "ds1aBcDeFgHiJk", "--disable-old-after", "0s"}, fix.hc)
+	fix.Assert()
+	// only the secret goes to the output, for scripts
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "ds1LmNoPqRsTuV:s3cr3t\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	for _, s := range []string{`created token "prod collectors" (ds1LmNoPqRsTuV) for datastream 41000900`, `disabled token "prod collectors" (ds1aBcDeFgHiJk)`} {
+		if !strings.Contains(fix.op.InfoBuf.String(), s) {
+			t.Errorf("expected %q in info output: %s", s, fix.op.InfoBuf.String())
+		}
+	}
+}
+
+func TestCmdDatastreamTokenRotateKeepsOld(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"datastreamTokens":[` + testDatastreamToken + `]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"createDatastreamToken":` + strings.TrimSuffix(strings.Replace(testDatastreamNew, "prod collectors", "prod collectors 2", 1), "}") + `,"secret":"ds1LmNoPqRsTuV:s3cr3t"}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"datastream", "token", "rotate", "prod collectors", "--name", "prod collectors 2"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "ds1LmNoPqRsTuV:s3cr3t\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "token ds1aBcDeFgHiJk is still enabled") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+}
+
+func TestCmdDatastreamTokenRotateUsage(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"datastream", "token", "rotate"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrDatastreamTokenRotateUsage.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
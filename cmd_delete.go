@@ -9,9 +9,10 @@ var (
 
 func init() {
 	RegisterCommand(&Command{
-		Name: "delete",
-		Help: "Delete an object identified by type and id.",
-		Func: cmdDelete,
+		Name:            "delete",
+		Help:            "Delete an object identified by type and id.",
+		Func:            cmdDelete,
+		WorkspaceScoped: true,
 	})
 }
 
@@ -34,6 +35,7 @@ func cmdDelete(fa FuncArgs) error {
 	if err != nil {
 		return NewObserveError(err, "delete %s", otyp.TypeName())
 	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
 	_, err = fmt.Fprintf(fa.op, "deleted %s\n", fa.args[2])
 	return err
 }
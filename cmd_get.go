@@ -11,22 +11,27 @@ import (
 )
 
 var (
-	flagsGet *pflag.FlagSet
+	flagsGet    *pflag.FlagSet
+	flagGetOpal bool
 )
 
 func init() {
 	flagsGet = pflag.NewFlagSet("get", pflag.ContinueOnError)
+	flagsGet.BoolVar(&flagGetOpal, "opal", false, "print the OPAL stages of the object as a query file, for objects like worksheets")
+	flagsGet.Lookup("opal").NoOptDefVal = "true"
 	RegisterCommand(&Command{
-		Name:  "get",
-		Help:  "Get the state of a particular object identified by type and id.",
-		Flags: flagsGet,
-		Func:  cmdGet,
+		Name:            "get",
+		Help:            "Get the state of a particular object identified by type and id.",
+		Flags:           flagsGet,
+		Func:            cmdGet,
+		WorkspaceScoped: true,
 	})
 }
 
 var (
-	ErrGetUsage  = ObserveError{Msg: "usage: observe get <object type> <object id>"}
+	ErrGetUsage  = ObserveError{Msg: "usage: observe get <object type> <object id> [--opal]"}
 	ErrCannotGet = ObserveError{Msg: "cannot get this object type"}
+	ErrNoOpal    = ObserveError{Msg: "this object type has no OPAL stages"}
 )
 
 func cmdGet(fa FuncArgs) error {
@@ -45,6 +50,13 @@ func cmdGet(fa FuncArgs) error {
 	if err != nil {
 		return NewObserveError(err, "get object type:%s", otyp.TypeName())
 	}
+	if flagGetOpal {
+		oo, is := obj.(opalObject)
+		if !is {
+			return ErrNoOpal
+		}
+		return writeQueryFile(fa.op, oo.QueryFile())
+	}
 	return obj.PrintToYaml(fa.op, otyp, obj)
 }
 
@@ -75,6 +87,12 @@ func printToYamlFromJson(op Output, otyp ObjectType, obj object) error {
 // Note: we probably should use yaml/v3, or some more introspection based
 // discovery.
 func printToYamlFromObjectInstance(op Output, otyp ObjectType, obj ObjectInstance) error {
+	return printToYamlWithConfig(op, otyp, obj, nil)
+}
+
+// printToYamlWithConfig is printToYamlFromObjectInstance for objects with
+// config that isn't properties; moreConfig prints it after the properties.
+func printToYamlWithConfig(op Output, otyp ObjectType, obj ObjectInstance, moreConfig func() error) error {
 	fmt.Fprintf(op, "object:\n")
 	fmt.Fprintf(op, "  type: %q\n", otyp.TypeName())
 	vals := obj.GetValues()
@@ -103,6 +121,11 @@ func printToYamlFromObjectInstance(op Output, otyp ObjectType, obj ObjectInstanc
 				fmt.Fprintf(op, "    %s: %s\n", p.Name, vstr)
 			}
 		}
+		if moreConfig != nil {
+			if err := moreConfig(); err != nil {
+				return err
+			}
+		}
 	}
 	if hasState {
 		fmt.Fprintf(op, "  state:\n")
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsGql        *pflag.FlagSet
+	flagGqlText     string
+	flagGqlFile     string
+	flagGqlVars     []string
+	flagGqlVarsFile string
+	flagGqlPath     string
+)
+
+func init() {
+	flagsGql = pflag.NewFlagSet("gql", pflag.ContinueOnError)
+	flagsGql.StringVarP(&flagGqlText, "query", "q", "", "GraphQL query or mutation text")
+	flagsGql.StringVarP(&flagGqlFile, "file", "f", "", "file containing GraphQL query text")
+	flagsGql.StringArrayVar(&flagGqlVars, "var", nil, "set string variable key=value")
+	flagsGql.StringVar(&flagGqlVarsFile, "vars-file", "", "JSON file with an object of variables")
+	flagsGql.StringVar(&flagGqlPath, "path", "", "print only the part of 'data' at this dotted path")
+	RegisterCommand(&Command{
+		Name:  "gql",
+		Help:  "Run a GraphQL query against the Observe metadata API.",
+		Flags: flagsGql,
+		Func:  cmdGql,
+	})
+}
+
+var ErrGqlUsage = ObserveError{Msg: "usage: observe gql --query text [--var key=value ...] [--vars-file file] [--path a.b.c]"}
+var ErrGqlNeedQueryOrFile = ObserveError{Msg: "need one of --query and --file for the GraphQL text"}
+var ErrGqlOnlyOneQueryOrFile = ObserveError{Msg: "only one of --query and --file may be specified"}
+var ErrGqlBadVar = ObserveError{Msg: "variables must be of the form key=value"}
+var ErrGqlBadVarsFile = ObserveError{Msg: "the --vars-file must contain a JSON object"}
+
+func cmdGql(fa FuncArgs) error {
+	if len(fa.args) != 1 {
+		return ErrGqlUsage
+	}
+	var queryText string
+	switch CountFlags(flagsGql, "query", "file") {
+	default:
+		return ErrGqlOnlyOneQueryOrFile
+	case 0:
+		return ErrGqlNeedQueryOrFile
+	case 1:
+		if flagsGql.Lookup("file").Changed {
+			data, err := fa.fs.ReadFile(flagGqlFile)
+			if err != nil {
+				return ErrFileNotReadable.WithInner(err)
+			}
+			queryText = string(data)
+		} else {
+			queryText = flagGqlText
+		}
+	}
+	vars, err := gqlVariables(fa.fs, flagGqlVarsFile, flagGqlVars)
+	if err != nil {
+		return err
+	}
+	// the extraction starts at 'data', but the user doesn't have to say so
+	path := []string{"data"}
+	if flagGqlPath != "" {
+		path = append(path, strings.Split(strings.TrimPrefix(flagGqlPath, "data."), ".")...)
+	}
+	ret, err := compileGqlQuery(queryText, path...).query(fa.cfg, fa.op, fa.hc, vars)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(fa.op)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ret)
+}
+
+// gqlVariables loads the --vars-file, if any, and then applies each --var on
+// top of it. Values given with --var are always strings; use the file for
+// numbers, booleans, and structured input.
+func gqlVariables(fs fileSystem, varsFile string, kvs []string) (object, error) {
+	vars := object{}
+	if varsFile != "" {
+		data, err := fs.ReadFile(varsFile)
+		if err != nil {
+			return nil, ErrFileNotReadable.WithInner(err)
+		}
+		if err := json.Unmarshal(data, &vars); err != nil {
+			return nil, ErrGqlBadVarsFile.WithInner(err)
+		}
+	}
+	for _, kv := range kvs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, NewObserveError(ErrGqlBadVar, "%q", kv)
+		}
+		vars[k] = v
+	}
+	return vars, nil
+}
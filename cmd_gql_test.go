@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdGql(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/meta`, 200, `{"data":{"workspaces":[{"id":"41000001","label":"Default"}]}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"gql", "-q", "query { workspaces { id label } }", "--path", "workspaces.0.label"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "\"Default\"\n"); diff != "" {
+		t.Error("unexpected data output:", diff)
+	}
+}
+
+func TestCmdGqlErrors(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/meta`, 200, `{"errors":[
+			{"message":"no such field","path":["workspaces",0,"bogus"],"locations":[{"line":1,"column":27}]},
+			{"message":"access denied"}
+		]}`},
+	)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"gql", "-q", "query { workspaces { bogus } }"}, fix.hc)
+	})
+	fix.Assert()
+	for _, want := range []string{
+		"2 errors:",
+		"no such field (path workspaces.0.bogus, line 1 column 27)",
+		"access denied",
+	} {
+		if !strings.Contains(fix.op.ErrorBuf.String(), want) {
+			t.Errorf("expected %q in error output: %s", want, fix.op.ErrorBuf.String())
+		}
+	}
+}
+
+func TestGqlVariables(t *testing.T) {
+	fix := startFixture(t)
+	if err := fix.fs.WriteFile("vars.json", []byte(`{"id":41000001,"name":"from-file"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vars, err := gqlVariables(fix.fs, "vars.json", []string{"name=from-flag", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(vars, object{"id": float64(41000001), "name": "from-flag", "empty": ""}); diff != "" {
+		t.Error("unexpected variables:", diff)
+	}
+	if _, err := gqlVariables(fix.fs, "", []string{"novalue"}); err == nil {
+		t.Error("expected error for variable without value")
+	}
+}
@@ -21,10 +21,11 @@ func init() {
 	flagsList.Lookup("json").NoOptDefVal = "true"
 	flagsList.IntVarP(&flagListColWidth, "col-width", "w", 0, "maximum column width; 0 for unlimited")
 	RegisterCommand(&Command{
-		Name:  "list",
-		Help:  "List objects of a particular type, optionally matching a substring.",
-		Flags: flagsList,
-		Func:  cmdList,
+		Name:            "list",
+		Help:            "List objects of a particular type, optionally matching a substring.",
+		Flags:           flagsList,
+		Func:            cmdList,
+		WorkspaceScoped: true,
 	})
 }
 
@@ -50,7 +51,7 @@ func cmdList(fa FuncArgs) error {
 		match = strings.ToLower(fa.args[2])
 		fa.op.Debug("match=%s\n", match)
 	}
-	infos, err := otyp.List(fa.cfg, fa.op, fa.hc)
+	infos, err := otyp.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
 		return NewObserveError(err, "list objects")
 	}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsMonitorMute          *pflag.FlagSet
+	flagsMonitorUnmute        *pflag.FlagSet
+	flagsMonitorTest          *pflag.FlagSet
+	flagMonitorFor            time.Duration
+	flagMonitorReason         string
+	flagMonitorTestStartTime  string
+	flagMonitorTestEndTime    string
+	flagMonitorTestRelative   time.Duration
+	flagMonitorTestTimeColumn string
+)
+
+func init() {
+	flagsMonitorMute = pflag.NewFlagSet("mute", pflag.ContinueOnError)
+	flagsMonitorMute.DurationVar(&flagMonitorFor, "for", 0, "how long to mute the monitor, such as 30m or 2h")
+	flagsMonitorMute.StringVar(&flagMonitorReason, "reason", "", "why the monitor is muted, shown to others")
+	flagsMonitorUnmute = pflag.NewFlagSet("unmute", pflag.ContinueOnError)
+	flagsMonitorTest = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagsMonitorTest.StringVarP(&flagMonitorTestStartTime, "start-time", "s", "", "start time of the window to test over")
+	flagsMonitorTest.StringVarP(&flagMonitorTestEndTime, "end-time", "e", "", "end time of the window to test over")
+	flagsMonitorTest.DurationVarP(&flagMonitorTestRelative, "relative", "r", 0, "duration of the window, anchored at either end")
+	flagsMonitorTest.StringVar(&flagMonitorTestTimeColumn, "time-column", "", "column with the time of each point; guessed by default")
+	RegisterCommand(&Command{
+		Name: "monitor",
+		Help: "Mute, unmute, and test monitors.",
+		Subcommands: []*Command{
+			{
+				Name:  "mute",
+				Help:  "Stop a monitor from notifying for a while.",
+				Flags: flagsMonitorMute,
+				Func:  cmdMonitorMute,
+			},
+			{
+				Name:  "unmute",
+				Help:  "Let a muted monitor notify again.",
+				Flags: flagsMonitorUnmute,
+				Func:  cmdMonitorUnmute,
+			},
+			{
+				Name:  "test",
+				Help:  "Show when a monitor would have fired over a past window.",
+				Flags: flagsMonitorTest,
+				Func:  cmdMonitorTest,
+			},
+		},
+	})
+}
+
+var (
+	ErrMonitorMuteUsage   = ObserveError{Msg: "usage: observe monitor mute <monitor> --for <duration> [--reason <text>]"}
+	ErrMonitorUnmuteUsage = ObserveError{Msg: "usage: observe monitor unmute <monitor>"}
+	ErrMonitorMuteFor     = ObserveError{Msg: "--for must be a positive duration, such as 30m or 2h"}
+)
+
+// resolveMonitor takes a monitor ID, or a name, which is looked up.
+func resolveMonitor(fa FuncArgs, idOrName string) (string, error) {
+	if _, err := strconv.ParseInt(idOrName, 10, 64); err == nil {
+		return idOrName, nil
+	}
+	info, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeMonitor, idOrName)
+	if err != nil {
+		return "", err
+	}
+	return info.Id, nil
+}
+
+func cmdMonitorMute(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrMonitorMuteUsage
+	}
+	if flagMonitorFor <= 0 {
+		return ErrMonitorMuteFor
+	}
+	id, err := resolveMonitor(fa, fa.args[1])
+	if err != nil {
+		return err
+	}
+	endTime := time.Now().Add(flagMonitorFor).UTC().Format(time.RFC3339)
+	m, err := muteMonitor(fa.cfg, fa.op, fa.hc, id, endTime, flagMonitorReason)
+	if err != nil {
+		return NewObserveError(err, "mute monitor %s", id)
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	until := endTime
+	if m.MutedUntil != nil {
+		until = *m.MutedUntil
+	}
+	fmt.Fprintf(fa.op, "muted monitor %q (%d) until %s\n", m.Name, m.Id, until)
+	return nil
+}
+
+func cmdMonitorUnmute(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrMonitorUnmuteUsage
+	}
+	id, err := resolveMonitor(fa, fa.args[1])
+	if err != nil {
+		return err
+	}
+	m, err := unmuteMonitor(fa.cfg, fa.op, fa.hc, id)
+	if err != nil {
+		return NewObserveError(err, "unmute monitor %s", id)
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	fmt.Fprintf(fa.op, "unmuted monitor %q (%d)\n", m.Name, m.Id)
+	return nil
+}
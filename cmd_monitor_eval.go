@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
+
+// `monitor test` runs a monitor's query over a past window, through the same
+// export API as `query`, and applies the rule to each row of the result. Each
+// row is a point in time, and the monitor compares each window of its lookback
+// to the threshold, so the rule is only a comparison of rows when the query
+// buckets its result at the lookback, for example with `timechart 10m` for a
+// lookback of 10 minutes. Results bucketed more finely are refused, rather
+// than guessing how the monitor would combine the rows of a window.
+
+var (
+	ErrMonitorTestUsage    = ObserveError{Msg: "usage: observe monitor test <monitor> [--start-time <time>] [--end-time <time>] [--relative <duration>]"}
+	ErrMonitorTestRuleKind = ObserveError{Msg: "only monitors with threshold rules can be tested"}
+	ErrMonitorTestCompare  = ObserveError{Msg: "unknown compare function"}
+	ErrMonitorTestNoTime   = ObserveError{Msg: "can't tell which column is the time; use --time-column"}
+	ErrMonitorTestBucket   = ObserveError{Msg: "the query result isn't bucketed at the monitor's lookback"}
+)
+
+// monitorTimeColumns are tried in order when --time-column isn't given.
+var monitorTimeColumns = []string{"_c_bucket", "_c_valid_from", "timestamp", "time"}
+
+// fires tells whether the rule triggers for the value.
+func (r *monitorRule) fires(v float64) (bool, error) {
+	want := 1
+	if strings.Contains(r.CompareFunction, "Between") {
+		want = 2
+	}
+	if len(r.CompareValues) != want {
+		return false, NewObserveError(nil, "%s needs %d compare values, not %d", r.CompareFunction, want, len(r.CompareValues))
+	}
+	cv := r.CompareValues
+	switch r.CompareFunction {
+	case "Greater":
+		return v > cv[0], nil
+	case "GreaterOrEqual":
+		return v >= cv[0], nil
+	case "Less":
+		return v < cv[0], nil
+	case "LessOrEqual":
+		return v <= cv[0], nil
+	case "Equal":
+		return v == cv[0], nil
+	case "NotEqual":
+		return v != cv[0], nil
+	case "BetweenHalfOpen":
+		return v >= cv[0] && v < cv[1], nil
+	case "NotBetweenHalfOpen":
+		return v < cv[0] || v >= cv[1], nil
+	}
+	return false, NewObserveError(ErrMonitorTestCompare, "%q", r.CompareFunction)
+}
+
+type monitorPoint struct {
+	time  string
+	value string
+	group string
+}
+
+type monitorRow struct {
+	time  string
+	value any
+	group string
+}
+
+// parseMonitorTime reads a time column, which is either a timestamp or
+// nanoseconds since the epoch.
+func parseMonitorTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	if ns, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, ns), true
+	}
+	return time.Time{}, false
+}
+
+// checkBuckets makes sure the rows are windows of the lookback: the times of
+// the rows must be a multiple of the lookback apart, as buckets without data
+// may be missing.
+func (r *monitorRule) checkBuckets(rows []monitorRow) error {
+	if r.LookbackTime == "" {
+		return nil
+	}
+	lookback, err := time.ParseDuration(r.LookbackTime)
+	if err != nil || lookback <= 0 {
+		return NewObserveError(err, "bad lookback %q", r.LookbackTime)
+	}
+	times := map[time.Time]bool{}
+	for _, row := range rows {
+		t, ok := parseMonitorTime(row.time)
+		if !ok {
+			return NewObserveError(ErrMonitorTestBucket, "can't read time %q", row.time)
+		}
+		times[t] = true
+	}
+	ts := maps.Keys(times)
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+	for i := 1; i < len(ts); i++ {
+		if gap := ts[i].Sub(ts[i-1]); gap%lookback != 0 {
+			return NewObserveError(ErrMonitorTestBucket, "rows at %s and %s are %s apart, but the lookback is %s; bucket the query at %s, for example with timechart", ts[i-1].Format(time.RFC3339), ts[i].Format(time.RFC3339), gap, lookback, lookback)
+		}
+	}
+	return nil
+}
+
+// evaluate reads the rows of an nd-JSON result, and returns the points the
+// rule fires for, and the number of points in all. Columns other than the
+// time and the value are the group the point is for.
+func (r *monitorRule) evaluate(rd io.Reader, timeColumn string) ([]monitorPoint, int, error) {
+	dec := json.NewDecoder(rd)
+	dec.UseNumber()
+	var rows []monitorRow
+	for {
+		var row object
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, len(rows), NewObserveError(err, "read query result")
+		}
+		tcol := timeColumn
+		for _, c := range monitorTimeColumns {
+			if _, has := row[c]; has && tcol == "" {
+				tcol = c
+			}
+		}
+		if _, has := row[tcol]; !has {
+			return nil, len(rows), ErrMonitorTestNoTime
+		}
+		raw, has := row[r.ValueColumnName]
+		if !has {
+			return nil, len(rows), NewObserveError(nil, "the result has no value column %q", r.ValueColumnName)
+		}
+		var group []string
+		for _, k := range sorted(maps.Keys(row)) {
+			if k != tcol && k != r.ValueColumnName {
+				group = append(group, fmt.Sprintf("%s=%v", k, row[k]))
+			}
+		}
+		rows = append(rows, monitorRow{fmt.Sprint(row[tcol]), raw, strings.Join(group, ", ")})
+	}
+	if err := r.checkBuckets(rows); err != nil {
+		return nil, len(rows), err
+	}
+	var ret []monitorPoint
+	for i, row := range rows {
+		if row.value == nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(fmt.Sprint(row.value), 64)
+		if err != nil {
+			return nil, len(rows), NewObserveError(err, "value of row %d", i+1)
+		}
+		fire, err := r.fires(v)
+		if err != nil {
+			return nil, len(rows), err
+		}
+		if fire {
+			ret = append(ret, monitorPoint{row.time, fmt.Sprint(row.value), row.group})
+		}
+	}
+	return ret, len(rows), nil
+}
+
+func cmdMonitorTest(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrMonitorTestUsage
+	}
+	nowTime := time.Now().Truncate(time.Second)
+	fromTime, toTime, err := queryWindow(fa, flagsMonitorTest, nowTime, flagMonitorTestStartTime, flagMonitorTestEndTime, flagMonitorTestRelative)
+	if err != nil {
+		return err
+	}
+	id, err := resolveMonitor(fa, fa.args[1])
+	if err != nil {
+		return err
+	}
+	def, err := getMonitorDefinition(fa.cfg, fa.op, fa.hc, id)
+	if err != nil {
+		return err
+	}
+	if def.Rule.RuleKind != "Threshold" {
+		return NewObserveError(ErrMonitorTestRuleKind, "monitor %q has a %s rule", def.Name, def.Rule.RuleKind)
+	}
+	noLinkify := false
+	req := V1ExportQueryRequest{
+		Query:        def.Query,
+		Presentation: &Presentation{Linkify: &noLinkify},
+	}
+	var result bytes.Buffer
+	if err := exportQuery(fa, &req, fromTime, toTime, "application/x-ndjson", &result); err != nil {
+		return err
+	}
+	points, n, err := def.Rule.evaluate(&result, flagMonitorTestTimeColumn)
+	if err != nil {
+		return err
+	}
+	fa.op.Info("monitor %q would have fired at %d of %d points between %s and %s\n", def.Name, len(points), n, fromTime.Format(time.RFC3339), toTime.Format(time.RFC3339))
+	if len(points) == 0 {
+		return nil
+	}
+	out := &ColumnFormatter{Output: fa.op, OmitLineDrawing: true, LiteralStrings: true}
+	out.SetColumnNames([]string{"time", def.Rule.ValueColumnName, "group"})
+	for _, p := range points {
+		out.AddRow([]string{p.time, p.value, p.group})
+	}
+	return out.Close()
+}
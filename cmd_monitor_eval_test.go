@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testMonitorDefinition = `{"data":{"monitor":{"name":"API errors",` +
+	`"query":{"outputStage":"stage-2","stages":[` +
+	`{"stageID":"stage-1","input":[{"inputName":"_","datasetId":"41007104","stageId":null}],"pipeline":"filter status >= 500"},` +
+	`{"stageID":"stage-2","input":[{"inputName":"_","datasetId":null,"stageId":"stage-1"}],"pipeline":"timechart 10m, errors:count(), group_by(service)"}]},` +
+	`"rule":{"ruleKind":"Threshold","lookbackTime":"10m0s","compareFunction":"Greater","compareValues":[100],"valueColumnName":"errors"}}}}`
+
+func TestCmdMonitorTest(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testMonitorDefinition},
+		testRequest{`/v1/meta/export/query\?startTime=2026-10-01T00%3A00%3A00Z&endTime=2026-10-01T01%3A00%3A00Z`, 200,
+			`{"_c_bucket":"2026-10-01T00:00:00Z","service":"api","errors":12}
+{"_c_bucket":"2026-10-01T00:10:00Z","service":"api","errors":340}
+{"_c_bucket":"2026-10-01T00:10:00Z","service":"web","errors":null}
+{"_c_bucket":"2026-10-01T00:20:00Z","service":"api","errors":"101.5"}
+`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"monitor", "test", "41000123", "--start-time", "2026-10-01T00:00:00Z", "--end-time", "2026-10-01T01:00:00Z"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `time                 errors group      
+2026-10-01T00:10:00Z 340    service=api
+2026-10-01T00:20:00Z 101.5  service=api
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "would have fired at 2 of 4 points") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+}
+
+func TestMonitorRuleLookback(t *testing.T) {
+	const rows = `{"_c_bucket":"2026-10-01T00:00:00Z","errors":12}
+{"_c_bucket":"2026-10-01T00:10:00Z","errors":340}
+{"_c_bucket":"2026-10-01T00:30:00Z","errors":5}
+`
+	r := monitorRule{CompareFunction: "Greater", CompareValues: []float64{100}, ValueColumnName: "errors"}
+	// a missing bucket is fine
+	r.LookbackTime = "10m0s"
+	if points, n, err := r.evaluate(strings.NewReader(rows), ""); err != nil || len(points) != 1 || n != 3 {
+		t.Error("unexpected result:", points, n, err)
+	}
+	// but a lookback longer than the buckets needs rows combined
+	r.LookbackTime = "30m0s"
+	_, _, err := r.evaluate(strings.NewReader(rows), "")
+	if err == nil || !strings.Contains(err.Error(), ErrMonitorTestBucket.Msg) || !strings.Contains(err.Error(), "10m0s apart, but the lookback is 30m0s") {
+		t.Error("expected a bucket error:", err)
+	}
+}
+
+func TestMonitorRuleFires(t *testing.T) {
+	for _, tc := range []struct {
+		fn     string
+		values []float64
+		v      float64
+		fires  bool
+	}{
+		{"Greater", []float64{10}, 10, false},
+		{"GreaterOrEqual", []float64{10}, 10, true},
+		{"LessOrEqual", []float64{10}, 11, false},
+		{"NotEqual", []float64{0}, 1, true},
+		{"BetweenHalfOpen", []float64{1, 5}, 5, false},
+		{"NotBetweenHalfOpen", []float64{1, 5}, 5, true},
+	} {
+		r := monitorRule{CompareFunction: tc.fn, CompareValues: tc.values}
+		got, err := r.fires(tc.v)
+		if err != nil || got != tc.fires {
+			t.Errorf("%s %v %v: got %v, %v", tc.fn, tc.values, tc.v, got, err)
+		}
+	}
+	r := monitorRule{CompareFunction: "BetweenHalfOpen", CompareValues: []float64{1}}
+	if _, err := r.fires(1); err == nil {
+		t.Error("expected an error for a missing compare value")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testMonitorFields = `"id":"41000123","name":"API errors","workspaceId":"41000001","folderId":"41000300","description":null,"disabled":false,"createdBy":"3",` +
+	`"query":{"stages":[{"pipeline":"filter status >= 500"},{"pipeline":"statsby count()"}]},` +
+	`"rule":{"ruleKind":"Threshold","lookbackTime":"10m0s","compareFunction":"Greater","compareValues":[100]},` +
+	`"actions":[{"action":{"name":"page on-call"}},{"action":{"name":"slack #api"}}]`
+
+func TestCmdGetMonitor(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"monitor":{` + testMonitorFields + `,"mutedUntil":null,"muteReason":null}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"get", "monitor", "41000123"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `object:
+  type: "monitor"
+  id: 41000123
+  config:
+    name: "API errors"
+    workspaceId: 41000001
+    folderId: 41000300
+    description: 
+    enabled: true
+    query: "filter status >= 500\nstatsby count()"
+    threshold: "Threshold Greater 100 over 10m0s"
+    actions: "page on-call, slack #api"
+  state:
+    mutedUntil: 
+    muteReason: 
+    createdBy: 3
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdMonitorMute(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"muteMonitor":{` + testMonitorFields + `,"mutedUntil":"2026-10-19T14:00:00Z","muteReason":"database upgrade"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"monitors":[{` + testMonitorFields + `,"mutedUntil":"2026-10-19T14:00:00Z","muteReason":"database upgrade"}]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"unmuteMonitor":{` + testMonitorFields + `,"mutedUntil":null,"muteReason":null}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"monitor", "mute", "41000123", "--for", "2h", "--reason", "database upgrade"}, fix.hc)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"monitor", "unmute", "api errors"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "muted monitor \"API errors\" (41000123) until 2026-10-19T14:00:00Z\nunmuted monitor \"API errors\" (41000123)\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdMonitorMuteUsage(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"monitor", "mute", "41000123"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrMonitorMuteFor.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+	fix.Assert()
+}
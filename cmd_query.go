@@ -46,10 +46,11 @@ func init() {
 	flagsQuery.Lookup("literal-strings").NoOptDefVal = "true"
 	flagsQuery.StringVar(&flagQueryFormat, "format", "", "specify output format: table, extended, csv, ndjson")
 	RegisterCommand(&Command{
-		Name:  "query",
-		Help:  "Run an OPAL query.",
-		Flags: flagsQuery,
-		Func:  cmdQuery,
+		Name:            "query",
+		Help:            "Run an OPAL query.",
+		Flags:           flagsQuery,
+		Func:            cmdQuery,
+		WorkspaceScoped: true,
 	})
 }
 
@@ -65,6 +66,7 @@ var ErrAtMostOneOutputFormat = ObserveError{Msg: "at most one of --csv and --jso
 var ErrAnInputIsRequired = ObserveError{Msg: "at least one --input is required"}
 var ErrQueryTooManyFormats = ObserveError{Msg: "at most one of --format and the format-specific flags may be specified"}
 var ErrUnknownFormat = ObserveError{Msg: "the --format is not known"}
+var ErrQueryFileHasInputs = ObserveError{Msg: "the query file has stages with their own inputs; --input can't also be given"}
 
 func cmdQuery(fa FuncArgs) error {
 	nowTime := time.Now().Truncate(time.Second)
@@ -93,44 +95,9 @@ func cmdQuery(fa FuncArgs) error {
 		return ErrTooLongQueryText
 	}
 
-	var fromTime, toTime time.Time
-	nTime := CountFlags(flagsQuery, "start-time", "end-time", "relative")
-	switch nTime {
-	case 0:
-		toTime = nowTime.Add(-15 * time.Second).Truncate(time.Minute)
-		fromTime = toTime.Add(-DefaultQueryWindowDuration)
-	case 1:
-		if flagsQuery.Lookup("start-time").Changed {
-			fromTime, err = ParseTime(flagQueryStartTime, nowTime)
-			toTime = fromTime.Add(DefaultQueryWindowDuration)
-		} else if flagsQuery.Lookup("end-time").Changed {
-			toTime, err = ParseTime(flagQueryEndTime, nowTime)
-			fromTime = toTime.Add(-DefaultQueryWindowDuration)
-		} else {
-			toTime = nowTime.Add(-15 * time.Second).Truncate(time.Minute)
-			fromTime = toTime.Add(-flagQueryRelative)
-		}
-	case 2:
-		if !flagsQuery.Lookup("start-time").Changed {
-			toTime, err = ParseTime(flagQueryEndTime, nowTime)
-			fromTime = toTime.Add(-flagQueryRelative)
-		} else if !flagsQuery.Lookup("end-time").Changed {
-			fromTime, err = ParseTime(flagQueryStartTime, nowTime)
-			toTime = fromTime.Add(flagQueryRelative)
-		} else {
-			fromTime, err = ParseTime(flagQueryStartTime, nowTime)
-			if err == nil {
-				toTime, err = ParseTime(flagQueryEndTime, nowTime)
-			}
-		}
-	default:
-		return ErrAtMostTwoTimeSpecifiers
-	}
+	fromTime, toTime, err := queryWindow(fa, flagsQuery, nowTime, flagQueryStartTime, flagQueryEndTime, flagQueryRelative)
 	if err != nil {
-		return NewObserveError(err, "bad time format")
-	}
-	if toTime.Sub(fromTime) <= 0 {
-		return ErrValidToMustBeAfterValidFrom
+		return err
 	}
 
 	nFmt := CountFlags(flagsQuery, "csv", "json")
@@ -143,50 +110,50 @@ func cmdQuery(fa FuncArgs) error {
 		return ErrAtMostOneOutputFormat
 	}
 
-	// TODO: we can remove this when in-text inputs are complete
-	if len(flagQueryInputs) == 0 {
-		return ErrAnInputIsRequired
+	qf, err := parseQueryFile(queryText)
+	if err != nil {
+		return err
 	}
-	var inputs []StageQueryInput
-	for i, in := range flagQueryInputs {
-		pieces := strings.SplitN(in, "=", 2)
-		if len(pieces) == 1 {
-			if i == 0 {
-				pieces = append([]string{"_"}, pieces[0])
-			} else {
-				return NewObserveError(nil, "input at index %d must be of the form id=dataset", i)
-			}
+	var query OpalQuery
+	if len(qf.Stages) > 0 {
+		// the inputs are in the file
+		if len(flagQueryInputs) > 0 {
+			return ErrQueryFileHasInputs
 		}
-		for j, k := range inputs {
-			if k.InputName == pieces[0] {
-				return NewObserveError(nil, "input at index %d duplicates input name %q from index %d", j, k.InputName, i)
-			}
+		if query, err = queryFromFile(fa, qf); err != nil {
+			return err
 		}
-		var i64 int64
-		if i64, err = strconv.ParseInt(pieces[1], 10, 64); err == nil {
-			inputs = append(inputs, StageQueryInput{
-				InputName: pieces[0],
-				DatasetID: &i64,
-			})
-			fa.op.Debug("input[%d] @%s <- datasetId(%d)\n", i, pieces[0], i64)
-		} else {
-			if !strings.Contains(pieces[1], ".") {
-				workspaceName := mustGetWorkspaceName(fa.cfg, fa.hc)
-				fa.op.Debug("default workspace=%s\n", workspaceName)
-				pieces[1] = workspaceName + "." + pieces[1]
+	} else {
+		queryInputs := flagQueryInputs
+		if len(queryInputs) == 0 {
+			queryInputs = fa.cfg.QueryInputs
+		}
+		// TODO: we can remove this when in-text inputs are complete
+		if len(queryInputs) == 0 {
+			return ErrAnInputIsRequired
+		}
+		var inputs []StageQueryInput
+		for i, in := range queryInputs {
+			pieces := strings.SplitN(in, "=", 2)
+			if len(pieces) == 1 {
+				if i == 0 {
+					pieces = append([]string{"_"}, pieces[0])
+				} else {
+					return NewObserveError(nil, "input at index %d must be of the form id=dataset", i)
+				}
+			}
+			for j, k := range inputs {
+				if k.InputName == pieces[0] {
+					return NewObserveError(nil, "input at index %d duplicates input name %q from index %d", j, k.InputName, i)
+				}
+			}
+			sqi, err := resolveQueryInput(fa, i, pieces[0], pieces[1])
+			if err != nil {
+				return err
 			}
-			inputs = append(inputs, StageQueryInput{
-				InputName:   pieces[0],
-				DatasetPath: &pieces[1],
-			})
-			fa.op.Debug("input[%d] @%s <- datasetPath(%q)\n", i, pieces[0], pieces[1])
+			inputs = append(inputs, sqi)
 		}
-	}
-
-	// I'm now ready to formulate the query
-	noLinkify := false
-	req := V1ExportQueryRequest{
-		Query: OpalQuery{
+		query = OpalQuery{
 			OutputStage: "query",
 			Stages: []StageQuery{
 				{
@@ -195,7 +162,13 @@ func cmdQuery(fa FuncArgs) error {
 					Pipeline: queryText,
 				},
 			},
-		},
+		}
+	}
+
+	// I'm now ready to formulate the query
+	noLinkify := false
+	req := V1ExportQueryRequest{
+		Query: query,
 		Presentation: &Presentation{
 			Linkify: &noLinkify,
 		},
@@ -250,14 +223,115 @@ func cmdQuery(fa FuncArgs) error {
 		output = tfmt
 	}
 
+	return exportQuery(fa, &req, fromTime, toTime, acceptHeader, output)
+}
+
+// queryWindow works out the time window of a query from the --start-time,
+// --end-time, and --relative flags of the given flag set, at most two of which
+// may be given.
+func queryWindow(fa FuncArgs, flags *pflag.FlagSet, nowTime time.Time, startTime string, endTime string, relative time.Duration) (fromTime time.Time, toTime time.Time, err error) {
+	nTime := CountFlags(flags, "start-time", "end-time", "relative")
+	switch nTime {
+	case 0:
+		window := DefaultQueryWindowDuration
+		if fa.cfg.QueryRelative != "" {
+			// the configured window only applies when no time flags are given
+			window, err = time.ParseDuration(fa.cfg.QueryRelative)
+			if err != nil {
+				return fromTime, toTime, NewObserveError(err, "bad configured relative window %q", fa.cfg.QueryRelative)
+			}
+		}
+		toTime = nowTime.Add(-15 * time.Second).Truncate(time.Minute)
+		fromTime = toTime.Add(-window)
+	case 1:
+		if flags.Lookup("start-time").Changed {
+			fromTime, err = ParseTime(startTime, nowTime)
+			toTime = fromTime.Add(DefaultQueryWindowDuration)
+		} else if flags.Lookup("end-time").Changed {
+			toTime, err = ParseTime(endTime, nowTime)
+			fromTime = toTime.Add(-DefaultQueryWindowDuration)
+		} else {
+			toTime = nowTime.Add(-15 * time.Second).Truncate(time.Minute)
+			fromTime = toTime.Add(-relative)
+		}
+	case 2:
+		if !flags.Lookup("start-time").Changed {
+			toTime, err = ParseTime(endTime, nowTime)
+			fromTime = toTime.Add(-relative)
+		} else if !flags.Lookup("end-time").Changed {
+			fromTime, err = ParseTime(startTime, nowTime)
+			toTime = fromTime.Add(relative)
+		} else {
+			fromTime, err = ParseTime(startTime, nowTime)
+			if err == nil {
+				toTime, err = ParseTime(endTime, nowTime)
+			}
+		}
+	default:
+		return fromTime, toTime, ErrAtMostTwoTimeSpecifiers
+	}
+	if err != nil {
+		return fromTime, toTime, NewObserveError(err, "bad time format")
+	}
+	if toTime.Sub(fromTime) <= 0 {
+		return fromTime, toTime, ErrValidToMustBeAfterValidFrom
+	}
+	return fromTime, toTime, nil
+}
+
+// resolveQueryInput turns a dataset ID, a dataset path, or, in a query file,
+// @ and the ID of a stage, into an input. A path without a workspace is in
+// the configured workspace.
+func resolveQueryInput(fa FuncArgs, i int, name string, source string) (StageQueryInput, error) {
+	if i64, err := strconv.ParseInt(source, 10, 64); err == nil {
+		fa.op.Debug("input[%d] @%s <- datasetId(%d)\n", i, name, i64)
+		return StageQueryInput{InputName: name, DatasetID: &i64}, nil
+	}
+	if stage, is := strings.CutPrefix(source, "@"); is {
+		fa.op.Debug("input[%d] @%s <- stageId(%q)\n", i, name, stage)
+		return StageQueryInput{InputName: name, StageID: &stage}, nil
+	}
+	if !strings.Contains(source, ".") {
+		ws, err := ResolveWorkspace(fa.cfg, fa.fs, fa.op, fa.hc)
+		if err != nil {
+			return StageQueryInput{}, err
+		}
+		fa.op.Debug("default workspace=%s\n", ws.Name)
+		source = ws.Name + "." + source
+	}
+	fa.op.Debug("input[%d] @%s <- datasetPath(%q)\n", i, name, source)
+	return StageQueryInput{InputName: name, DatasetPath: &source}, nil
+}
+
+// queryFromFile makes a multi-stage query from a query file; the last stage
+// is the output.
+func queryFromFile(fa FuncArgs, qf *queryFile) (OpalQuery, error) {
+	var ret OpalQuery
+	n := 0
+	for _, s := range qf.Stages {
+		sq := StageQuery{StageID: s.Id, Pipeline: s.Pipeline}
+		for _, in := range s.Inputs {
+			sqi, err := resolveQueryInput(fa, n, in.Name, in.Source)
+			if err != nil {
+				return ret, err
+			}
+			sq.Inputs = append(sq.Inputs, sqi)
+			n++
+		}
+		ret.Stages = append(ret.Stages, sq)
+		ret.OutputStage = s.Id
+	}
+	return ret, nil
+}
+
+// exportQuery runs the query over the window, and copies the result, in the
+// format the accept header asks for, to output.
+func exportQuery(fa FuncArgs, req *V1ExportQueryRequest, fromTime time.Time, toTime time.Time, acceptHeader string, output io.Writer) error {
 	uri := fmt.Sprintf("/v1/meta/export/query?startTime=%s&endTime=%s",
 		url.QueryEscape(fromTime.Format(time.RFC3339)),
 		url.QueryEscape(toTime.Format(time.RFC3339)))
-	err, _ = RequestPOSTWithBodyOutput(fa.cfg, fa.op, fa.hc, uri, &req, headers("Accept", acceptHeader, "Authorization", fa.cfg.AuthHeader()), output)
-	if err != nil {
-		return err
-	}
-	return nil
+	err, _ := RequestPOSTWithBodyOutput(fa.cfg, fa.op, fa.hc, uri, req, headers("Accept", acceptHeader, "Authorization", fa.cfg.AuthHeader()), output)
+	return err
 }
 
 type OpalQuery struct {
@@ -286,6 +360,8 @@ type StageQueryInput struct {
 	InputName   string  `json:"inputName"`
 	DatasetID   *int64  `json:"datasetId,string,omitempty"`
 	DatasetPath *string `json:"datasetPath,omitempty"`
+	// for multi-stage queries, like those of monitors
+	StageID *string `json:"stageId,omitempty"`
 }
 
 type Presentation struct {
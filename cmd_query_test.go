@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -38,3 +39,15 @@ func TestCmdQueryText(t *testing.T) {
 		t.Error("unexpected data output:", diff)
 	}
 }
+
+func TestCmdQueryWorkspaceFlag(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"41000001","name":"Default"},{"id":"41000002","name":"Payments"}]}}}`},
+		testRequest{`/v1/meta/export/query\?.*`, 200, "timestamp,log\n"},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"query", "-i", "Container Logs", "--workspace", "payments", "-q", "limit 1"}, fix.hc)
+	fix.Assert()
+	if !strings.Contains(fix.op.DebugBuf.String(), `datasetPath("Payments.Container Logs")`) {
+		t.Error("expected dataset path in resolved workspace:", fix.op.DebugBuf.String())
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsRbacAddMember       *pflag.FlagSet
+	flagsRbacRemoveMember    *pflag.FlagSet
+	flagRbacMemberUser       string
+	flagRbacMemberGroup      string
+	flagsRbacGrant           *pflag.FlagSet
+	flagRbacGrantRole        string
+	flagRbacGrantUser        string
+	flagRbacGrantGroup       string
+	flagRbacGrantAllUsers    bool
+	flagRbacGrantObjectId    string
+	flagRbacGrantFolder      string
+	flagRbacGrantType        string
+	flagRbacGrantName        string
+	flagRbacGrantOwner       bool
+	flagRbacGrantAllObjects  bool
+	flagRbacGrantDescription string
+	flagsRbacCheck           *pflag.FlagSet
+	flagRbacCheckUser        string
+	flagRbacCheckObject      string
+	flagRbacCheckRole        string
+	flagsRbacAudit           *pflag.FlagSet
+	flagRbacAuditFormat      string
+	flagsRbacTree            *pflag.FlagSet
+	flagRbacTreeUser         string
+	flagRbacTreeGroup        string
+	flagRbacTreeASCII        bool
+	flagsRbacSync            *pflag.FlagSet
+	flagRbacSyncFrom         string
+	flagRbacSyncApply        bool
+	flagRbacSyncDryRun       bool
+	flagRbacSyncPrune        bool
+)
+
+func init() {
+	flagsRbacAddMember = pflag.NewFlagSet("add-member", pflag.ContinueOnError)
+	flagsRbacAddMember.StringVar(&flagRbacMemberUser, "user", "", "user to add, by ID, email, or name")
+	flagsRbacAddMember.StringVar(&flagRbacMemberGroup, "group", "", "group to add, by ID or name")
+	flagsRbacRemoveMember = pflag.NewFlagSet("remove-member", pflag.ContinueOnError)
+	flagsRbacRemoveMember.StringVar(&flagRbacMemberUser, "user", "", "user to remove, by ID, email, or name")
+	flagsRbacRemoveMember.StringVar(&flagRbacMemberGroup, "group", "", "group to remove, by ID or name")
+	flagsRbacGrant = pflag.NewFlagSet("grant", pflag.ContinueOnError)
+	flagsRbacGrant.StringVar(&flagRbacGrantRole, "role", "", "role to grant: "+strings.Join(rbacRoles, ", "))
+	flagsRbacGrant.StringVar(&flagRbacGrantUser, "user", "", "grant to this user, by ID, email, or name")
+	flagsRbacGrant.StringVar(&flagRbacGrantGroup, "group", "", "grant to this group, by ID or name")
+	flagsRbacGrant.BoolVar(&flagRbacGrantAllUsers, "all-users", false, "grant to all users")
+	flagsRbacGrant.Lookup("all-users").NoOptDefVal = "true"
+	flagsRbacGrant.StringVar(&flagRbacGrantObjectId, "object-id", "", "grant on this object ID")
+	flagsRbacGrant.StringVar(&flagRbacGrantFolder, "folder", "", "grant on the objects in this folder ID")
+	flagsRbacGrant.StringVar(&flagRbacGrantType, "type", "", "grant on objects of this type, such as dataset")
+	flagsRbacGrant.StringVar(&flagRbacGrantName, "name", "", "with --type, grant only on objects of this name")
+	flagsRbacGrant.BoolVar(&flagRbacGrantOwner, "owner", false, "grant on the objects the subject owns")
+	flagsRbacGrant.Lookup("owner").NoOptDefVal = "true"
+	flagsRbacGrant.BoolVar(&flagRbacGrantAllObjects, "all-objects", false, "grant on all objects")
+	flagsRbacGrant.Lookup("all-objects").NoOptDefVal = "true"
+	flagsRbacGrant.StringVar(&flagRbacGrantDescription, "description", "", "description of the statement; generated if not given")
+	flagsRbacCheck = pflag.NewFlagSet("check", pflag.ContinueOnError)
+	flagsRbacCheck.StringVar(&flagRbacCheckUser, "user", "", "user to check, by ID, email, or name")
+	flagsRbacCheck.StringVar(&flagRbacCheckObject, "object", "", "object to check, as <type>:<id>")
+	flagsRbacCheck.StringVar(&flagRbacCheckRole, "role", "", "role to check for: "+strings.Join(rbacRoles, ", "))
+	flagsRbacAudit = pflag.NewFlagSet("audit", pflag.ContinueOnError)
+	flagsRbacAudit.StringVar(&flagRbacAuditFormat, "format", "table", "output format: table, json, or junit")
+	flagsRbacTree = pflag.NewFlagSet("tree", pflag.ContinueOnError)
+	flagsRbacTree.StringVar(&flagRbacTreeUser, "user", "", "user to show, by ID, email, or name")
+	flagsRbacTree.StringVar(&flagRbacTreeGroup, "group", "", "group to show, by ID or name")
+	flagsRbacTree.BoolVar(&flagRbacTreeASCII, "ascii", false, "draw the tree with ASCII rather than Unicode")
+	flagsRbacTree.Lookup("ascii").NoOptDefVal = "true"
+	flagsRbacSync = pflag.NewFlagSet("sync", pflag.ContinueOnError)
+	flagsRbacSync.StringVar(&flagRbacSyncFrom, "from", "", "CSV file with group and email columns")
+	flagsRbacSync.BoolVar(&flagRbacSyncApply, "apply", false, "make the changes, rather than only printing them")
+	flagsRbacSync.Lookup("apply").NoOptDefVal = "true"
+	flagsRbacSync.BoolVar(&flagRbacSyncDryRun, "dry-run", false, "only print the changes; this is the default")
+	flagsRbacSync.Lookup("dry-run").NoOptDefVal = "true"
+	flagsRbacSync.BoolVar(&flagRbacSyncPrune, "prune", false, "remove members that aren't in the file")
+	flagsRbacSync.Lookup("prune").NoOptDefVal = "true"
+	RegisterCommand(&Command{
+		Name: "rbac",
+		Help: "Manage role-based access control groups and statements.",
+		Subcommands: []*Command{
+			{
+				Name: "group",
+				Help: "Manage the members of RBAC groups.",
+				Subcommands: []*Command{
+					{
+						Name:  "add-member",
+						Help:  "Add a user or group to a group.",
+						Flags: flagsRbacAddMember,
+						Func:  cmdRbacGroupAddMember,
+					},
+					{
+						Name:  "remove-member",
+						Help:  "Remove a user or group from a group.",
+						Flags: flagsRbacRemoveMember,
+						Func:  cmdRbacGroupRemoveMember,
+					},
+				},
+			},
+			{
+				Name:            "grant",
+				Help:            "Grant a role on some objects to a user, a group, or everyone.",
+				Flags:           flagsRbacGrant,
+				Func:            cmdRbacGrant,
+				WorkspaceScoped: true,
+			},
+			{
+				Name: "revoke",
+				Help: "Revoke a grant by deleting its statement.",
+				Func: cmdRbacRevoke,
+			},
+			{
+				Name:  "check",
+				Help:  "Explain whether a user holds a role on an object.",
+				Flags: flagsRbacCheck,
+				Func:  cmdRbacCheck,
+			},
+			{
+				Name:  "audit",
+				Help:  "Report RBAC hygiene problems, such as empty groups and dangling statements.",
+				Flags: flagsRbacAudit,
+				Func:  cmdRbacAudit,
+			},
+			{
+				Name:  "tree",
+				Help:  "Print the groups a user or group is in, and their grants, as a tree.",
+				Flags: flagsRbacTree,
+				Func:  cmdRbacTree,
+			},
+			{
+				Name:  "sync",
+				Help:  "Reconcile groups and their members with a CSV file.",
+				Flags: flagsRbacSync,
+				Func:  cmdRbacSync,
+			},
+		},
+	})
+}
+
+// The roles that statements can grant, from least to most powerful. Each
+// role includes the ones before it.
+var rbacRoles = []string{"Lister", "Viewer", "Editor", "Manager"}
+
+var (
+	ErrRbacGrantUsage      = ObserveError{Msg: "usage: observe rbac grant --role <role> <subject> <object>"}
+	ErrRbacRevokeUsage     = ObserveError{Msg: "usage: observe rbac revoke <statement id>"}
+	ErrRbacUnknownRole     = ObserveError{Msg: "unknown role; the roles are " + strings.Join(rbacRoles, ", ")}
+	ErrRbacGrantOneSubject = ObserveError{Msg: "exactly one of --user, --group, and --all-users is required"}
+	ErrRbacGrantOneObject  = ObserveError{Msg: "exactly one of --object-id, --folder, --workspace, --type, --owner, and --all-objects is required"}
+	ErrRbacGrantNameType   = ObserveError{Msg: "--name can only be used with --type"}
+	ErrRbacMemberUsage     = ObserveError{Msg: "usage: observe rbac group add-member|remove-member <group> --user <user> | --group <group>"}
+	ErrRbacOneMember       = ObserveError{Msg: "exactly one of --user and --group is required"}
+)
+
+// rbacMember is a resolved --user or --group flag.
+type rbacMember struct {
+	userId  *int64
+	groupId *string
+	label   string
+}
+
+func (m rbacMember) matches(gm *objectRbacgroupmember) bool {
+	if m.userId != nil {
+		return gm.MemberUserId != nil && *gm.MemberUserId == *m.userId
+	}
+	return gm.MemberGroupId != nil && *gm.MemberGroupId == *m.groupId
+}
+
+func resolveRbacMember(fa FuncArgs) (rbacMember, error) {
+	user, group := flagRbacMemberUser, flagRbacMemberGroup
+	if (user == "") == (group == "") {
+		return rbacMember{}, ErrRbacOneMember
+	}
+	if user != "" {
+		info, err := resolveUser(fa.cfg, fa.fs, fa.op, fa.hc, user)
+		if err != nil {
+			return rbacMember{}, err
+		}
+		id := must(strconv.ParseInt(info.Id, 10, 64))
+		return rbacMember{userId: &id, label: fmt.Sprintf("user %q (%s)", info.Name, info.Id)}, nil
+	}
+	info, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeRbacgroup, group)
+	if err != nil {
+		return rbacMember{}, err
+	}
+	return rbacMember{groupId: &info.Id, label: fmt.Sprintf("group %q (%s)", info.Name, info.Id)}, nil
+}
+
+// resolveUser finds a user by ID, email, or name. Numeric IDs aren't looked
+// up, so that users the caller can't list still work.
+func resolveUser(cfg *Config, fs fileSystem, op Output, hc httpClient, idOrName string) (*ObjectInfo, error) {
+	if _, err := strconv.ParseInt(idOrName, 10, 64); err == nil {
+		return &ObjectInfo{Id: idOrName, Name: idOrName}, nil
+	}
+	users, err := ObjectTypeUser.List(cfg, fs, op, hc)
+	if err != nil {
+		return nil, NewObserveError(err, "list user")
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Object.(*objectUser).Email, idOrName) {
+			return u, nil
+		}
+	}
+	return matchObject("user", idOrName, users)
+}
+
+// rbacGroupMembers lists the memberships of group that match m.
+func rbacGroupMembers(fa FuncArgs, groupId string, m rbacMember) ([]*objectRbacgroupmember, error) {
+	infos, err := ObjectTypeRbacgroupmember.List(fa.cfg, fa.fs, fa.op, fa.hc)
+	if err != nil {
+		return nil, NewObserveError(err, "list rbacgroupmember")
+	}
+	var ret []*objectRbacgroupmember
+	for _, info := range infos {
+		gm := info.Object.(*objectRbacgroupmember)
+		if gm.GroupId == groupId && m.matches(gm) {
+			ret = append(ret, gm)
+		}
+	}
+	return ret, nil
+}
+
+// Adding and removing are idempotent, so that onboarding and offboarding
+// scripts can be re-run.
+
+func cmdRbacGroupAddMember(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrRbacMemberUsage
+	}
+	// don't trust cached listings when changing things
+	fa.cfg.CacheRefresh = true
+	group, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeRbacgroup, fa.args[1])
+	if err != nil {
+		return err
+	}
+	m, err := resolveRbacMember(fa)
+	if err != nil {
+		return err
+	}
+	existing, err := rbacGroupMembers(fa, group.Id, m)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		fa.op.Info("%s is already a member of group %q\n", m.label, group.Name)
+		return nil
+	}
+	input := object{"groupid": group.Id}
+	if m.userId != nil {
+		input["memberuserid"] = *m.userId
+	} else {
+		input["membergroupid"] = *m.groupId
+	}
+	obj, err := ObjectTypeRbacgroupmember.Create(fa.cfg, fa.op, fa.hc, input)
+	if err != nil {
+		return NewObserveError(err, "add member")
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	_, err = fmt.Fprintf(fa.op, "added %s to group %q (%s)\n", m.label, group.Name, obj.GetInfo().Id)
+	return err
+}
+
+func cmdRbacGroupRemoveMember(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrRbacMemberUsage
+	}
+	fa.cfg.CacheRefresh = true
+	group, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeRbacgroup, fa.args[1])
+	if err != nil {
+		return err
+	}
+	m, err := resolveRbacMember(fa)
+	if err != nil {
+		return err
+	}
+	existing, err := rbacGroupMembers(fa, group.Id, m)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		fa.op.Info("%s is not a member of group %q\n", m.label, group.Name)
+		return nil
+	}
+	for _, gm := range existing {
+		if err := ObjectTypeRbacgroupmember.Delete(fa.cfg, fa.op, fa.hc, gm.Id); err != nil {
+			return NewObserveError(err, "remove member %s", gm.Id)
+		}
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	_, err = fmt.Fprintf(fa.op, "removed %s from group %q\n", m.label, group.Name)
+	return err
+}
+
+// normalizeRbacRole accepts a role in any case.
+func normalizeRbacRole(role string) (string, error) {
+	for _, r := range rbacRoles {
+		if strings.EqualFold(r, role) {
+			return r, nil
+		}
+	}
+	return "", NewObserveError(ErrRbacUnknownRole, "%q", role)
+}
+
+func countTrue(bs ...bool) int {
+	n := 0
+	for _, b := range bs {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+func cmdRbacGrant(fa FuncArgs) error {
+	if len(fa.args) != 1 {
+		return ErrRbacGrantUsage
+	}
+	role, err := normalizeRbacRole(flagRbacGrantRole)
+	if err != nil {
+		return err
+	}
+	// only a --workspace given to grant selects the object; the configured
+	// workspace doesn't
+	byWorkspace := flagsRbacGrant.Lookup("workspace").Changed
+	if countTrue(flagRbacGrantUser != "", flagRbacGrantGroup != "", flagRbacGrantAllUsers) != 1 {
+		return ErrRbacGrantOneSubject
+	}
+	if countTrue(flagRbacGrantObjectId != "", flagRbacGrantFolder != "", byWorkspace, flagRbacGrantType != "", flagRbacGrantOwner, flagRbacGrantAllObjects) != 1 {
+		return ErrRbacGrantOneObject
+	}
+	if flagRbacGrantName != "" && flagRbacGrantType == "" {
+		return ErrRbacGrantNameType
+	}
+
+	input := object{"role": role}
+	var subject, target string
+	switch {
+	case flagRbacGrantUser != "":
+		u, err := resolveUser(fa.cfg, fa.fs, fa.op, fa.hc, flagRbacGrantUser)
+		if err != nil {
+			return err
+		}
+		input["subjectuserid"] = u.Id
+		subject = fmt.Sprintf("user %s", u.Name)
+	case flagRbacGrantGroup != "":
+		g, err := resolveObject(fa.cfg, fa.fs, fa.op, fa.hc, ObjectTypeRbacgroup, flagRbacGrantGroup)
+		if err != nil {
+			return err
+		}
+		input["subjectgroupid"] = g.Id
+		subject = fmt.Sprintf("group %s", g.Name)
+	default:
+		input["subjectAll"] = true
+		subject = "all users"
+	}
+	switch {
+	case flagRbacGrantObjectId != "":
+		input["objectobjectid"] = flagRbacGrantObjectId
+		target = "object " + flagRbacGrantObjectId
+	case flagRbacGrantFolder != "":
+		input["objectfolderid"] = flagRbacGrantFolder
+		target = "folder " + flagRbacGrantFolder
+	case byWorkspace:
+		ws, err := ResolveWorkspace(fa.cfg, fa.fs, fa.op, fa.hc)
+		if err != nil {
+			return err
+		}
+		input["objectworkspaceid"] = ws.Id
+		target = "workspace " + ws.Name
+	case flagRbacGrantType != "":
+		input["objecttype"] = flagRbacGrantType
+		target = "all " + flagRbacGrantType
+		if flagRbacGrantName != "" {
+			input["objectname"] = flagRbacGrantName
+			target = fmt.Sprintf("%s %s", flagRbacGrantType, flagRbacGrantName)
+		}
+	case flagRbacGrantOwner:
+		input["objectowner"] = true
+		target = "owned objects"
+	default:
+		input["objectall"] = true
+		target = "all objects"
+	}
+	input["description"] = flagRbacGrantDescription
+	if flagRbacGrantDescription == "" {
+		input["description"] = fmt.Sprintf("%s for %s on %s", role, subject, target)
+	}
+	obj, err := ObjectTypeRbacstatement.Create(fa.cfg, fa.op, fa.hc, input)
+	if err != nil {
+		return NewObserveError(err, "grant")
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	return obj.PrintToYaml(fa.op, ObjectTypeRbacstatement, obj)
+}
+
+func cmdRbacRevoke(fa FuncArgs) error {
+	if len(fa.args) != 2 {
+		return ErrRbacRevokeUsage
+	}
+	if err := ObjectTypeRbacstatement.Delete(fa.cfg, fa.op, fa.hc, fa.args[1]); err != nil {
+		return NewObserveError(err, "revoke")
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	_, err := fmt.Fprintf(fa.op, "revoked %s\n", fa.args[1])
+	return err
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// `rbac audit` looks for the things quarterly access reviews look for, so
+// they don't have to be spotted in rbac-dot pictures.
+
+var (
+	ErrRbacAuditUsage  = ObserveError{Msg: "usage: observe rbac audit [--format table|json|junit]"}
+	ErrRbacAuditFormat = ObserveError{Msg: "--format must be table, json, or junit"}
+)
+
+// The audit checks, in the order they're reported.
+var rbacAuditChecks = []struct {
+	name string
+	help string
+}{
+	{"empty-group", "groups with no members"},
+	{"group-cycle", "cycles in group membership"},
+	{"dangling-statement", "statements for deleted users or groups"},
+	{"multi-path-admin", "users who are admins through more than one path"},
+	{"inactive-grant", "inactive users who still have grants"},
+}
+
+type rbacFinding struct {
+	Check  string `json:"check"`
+	Id     string `json:"id"`
+	Detail string `json:"detail"`
+}
+
+func cmdRbacAudit(fa FuncArgs) error {
+	if len(fa.args) != 1 {
+		return ErrRbacAuditUsage
+	}
+	switch flagRbacAuditFormat {
+	case "table", "json", "junit":
+	default:
+		return NewObserveError(ErrRbacAuditFormat, "%q", flagRbacAuditFormat)
+	}
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return err
+	}
+	findings := ri.audit()
+	switch flagRbacAuditFormat {
+	case "json":
+		enc := json.NewEncoder(fa.op)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []rbacFinding{}
+		}
+		return enc.Encode(findings)
+	case "junit":
+		return writeRbacAuditJUnit(fa.op, findings)
+	}
+	if len(findings) == 0 {
+		fa.op.Info("no findings\n")
+		return nil
+	}
+	out := &ColumnFormatter{Output: fa.op, OmitLineDrawing: true, LiteralStrings: true}
+	out.SetColumnNames([]string{"check", "id", "detail"})
+	for _, f := range findings {
+		out.AddRow([]string{f.Check, f.Id, f.Detail})
+	}
+	return out.Close()
+}
+
+func (ri *rbacInstanceState) audit() []rbacFinding {
+	var ret []rbacFinding
+	add := func(check, id, ff string, args ...any) {
+		ret = append(ret, rbacFinding{check, id, fmt.Sprintf(ff, args...)})
+	}
+	groupIds := sorted(maps.Keys(ri.groups))
+	memberIds := sorted(maps.Keys(ri.groupmembers))
+	statementIds := sorted(maps.Keys(ri.statements))
+	userIds := sorted(maps.Keys(ri.users))
+
+	hasMembers := map[string]bool{}
+	for _, m := range ri.groupmembers {
+		hasMembers[m.GroupId] = true
+	}
+	for _, gid := range groupIds {
+		if !hasMembers[gid] {
+			add("empty-group", gid, "group %q has no members", ri.groupName(gid))
+		}
+	}
+
+	for _, cycle := range ri.groupCycles() {
+		names := make([]string, len(cycle))
+		for i, gid := range cycle {
+			names[i] = strconv.Quote(ri.groupName(gid))
+		}
+		add("group-cycle", cycle[0], "groups %s are members of each other", strings.Join(names, ", "))
+	}
+
+	for _, sid := range statementIds {
+		s := ri.statements[sid]
+		if s.SubjectUser != nil && ri.users[*s.SubjectUser] == nil {
+			add("dangling-statement", sid, "statement %q grants %s to deleted user %d", s.Description, s.Role, *s.SubjectUser)
+		}
+		if s.SubjectGroup != nil && ri.groups[*s.SubjectGroup] == nil {
+			add("dangling-statement", sid, "statement %q grants %s to deleted group %s", s.Description, s.Role, *s.SubjectGroup)
+		}
+	}
+
+	gc := ri.groupChains(memberIds)
+	for _, uid := range userIds {
+		u := ri.users[uid]
+		if n, paths := ri.adminPaths(u, gc, statementIds); n > 1 {
+			if n > len(paths) {
+				paths = append(paths, fmt.Sprintf("and %d more", n-len(paths)))
+			}
+			add("multi-path-admin", strconv.FormatInt(uid, 10), "user %q is an admin through %d paths: %s", u.Name, n, strings.Join(paths, "; "))
+		}
+	}
+
+	for _, uid := range userIds {
+		u := ri.users[uid]
+		if u.Status == "" || u.Status == "UserStatusActive" {
+			continue
+		}
+		paths := ri.groupPaths(uid)
+		n := 0
+		for _, sid := range statementIds {
+			// statements for all users don't single out the user
+			s := ri.statements[sid]
+			if s.SubjectAll != nil && *s.SubjectAll {
+				continue
+			}
+			if _, ok := rbacSubjectChain(s, uid, paths); ok {
+				n++
+			}
+		}
+		if n > 0 {
+			add("inactive-grant", strconv.FormatInt(uid, 10), "user %q has status %s, and %d statements grant to them", u.Name, u.Status, n)
+		}
+	}
+	return ret
+}
+
+// groupCycles finds the sets of groups that are (transitively) members of
+// each other, as strongly connected components of the membership graph.
+func (ri *rbacInstanceState) groupCycles() [][]string {
+	parents := map[string][]string{}
+	selfLoop := map[string]bool{}
+	for _, mid := range sorted(maps.Keys(ri.groupmembers)) {
+		m := ri.groupmembers[mid]
+		if m.MemberGroupId != nil {
+			parents[*m.MemberGroupId] = append(parents[*m.MemberGroupId], m.GroupId)
+			if *m.MemberGroupId == m.GroupId {
+				selfLoop[m.GroupId] = true
+			}
+		}
+	}
+	var ret [][]string
+	for _, scc := range groupComponents(parents) {
+		if len(scc) > 1 || selfLoop[scc[0]] {
+			ret = append(ret, scc)
+		}
+	}
+	return ret
+}
+
+// groupComponents finds the strongly connected components of the
+// membership graph, given the groups each group is a member of, with
+// Tarjan's algorithm. Each component is sorted, and components come before
+// the ones they are members of.
+func groupComponents(parents map[string][]string) [][]string {
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var ret [][]string
+	var visit func(g string)
+	visit = func(g string) {
+		index[g] = len(index)
+		lowlink[g] = index[g]
+		stack = append(stack, g)
+		onStack[g] = true
+		for _, p := range parents[g] {
+			if _, seen := index[p]; !seen {
+				visit(p)
+				lowlink[g] = min(lowlink[g], lowlink[p])
+			} else if onStack[p] {
+				lowlink[g] = min(lowlink[g], index[p])
+			}
+		}
+		if lowlink[g] == index[g] {
+			var scc []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				scc = append(scc, top)
+				if top == g {
+					break
+				}
+			}
+			ret = append(ret, sorted(scc))
+		}
+	}
+	for _, g := range sorted(maps.Keys(parents)) {
+		if _, seen := index[g]; !seen {
+			visit(g)
+		}
+	}
+	return ret
+}
+
+// rbacAuditShownPaths caps the admin paths listed for a user; the rest are
+// only counted, as diamond-shaped group hierarchies can have very many.
+const rbacAuditShownPaths = 5
+
+// A user is an admin if their built-in role is admin, or if they're granted
+// Manager on all objects. adminPaths returns how many ways the user is an
+// admin, and describes the first few of them.
+func (ri *rbacInstanceState) adminPaths(u *objectUser, gc *rbacGroupChains, statementIds []string) (int, []string) {
+	n := 0
+	var ret []string
+	if strings.EqualFold(u.Role, "admin") {
+		n++
+		ret = append(ret, "built-in role admin")
+	}
+	for _, sid := range statementIds {
+		s := ri.statements[sid]
+		if !(s.ObjectAll != nil && *s.ObjectAll) || !rbacRoleIncludes(s.Role, "Manager") {
+			continue
+		}
+		switch {
+		case s.SubjectAll != nil && *s.SubjectAll:
+			n++
+			ret = append(ret, fmt.Sprintf("%s to all users", sid))
+		case s.SubjectUser != nil && *s.SubjectUser == u.Id:
+			n++
+			ret = append(ret, fmt.Sprintf("%s to the user", sid))
+		case s.SubjectGroup != nil:
+			for _, g := range gc.userGroups[u.Id] {
+				n += gc.count(g, *s.SubjectGroup)
+			}
+			for _, chain := range gc.chains(u.Id, *s.SubjectGroup, rbacAuditShownPaths-len(ret)) {
+				ret = append(ret, fmt.Sprintf("%s to %s", sid, chain))
+			}
+		}
+	}
+	if len(ret) > rbacAuditShownPaths {
+		ret = ret[:rbacAuditShownPaths]
+	}
+	return n, ret
+}
+
+// rbacGroupChains counts and lists the ways users are in groups, through
+// the groups they're in. Groups that are members of each other are merged
+// into one component, so that what's left has no cycles, and the counts can
+// be memoized per component and target; that way, diamond-shaped
+// hierarchies aren't walked once for each path through them. Each
+// membership that leads from one component to another is a separate path.
+type rbacGroupChains struct {
+	ri         *rbacInstanceState
+	parents    map[string][]string // the groups each group is a member of
+	userGroups map[int64][]string  // the groups each user is directly in
+	comp       map[string]int      // the component of each group
+	members    [][]string          // the groups in each component
+	counts     map[string]map[int]int
+}
+
+func (ri *rbacInstanceState) groupChains(memberIds []string) *rbacGroupChains {
+	gc := &rbacGroupChains{
+		ri:         ri,
+		parents:    map[string][]string{},
+		userGroups: map[int64][]string{},
+		comp:       map[string]int{},
+		counts:     map[string]map[int]int{},
+	}
+	for _, mid := range memberIds {
+		m := ri.groupmembers[mid]
+		switch {
+		case m.MemberGroupId != nil:
+			gc.parents[*m.MemberGroupId] = append(gc.parents[*m.MemberGroupId], m.GroupId)
+		case m.MemberUserId != nil:
+			gc.userGroups[*m.MemberUserId] = append(gc.userGroups[*m.MemberUserId], m.GroupId)
+		}
+	}
+	gc.members = groupComponents(gc.parents)
+	for c, groups := range gc.members {
+		for _, g := range groups {
+			gc.comp[g] = c
+		}
+	}
+	return gc
+}
+
+// component returns the component of a group, which is a component of its
+// own when it isn't a member of any group.
+func (gc *rbacGroupChains) component(g string) int {
+	if c, has := gc.comp[g]; has {
+		return c
+	}
+	gc.comp[g] = len(gc.members)
+	gc.members = append(gc.members, []string{g})
+	return gc.comp[g]
+}
+
+// count is the number of membership paths from group g up to target.
+func (gc *rbacGroupChains) count(g, target string) int {
+	return gc.countFrom(gc.component(g), gc.component(target), target)
+}
+
+func (gc *rbacGroupChains) countFrom(c, tc int, target string) int {
+	if c == tc {
+		return 1
+	}
+	memo := gc.counts[target]
+	if memo == nil {
+		memo = map[int]int{}
+		gc.counts[target] = memo
+	}
+	if n, has := memo[c]; has {
+		return n
+	}
+	n := 0
+	for _, h := range gc.members[c] {
+		for _, p := range gc.parents[h] {
+			if pc := gc.component(p); pc != c {
+				n += gc.countFrom(pc, tc, target)
+			}
+		}
+	}
+	memo[c] = n
+	return n
+}
+
+// route is the shortest way from group g to group to, in the same
+// component, as a list of groups that starts with g and ends with to.
+func (gc *rbacGroupChains) route(g, to string) []string {
+	c := gc.comp[g]
+	prev := map[string]string{g: ""}
+	queue := []string{g}
+	for len(queue) > 0 && to != g {
+		h := queue[0]
+		queue = queue[1:]
+		for _, p := range gc.parents[h] {
+			if _, seen := prev[p]; seen || gc.comp[p] != c {
+				continue
+			}
+			prev[p] = h
+			if p == to {
+				queue = nil
+				break
+			}
+			queue = append(queue, p)
+		}
+	}
+	ret := []string{to}
+	for h := to; h != g; {
+		h = prev[h]
+		ret = append([]string{h}, ret...)
+	}
+	return ret
+}
+
+// chains describes up to max of the ways the user is in the group, as
+// "a" > "b". Only components with a path to the target are walked into;
+// within a component, the shortest route through it is shown.
+func (gc *rbacGroupChains) chains(uid int64, target string, max int) []string {
+	tc := gc.component(target)
+	var ret []string
+	var chain []string
+	var walk func(g string)
+	walk = func(g string) {
+		c := gc.component(g)
+		if len(ret) >= max || gc.countFrom(c, tc, target) == 0 {
+			return
+		}
+		if c == tc {
+			names := make([]string, 0, len(chain)+1)
+			for _, h := range append(chain, gc.route(g, target)...) {
+				names = append(names, strconv.Quote(gc.ri.groupName(h)))
+			}
+			ret = append(ret, strings.Join(names, " > "))
+			return
+		}
+		for _, h := range gc.members[c] {
+			for _, p := range gc.parents[h] {
+				if gc.component(p) == c {
+					continue
+				}
+				n := len(chain)
+				chain = append(chain, gc.route(g, h)...)
+				walk(p)
+				chain = chain[:n]
+			}
+		}
+	}
+	for _, g := range gc.userGroups[uid] {
+		walk(g)
+	}
+	return ret
+}
+
+// In JUnit, each check is a test case that fails when it has findings.
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeRbacAuditJUnit(op Output, findings []rbacFinding) error {
+	suite := junitTestSuite{Name: "rbac audit", Tests: len(rbacAuditChecks)}
+	for _, check := range rbacAuditChecks {
+		tc := junitTestCase{Name: check.name, ClassName: "rbac.audit"}
+		var lines []string
+		for _, f := range findings {
+			if f.Check == check.name {
+				lines = append(lines, fmt.Sprintf("%s: %s", f.Id, f.Detail))
+			}
+		}
+		if len(lines) > 0 {
+			suite.Failures++
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d %s", len(lines), check.help),
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	if _, err := fmt.Fprint(op, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(op)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(op)
+	return err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/exp/maps"
+)
+
+const testRbacAuditGroups = `{"data":{"rbacGroups":[
+	{"id":"o::101:rbacgroup:8000001001","name":"reader","description":""},
+	{"id":"o::101:rbacgroup:8000001002","name":"writer","description":""},
+	{"id":"o::101:rbacgroup:8000001003","name":"admins","description":""}
+]}}`
+
+const testRbacAuditGroupmembers = `{"data":{"rbacGroupmembers":[
+	{"id":"o::101:rbacgroupmember:8000001011","description":"","groupid":"o::101:rbacgroup:8000001001","membergroupid":null,"memberuserid":"3"},
+	{"id":"o::101:rbacgroupmember:8000001012","description":"","groupid":"o::101:rbacgroup:8000001002","membergroupid":"o::101:rbacgroup:8000001001","memberuserid":null},
+	{"id":"o::101:rbacgroupmember:8000001013","description":"","groupid":"o::101:rbacgroup:8000001001","membergroupid":"o::101:rbacgroup:8000001002","memberuserid":null}
+]}}`
+
+const testRbacAuditStatements = `{"data":{"rbacStatements":[
+	{"id":"o::101:rbacstatement:8000002001","description":"writers manage","subject":{"userId":null,"groupId":"o::101:rbacgroup:8000001002","all":null},"object":{"objectId":null,"folderId":null,"workspaceId":null,"type":null,"name":null,"owner":null,"all":true},"role":"Manager"},
+	{"id":"o::101:rbacstatement:8000002002","description":"jane manages","subject":{"userId":"3","groupId":null,"all":null},"object":{"objectId":null,"folderId":null,"workspaceId":null,"type":null,"name":null,"owner":null,"all":true},"role":"Manager"},
+	{"id":"o::101:rbacstatement:8000002003","description":"joe views","subject":{"userId":"5","groupId":null,"all":null},"object":{"objectId":null,"folderId":null,"workspaceId":"4100","type":null,"name":null,"owner":null,"all":null},"role":"Viewer"},
+	{"id":"o::101:rbacstatement:8000002004","description":"gone edit","subject":{"userId":null,"groupId":"o::101:rbacgroup:8000001009","all":null},"object":{"objectId":null,"folderId":null,"workspaceId":null,"type":null,"name":null,"owner":null,"all":true},"role":"Editor"}
+]}}`
+
+func startRbacAuditFixture(t *testing.T) *testFixture {
+	return startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacAuditGroups},
+		testRequest{"/v1/meta", 200, testRbacAuditGroupmembers},
+		testRequest{"/v1/meta", 200, testRbacAuditStatements},
+	)
+}
+
+func TestCmdRbacAuditJSON(t *testing.T) {
+	fix := startRbacAuditFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "audit", "--format", "json"}, fix.hc)
+	fix.Assert()
+	var findings []rbacFinding
+	if err := json.Unmarshal(fix.op.OutputBuf.Bytes(), &findings); err != nil {
+		t.Fatal("bad JSON:", err, fix.op.OutputBuf.String())
+	}
+	if diff := cmp.Diff(findings, []rbacFinding{
+		{"empty-group", "o::101:rbacgroup:8000001003", `group "admins" has no members`},
+		{"group-cycle", "o::101:rbacgroup:8000001001", `groups "reader", "writer" are members of each other`},
+		{"dangling-statement", "o::101:rbacstatement:8000002004", `statement "gone edit" grants Editor to deleted group o::101:rbacgroup:8000001009`},
+		{"multi-path-admin", "3", `user "Jane" is an admin through 2 paths: o::101:rbacstatement:8000002001 to "reader" > "writer"; o::101:rbacstatement:8000002002 to the user`},
+		{"inactive-grant", "5", `user "Joe" has status UserStatusDeactivated, and 1 statements grant to them`},
+	}); diff != "" {
+		t.Error("unexpected findings:", diff)
+	}
+}
+
+func TestCmdRbacAuditTable(t *testing.T) {
+	fix := startRbacAuditFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "audit"}, fix.hc)
+	fix.Assert()
+	lines := strings.Split(fix.op.OutputBuf.String(), "\n")
+	if len(lines) != 7 || !strings.HasPrefix(lines[0], "check ") || !strings.HasPrefix(lines[1], "empty-group        o::101:rbacgroup:8000001003 ") {
+		t.Error("unexpected output:", fix.op.OutputBuf.String())
+	}
+}
+
+func TestCmdRbacAuditJUnit(t *testing.T) {
+	fix := startRbacAuditFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "audit", "--format", "junit"}, fix.hc)
+	fix.Assert()
+	out := fix.op.OutputBuf.String()
+	for _, want := range []string{
+		`<testsuite name="rbac audit" tests="5" failures="5">`,
+		`<testcase name="empty-group" classname="rbac.audit">`,
+		`<failure message="1 groups with no members">o::101:rbacgroup:8000001003: group &#34;admins&#34; has no members</failure>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCmdRbacAuditFormat(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "audit", "--format", "xml"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacAuditFormat.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdRbacAuditDiamonds(t *testing.T) {
+	// 20 diamonds in a row: 2^20 ways up from "g0" to "g20"
+	var groups, members []string
+	member := func(gid, sub string) {
+		members = append(members, fmt.Sprintf(`{"id":"o::101:rbacgroupmember:%d","description":"","groupid":"%s","membergroupid":"%s","memberuserid":null}`, 8000003000+len(members), gid, sub))
+	}
+	gid := func(name string) string { return "o::101:rbacgroup:" + name }
+	for i := 0; i <= 20; i++ {
+		groups = append(groups, fmt.Sprintf(`{"id":"%s","name":"g%d","description":""}`, gid(fmt.Sprint("g", i)), i))
+		if i == 0 {
+			continue
+		}
+		for _, side := range []string{"a", "b"} {
+			name := fmt.Sprint(side, i)
+			groups = append(groups, fmt.Sprintf(`{"id":"%s","name":"%s","description":""}`, gid(name), name))
+			member(gid(name), gid(fmt.Sprint("g", i-1)))
+			member(gid(fmt.Sprint("g", i)), gid(name))
+		}
+	}
+	members = append(members, `{"id":"o::101:rbacgroupmember:8000003999","description":"","groupid":"o::101:rbacgroup:g0","membergroupid":null,"memberuserid":"3"}`)
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, `{"data":{"rbacGroups":[` + strings.Join(groups, ",") + `]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"rbacGroupmembers":[` + strings.Join(members, ",") + `]}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"rbacStatements":[
+			{"id":"o::101:rbacstatement:8000002001","description":"top manages","subject":{"userId":null,"groupId":"o::101:rbacgroup:g20","all":null},"object":{"objectId":null,"folderId":null,"workspaceId":null,"type":null,"name":null,"owner":null,"all":true},"role":"Manager"}
+		]}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "audit", "--format", "json"}, fix.hc)
+	fix.Assert()
+	var findings []rbacFinding
+	if err := json.Unmarshal(fix.op.OutputBuf.Bytes(), &findings); err != nil {
+		t.Fatal("bad JSON:", err, fix.op.OutputBuf.String())
+	}
+	var detail string
+	for _, f := range findings {
+		if f.Check == "multi-path-admin" {
+			detail = f.Detail
+		}
+	}
+	if !strings.HasPrefix(detail, `user "Jane" is an admin through 1048576 paths: o::101:rbacstatement:8000002001 to "g0" > "a1" > "g1" > `) ||
+		strings.Count(detail, "o::101:rbacstatement:8000002001 to ") != rbacAuditShownPaths ||
+		!strings.HasSuffix(detail, "; and 1048571 more") {
+		t.Error("unexpected finding:", detail)
+	}
+}
+
+func TestRbacGroupChainsCycle(t *testing.T) {
+	// A and B are members of each other, A is in T, and the user is in B
+	a, b := "A", "B"
+	uid := int64(3)
+	ri := &rbacInstanceState{
+		groups: map[string]*objectRbacgroup{"A": {Id: "A", Name: "A"}, "B": {Id: "B", Name: "B"}, "T": {Id: "T", Name: "T"}},
+		groupmembers: map[string]*objectRbacgroupmember{
+			"1": {Id: "1", GroupId: "B", MemberGroupId: &a},
+			"2": {Id: "2", GroupId: "A", MemberGroupId: &b},
+			"3": {Id: "3", GroupId: "T", MemberGroupId: &a},
+			"4": {Id: "4", GroupId: "B", MemberUserId: &uid},
+		},
+	}
+	gc := ri.groupChains(sorted(maps.Keys(ri.groupmembers)))
+	// whichever group is counted first, the other counts the same
+	if n := gc.count("A", "T"); n != 1 {
+		t.Error("unexpected count from A:", n)
+	}
+	if n := gc.count("B", "T"); n != 1 {
+		t.Error("unexpected count from B:", n)
+	}
+	if diff := cmp.Diff(gc.chains(uid, "T", rbacAuditShownPaths), []string{`"B" > "A" > "T"`}); diff != "" {
+		t.Error("unexpected chains:", diff)
+	}
+	if diff := cmp.Diff(gc.chains(uid, "A", rbacAuditShownPaths), []string{`"B" > "A"`}); diff != "" {
+		t.Error("unexpected chains:", diff)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// `rbac check` answers "why can't this user see that?" offline: it loads all
+// users, groups, memberships, and statements, and evaluates them the way the
+// server does, explaining which statements grant the role, or why none do.
+
+var (
+	ErrRbacCheckUsage    = ObserveError{Msg: "usage: observe rbac check --user <user> --object <type>:<id> --role <role>"}
+	ErrRbacCheckObject   = ObserveError{Msg: "--object must be <type>:<id>, with a numeric id"}
+	ErrRbacCheckNoObject = ObserveError{Msg: "object not found"}
+	ErrRbacCheckNoUser   = ObserveError{Msg: "user not found"}
+	ErrRbacCheckDenied   = ObserveError{Msg: "the user does not have the role"}
+)
+
+// rbacTarget is what statements can select an object by. Scopes the object
+// type doesn't report are nil, and statements scoped by them don't match.
+type rbacTarget struct {
+	typ         string
+	id          int64
+	name        string
+	folderId    *int64
+	workspaceId *int64
+	ownerId     *int64
+}
+
+func (t *rbacTarget) String() string {
+	if t.name != "" {
+		return fmt.Sprintf("%s %d (%s)", t.typ, t.id, t.name)
+	}
+	return fmt.Sprintf("%s %d", t.typ, t.id)
+}
+
+// resolveRbacTarget parses <type>:<id> and looks the object up, to learn its
+// name, folder, workspace, and owner.
+func resolveRbacTarget(fa FuncArgs, spec string) (*rbacTarget, error) {
+	typ, idstr, _ := strings.Cut(spec, ":")
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if typ == "" || err != nil {
+		return nil, NewObserveError(ErrRbacCheckObject, "%q", spec)
+	}
+	t := &rbacTarget{typ: typ, id: id}
+	if typ == "workspace" {
+		t.workspaceId = &t.id
+	}
+	ot := GetObjectType(typ)
+	if ot == nil || !ot.CanGet() {
+		fa.op.Info("can't look up %s objects; statements scoped by name, folder, workspace, or owner won't match\n", typ)
+		return t, nil
+	}
+	obj, err := ot.Get(fa.cfg, fa.op, fa.hc, idstr)
+	if err != nil {
+		return nil, NewObserveError(err, "get %s %s", typ, idstr)
+	}
+	if obj == nil {
+		return nil, NewObserveError(ErrRbacCheckNoObject, "%s", spec)
+	}
+	for _, v := range obj.GetValues() {
+		val := v.GetValue()
+		n, isInt := val.(int64)
+		switch v.GetDesc().Name {
+		case "name":
+			t.name, _ = val.(string)
+		case "folderId":
+			if isInt {
+				t.folderId = &n
+			}
+		case "workspaceId":
+			if isInt {
+				t.workspaceId = &n
+			}
+		case "createdBy":
+			if isInt {
+				t.ownerId = &n
+			}
+		}
+	}
+	return t, nil
+}
+
+// rbacRoleIncludes tells whether holding one role gives another.
+func rbacRoleIncludes(held, want string) bool {
+	hi, wi := -1, -1
+	for i, r := range rbacRoles {
+		if strings.EqualFold(r, held) {
+			hi = i
+		}
+		if strings.EqualFold(r, want) {
+			wi = i
+		}
+	}
+	if hi < 0 || wi < 0 {
+		return strings.EqualFold(held, want)
+	}
+	return hi >= wi
+}
+
+func (ri *rbacInstanceState) findUser(idOrName string) (*objectUser, error) {
+	if id, err := strconv.ParseInt(idOrName, 10, 64); err == nil {
+		if u, has := ri.users[id]; has {
+			return u, nil
+		}
+		return nil, NewObserveError(ErrRbacCheckNoUser, "%s", idOrName)
+	}
+	var infos []*ObjectInfo
+	for _, id := range sorted(maps.Keys(ri.users)) {
+		u := ri.users[id]
+		if strings.EqualFold(u.Email, idOrName) {
+			return u, nil
+		}
+		infos = append(infos, u.GetInfo())
+	}
+	info, err := matchObject("user", idOrName, infos)
+	if err != nil {
+		return nil, err
+	}
+	return info.Object.(*objectUser), nil
+}
+
+func (ri *rbacInstanceState) groupName(id string) string {
+	if g, has := ri.groups[id]; has {
+		return g.Name
+	}
+	return id
+}
+
+// groupPaths finds every group the user is in, directly or through other
+// groups, with the shortest chain of groups that leads there, starting at a
+// group the user is a direct member of. Cycles are harmless.
+func (ri *rbacInstanceState) groupPaths(uid int64) map[string][]string {
+	memberIds := sorted(maps.Keys(ri.groupmembers))
+	paths := map[string][]string{}
+	var queue []string
+	for _, mid := range memberIds {
+		m := ri.groupmembers[mid]
+		if m.MemberUserId != nil && *m.MemberUserId == uid && paths[m.GroupId] == nil {
+			paths[m.GroupId] = []string{m.GroupId}
+			queue = append(queue, m.GroupId)
+		}
+	}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		for _, mid := range memberIds {
+			m := ri.groupmembers[mid]
+			if m.MemberGroupId != nil && *m.MemberGroupId == g && paths[m.GroupId] == nil {
+				paths[m.GroupId] = append(append([]string{}, paths[g]...), m.GroupId)
+				queue = append(queue, m.GroupId)
+			}
+		}
+	}
+	return paths
+}
+
+// rbacSubjectChain tells whether the statement applies to the user, and
+// through which groups.
+func rbacSubjectChain(s *objectRbacstatement, uid int64, paths map[string][]string) ([]string, bool) {
+	switch {
+	case s.SubjectAll != nil && *s.SubjectAll:
+		return nil, true
+	case s.SubjectUser != nil:
+		return nil, *s.SubjectUser == uid
+	case s.SubjectGroup != nil:
+		path, has := paths[*s.SubjectGroup]
+		return path, has
+	}
+	return nil, false
+}
+
+// rbacCovers tells whether the statement's object selector matches t.
+// Owner statements cover the objects that the user being checked owns.
+func rbacCovers(s *objectRbacstatement, t *rbacTarget, uid int64) bool {
+	eq := func(a, b *int64) bool { return a != nil && b != nil && *a == *b }
+	switch {
+	case s.ObjectAll != nil && *s.ObjectAll:
+		return true
+	case s.ObjectObjectId != nil:
+		return *s.ObjectObjectId == t.id
+	case s.ObjectFolderId != nil:
+		return eq(s.ObjectFolderId, t.folderId)
+	case s.ObjectWorkspaceId != nil:
+		return eq(s.ObjectWorkspaceId, t.workspaceId)
+	case s.ObjectType != nil:
+		return strings.EqualFold(*s.ObjectType, t.typ) && (s.ObjectName == nil || *s.ObjectName == t.name)
+	case s.ObjectOwner != nil && *s.ObjectOwner:
+		return eq(t.ownerId, &uid)
+	}
+	return false
+}
+
+func rbacScope(s *objectRbacstatement) string {
+	switch {
+	case s.ObjectAll != nil && *s.ObjectAll:
+		return "all objects"
+	case s.ObjectObjectId != nil:
+		return fmt.Sprintf("object %d", *s.ObjectObjectId)
+	case s.ObjectFolderId != nil:
+		return fmt.Sprintf("folder %d", *s.ObjectFolderId)
+	case s.ObjectWorkspaceId != nil:
+		return fmt.Sprintf("workspace %d", *s.ObjectWorkspaceId)
+	case s.ObjectType != nil && s.ObjectName != nil:
+		return fmt.Sprintf("%s %q", *s.ObjectType, *s.ObjectName)
+	case s.ObjectType != nil:
+		return fmt.Sprintf("all %s objects", *s.ObjectType)
+	case s.ObjectOwner != nil && *s.ObjectOwner:
+		return "the objects the user owns"
+	}
+	return "nothing"
+}
+
+func (ri *rbacInstanceState) printStatement(op Output, s *objectRbacstatement, chain []string) {
+	fmt.Fprintf(op, "  statement %s (%s) grants %s on %s\n", s.Id, s.Description, s.Role, rbacScope(s))
+	switch {
+	case s.SubjectAll != nil && *s.SubjectAll:
+		fmt.Fprintf(op, "    to all users\n")
+	case s.SubjectUser != nil:
+		fmt.Fprintf(op, "    to the user directly\n")
+	case len(chain) == 1:
+		fmt.Fprintf(op, "    to group %q, which the user is a member of\n", ri.groupName(chain[0]))
+	default:
+		names := make([]string, len(chain))
+		for i, g := range chain {
+			names[i] = strconv.Quote(ri.groupName(g))
+		}
+		fmt.Fprintf(op, "    to group %q, which the user is in through %s\n", ri.groupName(chain[len(chain)-1]), strings.Join(names, " > "))
+	}
+}
+
+func cmdRbacCheck(fa FuncArgs) error {
+	if len(fa.args) != 1 || flagRbacCheckUser == "" || flagRbacCheckObject == "" || flagRbacCheckRole == "" {
+		return ErrRbacCheckUsage
+	}
+	role, err := normalizeRbacRole(flagRbacCheckRole)
+	if err != nil {
+		return err
+	}
+	target, err := resolveRbacTarget(fa, flagRbacCheckObject)
+	if err != nil {
+		return err
+	}
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return err
+	}
+	user, err := ri.findUser(flagRbacCheckUser)
+	if err != nil {
+		return err
+	}
+	who := fmt.Sprintf("user %q (%d)", user.Name, user.Id)
+	if user.Status != "" && user.Status != "UserStatusActive" {
+		fa.op.Info("%s has status %s\n", who, user.Status)
+	}
+
+	paths := ri.groupPaths(user.Id)
+	type applied struct {
+		stmt  *objectRbacstatement
+		chain []string
+	}
+	var granting, weaker []applied
+	elsewhere := 0
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		s := ri.statements[sid]
+		chain, ok := rbacSubjectChain(s, user.Id, paths)
+		if !ok {
+			continue
+		}
+		switch {
+		case !rbacCovers(s, target, user.Id):
+			elsewhere++
+		case rbacRoleIncludes(s.Role, role):
+			granting = append(granting, applied{s, chain})
+		default:
+			weaker = append(weaker, applied{s, chain})
+		}
+	}
+
+	// the built-in admin role is above RBAC, as in `rbac audit`
+	builtinAdmin := strings.EqualFold(user.Role, "admin")
+	if builtinAdmin || len(granting) > 0 {
+		fmt.Fprintf(fa.op, "%s has %s on %s:\n", who, role, target)
+		if builtinAdmin {
+			fmt.Fprintf(fa.op, "  the user's built-in role is admin, which has every role on every object\n")
+		}
+		for _, a := range granting {
+			ri.printStatement(fa.op, a.stmt, a.chain)
+		}
+		return nil
+	}
+	fmt.Fprintf(fa.op, "%s does not have %s on %s:\n", who, role, target)
+	if len(paths) == 0 {
+		fmt.Fprintf(fa.op, "  the user is not in any group\n")
+	} else {
+		var names []string
+		for g := range paths {
+			names = append(names, strconv.Quote(ri.groupName(g)))
+		}
+		fmt.Fprintf(fa.op, "  the user is in groups %s\n", strings.Join(sorted(names), ", "))
+	}
+	for _, a := range weaker {
+		ri.printStatement(fa.op, a.stmt, a.chain)
+		fmt.Fprintf(fa.op, "    but %s does not include %s\n", a.stmt.Role, role)
+	}
+	switch {
+	case elsewhere > 0:
+		fmt.Fprintf(fa.op, "  %d other statements apply to the user, but not to this object\n", elsewhere)
+	case len(weaker) == 0:
+		fmt.Fprintf(fa.op, "  no statements apply to the user\n")
+	}
+	return ErrRbacCheckDenied
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testRbacUsers = `{"data":{"currentCustomer":{"users":[
+	{"id":"3","name":"Jane","email":"jane@example.com","status":"UserStatusActive","role":"reader"},
+	{"id":"5","name":"Joe","email":"joe@example.com","status":"UserStatusDeactivated","role":"reader"}
+]}}}`
+
+const testRbacStatements = `{"data":{"rbacStatements":[
+	{"id":"o::101:rbacstatement:8000002001","description":"everyone lists","subject":{"userId":null,"groupId":null,"all":true},"object":{"objectId":null,"folderId":null,"workspaceId":null,"type":null,"name":null,"owner":null,"all":true},"role":"Lister"},
+	{"id":"o::101:rbacstatement:8000002002","description":"writers edit","subject":{"userId":null,"groupId":"o::101:rbacgroup:8000001002","all":null},"object":{"objectId":null,"folderId":null,"workspaceId":"4100","type":null,"name":null,"owner":null,"all":null},"role":"Editor"},
+	{"id":"o::101:rbacstatement:8000002003","description":"joe manages","subject":{"userId":"5","groupId":null,"all":null},"object":{"objectId":"999","folderId":null,"workspaceId":null,"type":null,"name":null,"owner":null,"all":null},"role":"Manager"}
+]}}`
+
+const testRbacDataset = `{"data":{"dataset":{"id":"41007104","name":"logs","workspaceId":"4100","folderId":"4101","path":"Default.logs","kind":"Event","version":"1","updatedDate":"2024-01-01T00:00:00Z"}}}`
+
+func TestCmdRbacCheckGranted(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacDataset},
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, testRbacStatements},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "check", "--user", "jane@example.com", "--object", "dataset:41007104", "--role", "viewer"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `user "Jane" (3) has Viewer on dataset 41007104 (logs):
+  statement o::101:rbacstatement:8000002002 (writers edit) grants Editor on workspace 4100
+    to group "writer", which the user is in through "reader" > "writer"
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacCheckDenied(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacDataset},
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, testRbacStatements},
+	)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "check", "--user", "5", "--object", "dataset:41007104", "--role", "Viewer"}, fix.hc)
+	})
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `user "Joe" (5) does not have Viewer on dataset 41007104 (logs):
+  the user is not in any group
+  statement o::101:rbacstatement:8000002001 (everyone lists) grants Lister on all objects
+    to all users
+    but Lister does not include Viewer
+  1 other statements apply to the user, but not to this object
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "UserStatusDeactivated") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacCheckDenied.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestRbacGroupPathsCycle(t *testing.T) {
+	a, b := "o::101:rbacgroup:1", "o::101:rbacgroup:2"
+	uid := int64(7)
+	ri := &rbacInstanceState{groupmembers: map[string]*objectRbacgroupmember{
+		"m1": {Id: "m1", GroupId: a, MemberUserId: &uid},
+		"m2": {Id: "m2", GroupId: b, MemberGroupId: &a},
+		"m3": {Id: "m3", GroupId: a, MemberGroupId: &b},
+	}}
+	if diff := cmp.Diff(ri.groupPaths(uid), map[string][]string{a: {a}, b: {a, b}}); diff != "" {
+		t.Error("unexpected paths:", diff)
+	}
+}
+
+func TestCmdRbacCheckOwner(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"dataset":{"id":"41007104","name":"logs","workspaceId":"4100","folderId":"4101","path":"Default.logs","kind":"Event","version":"1","updatedDate":"2024-01-01T00:00:00Z","createdBy":"3"}}}`},
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, `{"data":{"rbacStatements":[
+			{"id":"o::101:rbacstatement:8000002004","description":"owners manage","subject":{"userId":null,"groupId":null,"all":true},"object":{"objectId":null,"folderId":null,"workspaceId":null,"type":null,"name":null,"owner":true,"all":null},"role":"Manager"}
+		]}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "check", "--user", "3", "--object", "dataset:41007104", "--role", "manager"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `user "Jane" (3) has Manager on dataset 41007104 (logs):
+  statement o::101:rbacstatement:8000002004 (owners manage) grants Manager on the objects the user owns
+    to all users
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacCheckBuiltinAdmin(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacDataset},
+		testRequest{"/v1/meta", 200, `{"data":{"currentCustomer":{"users":[{"id":"7","name":"Ada","email":"ada@example.com","status":"UserStatusActive","role":"admin"}]}}}`},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, testRbacStatements},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "check", "--user", "7", "--object", "dataset:41007104", "--role", "manager"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `user "Ada" (7) has Manager on dataset 41007104 (logs):
+  the user's built-in role is admin, which has every role on every object
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
@@ -2,20 +2,31 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/pflag"
+	"golang.org/x/exp/maps"
 )
 
 var (
-	flagsRbacDot    *pflag.FlagSet
-	flagRbacDotUser string
-	flagRbacDotAll  bool
+	flagsRbacDot      *pflag.FlagSet
+	flagRbacDotUser   string
+	flagRbacDotGroup  string
+	flagRbacDotObject string
+	flagRbacDotRole   string
+	flagRbacDotAll    bool
+	flagRbacDotFormat string
 )
 
 func init() {
 	flagsRbacDot = pflag.NewFlagSet("rbac-dot", pflag.ContinueOnError)
 	flagsRbacDot.StringVar(&flagRbacDotUser, "user", "", "a user to plot membership graph for")
+	flagsRbacDot.StringVar(&flagRbacDotGroup, "group", "", "a group to plot members and grants for, by ID or name")
+	flagsRbacDot.StringVar(&flagRbacDotObject, "object", "", "an object, as <type>:<id>, to plot who has access to")
+	flagsRbacDot.StringVar(&flagRbacDotRole, "role", "", "a role to plot the statements and subjects for")
 	flagsRbacDot.BoolVar(&flagRbacDotAll, "all", false, "plot all users/group/statements")
+	flagsRbacDot.StringVar(&flagRbacDotFormat, "format", "dot", "output format: dot, mermaid, or json-graph")
 	RegisterCommand(&Command{
 		Name:            "rbac-dot",
 		Help:            "Generate GraphViz visualizations of RBAC relationships.",
@@ -27,17 +38,25 @@ func init() {
 }
 
 var ErrMultiplePlots = ObserveError{Msg: "you can only specify one kind of plot (--user, etc)"}
-var ErrMustSpecifyPlot = ObserveError{Msg: "you must specify exactly one plot (--user, etc)"}
+var ErrMustSpecifyPlot = ObserveError{Msg: "you must specify exactly one plot (--user, --group, --object, --role, or --all)"}
+var ErrRbacDotFormat = ObserveError{Msg: "--format must be dot, mermaid, or json-graph"}
 
 func cmdRbacDot(fa FuncArgs) error {
-	var todo func(FuncArgs) error
+	render, has := rbacGraphRenderers[flagRbacDotFormat]
+	if !has {
+		return NewObserveError(ErrRbacDotFormat, "%q", flagRbacDotFormat)
+	}
+	var todo func(FuncArgs) (*rbacGraph, error)
 	var prev string
 	for _, opt := range []struct {
 		oarg  string
 		oname string
-		fun   func(FuncArgs) error
+		fun   func(FuncArgs) (*rbacGraph, error)
 	}{
 		{flagRbacDotUser, "user", cmdRbacDotUser},
+		{flagRbacDotGroup, "group", cmdRbacDotGroup},
+		{flagRbacDotObject, "object", cmdRbacDotObject},
+		{flagRbacDotRole, "role", cmdRbacDotRole},
 	} {
 		if opt.oarg != "" {
 			if prev != "" {
@@ -48,43 +67,89 @@ func cmdRbacDot(fa FuncArgs) error {
 		}
 	}
 	if todo == nil {
-		if flagRbacDotAll {
-			return cmdRbacDotAll(fa)
+		if !flagRbacDotAll {
+			return ErrMustSpecifyPlot
 		}
-		return ErrMustSpecifyPlot
-	}
-	if flagRbacDotAll {
+		todo = cmdRbacDotAll
+	} else if flagRbacDotAll {
 		return NewObserveError(ErrMultiplePlots, "%s and all", prev)
 	}
-	return todo(fa)
+	g, err := todo(fa)
+	if err != nil {
+		return err
+	}
+	return render(fa.op, g)
 }
 
-func cmdRbacDotUser(fa FuncArgs) error {
+func cmdRbacDotUser(fa FuncArgs) (*rbacGraph, error) {
 	u, err := ObjectTypeUser.Get(fa.cfg, fa.op, fa.hc, flagRbacDotUser)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	user := u.(*objectUser)
-	gs, err := ObjectTypeRbacgroup.List(fa.cfg, fa.op, fa.hc)
+	gs, err := ObjectTypeRbacgroup.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	groupmap := map[string]*objectRbacgroup{}
 	for _, g := range gs {
 		gg := g.Object.(*objectRbacgroup)
 		groupmap[gg.Id] = gg
 	}
-	ms, err := ObjectTypeRbacgroupmember.List(fa.cfg, fa.op, fa.hc)
+	ms, err := ObjectTypeRbacgroupmember.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	membermap := map[string]*objectRbacgroupmember{}
 	for _, m := range ms {
 		mm := m.Object.(*objectRbacgroupmember)
 		membermap[mm.Id] = mm
 	}
-	plotUserGroupsDot(fa.op, user, groupmap, membermap)
-	return nil
+	return plotUserGroups(user, groupmap, membermap), nil
+}
+
+func cmdRbacDotGroup(fa FuncArgs) (*rbacGraph, error) {
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return nil, err
+	}
+	gid, err := ri.findGroup(flagRbacDotGroup)
+	if err != nil {
+		return nil, err
+	}
+	return plotGroup(ri, gid), nil
+}
+
+func cmdRbacDotObject(fa FuncArgs) (*rbacGraph, error) {
+	target, err := resolveRbacTarget(fa, flagRbacDotObject)
+	if err != nil {
+		return nil, err
+	}
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return nil, err
+	}
+	return plotObject(ri, target), nil
+}
+
+func cmdRbacDotRole(fa FuncArgs) (*rbacGraph, error) {
+	role, err := normalizeRbacRole(flagRbacDotRole)
+	if err != nil {
+		return nil, err
+	}
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return nil, err
+	}
+	return plotRole(ri, role), nil
+}
+
+func cmdRbacDotAll(fa FuncArgs) (*rbacGraph, error) {
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return nil, err
+	}
+	return plotFullConnectivity(ri), nil
 }
 
 type rbacInstanceState struct {
@@ -95,7 +160,7 @@ type rbacInstanceState struct {
 }
 
 func (ri *rbacInstanceState) fillUsers(fa FuncArgs) error {
-	us, err := ObjectTypeUser.List(fa.cfg, fa.op, fa.hc)
+	us, err := ObjectTypeUser.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
 		return err
 	}
@@ -108,7 +173,7 @@ func (ri *rbacInstanceState) fillUsers(fa FuncArgs) error {
 }
 
 func (ri *rbacInstanceState) fillGroups(fa FuncArgs) error {
-	gs, err := ObjectTypeRbacgroup.List(fa.cfg, fa.op, fa.hc)
+	gs, err := ObjectTypeRbacgroup.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
 		return err
 	}
@@ -121,7 +186,7 @@ func (ri *rbacInstanceState) fillGroups(fa FuncArgs) error {
 }
 
 func (ri *rbacInstanceState) fillGroupMembers(fa FuncArgs) error {
-	ms, err := ObjectTypeRbacgroupmember.List(fa.cfg, fa.op, fa.hc)
+	ms, err := ObjectTypeRbacgroupmember.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
 		return err
 	}
@@ -134,7 +199,7 @@ func (ri *rbacInstanceState) fillGroupMembers(fa FuncArgs) error {
 }
 
 func (ri *rbacInstanceState) fillStatements(fa FuncArgs) error {
-	ss, err := ObjectTypeRbacstatement.List(fa.cfg, fa.op, fa.hc)
+	ss, err := ObjectTypeRbacstatement.List(fa.cfg, fa.fs, fa.op, fa.hc)
 	if err != nil {
 		return err
 	}
@@ -162,55 +227,61 @@ func (ri *rbacInstanceState) fillAll(fa FuncArgs) error {
 	return nil
 }
 
-func cmdRbacDotAll(fa FuncArgs) error {
-	ri := &rbacInstanceState{}
-	if err := ri.fillAll(fa); err != nil {
-		return err
+func (ri *rbacInstanceState) findGroup(idOrName string) (string, error) {
+	if _, has := ri.groups[idOrName]; has {
+		return idOrName, nil
 	}
-	plotFullConnectivityDot(fa.op, ri)
-	return nil
+	var infos []*ObjectInfo
+	for _, gid := range sorted(maps.Keys(ri.groups)) {
+		infos = append(infos, ri.groups[gid].GetInfo())
+	}
+	info, err := matchObject("rbacgroup", idOrName, infos)
+	if err != nil {
+		return "", err
+	}
+	return info.Id, nil
 }
 
-func plotUserGroupsDot(op Output, user *objectUser, groupmap map[string]*objectRbacgroup, membermap map[string]*objectRbacgroupmember) {
-	fmt.Fprintf(op, "digraph {\n")
-	fmt.Fprintf(op, "  node [shape=box];\n")
-	fmt.Fprintf(op, "  rankdir=LR;\n")
-	fmt.Fprintf(op, "  ranksep=1.5;\n")
-	fmt.Fprintf(op, "  \"%d\" [label=%q];\n", user.Id, user.Name)
+// plotUserGroups plots the groups the user is in, directly or through other
+// groups, breadth first and in ID order, so the output is stable. A
+// membership in a group that no longer exists is plotted, but marked.
+func plotUserGroups(user *objectUser, groupmap map[string]*objectRbacgroup, membermap map[string]*objectRbacgroupmember) *rbacGraph {
+	g := newRbacGraph("node [shape=box]", "rankdir=LR", "ranksep=1.5")
+	uid := strconv.FormatInt(user.Id, 10)
+	g.node(uid, user.Name, "user", "")
 
 	// seed with user
-	groupsToGo := map[string]struct{}{}
-	for _, m := range membermap {
+	memberIds := sorted(maps.Keys(membermap))
+	var groupsToGo []string
+	for _, mid := range memberIds {
+		m := membermap[mid]
 		if m.MemberUserId != nil && *m.MemberUserId == user.Id {
-			g := groupmap[m.GroupId]
-			fmt.Fprintf(op, "  \"%d\" -> %q;\n", user.Id, g.Id)
-			groupsToGo[g.Id] = struct{}{}
+			g.edge(uid, m.GroupId, "", 0)
+			groupsToGo = append(groupsToGo, m.GroupId)
 		}
 	}
 	// plot transitive memberships
-	groupsDone := map[string]struct{}{}
-	recursivePlotGroups(op, groupsToGo, groupsDone, groupmap, membermap)
-	fmt.Fprintf(op, "}\n")
-}
-
-func recursivePlotGroups(op Output, groupsToGo map[string]struct{}, groupsDone map[string]struct{}, groupmap map[string]*objectRbacgroup, membermap map[string]*objectRbacgroupmember) {
-	newgg := map[string]struct{}{}
-	for g := range groupsToGo {
-		if _, has := groupsDone[g]; has {
+	groupsDone := map[string]bool{}
+	for len(groupsToGo) > 0 {
+		gid := groupsToGo[0]
+		groupsToGo = groupsToGo[1:]
+		if groupsDone[gid] {
 			continue
 		}
-		groupsDone[g] = struct{}{}
-		gobj := groupmap[g]
-		fmt.Fprintf(op, "  %q [label=%q];\n", g, gobj.Name)
-		for _, m := range membermap {
-			if m.MemberGroupId != nil && *m.MemberGroupId == gobj.Id {
-				gg := groupmap[m.GroupId]
-				fmt.Fprintf(op, "  %q -> %q;\n", g, gg.Id)
-				newgg[gg.Id] = struct{}{}
+		groupsDone[gid] = true
+		label := gid + " (missing)"
+		if gobj := groupmap[gid]; gobj != nil {
+			label = gobj.Name
+		}
+		g.node(gid, label, "group", "")
+		for _, mid := range memberIds {
+			if m := membermap[mid]; m.MemberGroupId != nil && *m.MemberGroupId == gid {
+				g.edge(gid, m.GroupId, "", 0)
+				groupsToGo = append(groupsToGo, m.GroupId)
 			}
 		}
-		recursivePlotGroups(op, newgg, groupsDone, groupmap, membermap)
 	}
+	return g
 }
 
 func dotStmtName(s *objectRbacstatement) string {
@@ -235,54 +306,162 @@ func dotStmtName(s *objectRbacstatement) string {
 	return s.Role + " ?"
 }
 
-func plotFullConnectivityDot(op Output, ri *rbacInstanceState) {
-	fmt.Fprintf(op, "digraph {\n")
-	fmt.Fprintf(op, "  newrank=true;\n")
-	fmt.Fprintf(op, "  rankdir=LR;\n")
-	fmt.Fprintf(op, "  ranksep=10;\n")
-	fmt.Fprintf(op, "  subgraph cluster_users {\n")
-	fmt.Fprintf(op, "    label=\"Users\";\n")
-	fmt.Fprintf(op, "    color=blue;\n")
-	fmt.Fprintf(op, "    node [shape=box fixedsize=true width=3 height=1];\n")
-	for uid, uobj := range ri.users {
-		fmt.Fprintf(op, "    u_%d [label=%q];\n", uid, uobj.Name)
-	}
-	fmt.Fprintf(op, "  }\n")
-	fmt.Fprintf(op, "  subgraph cluster_groups {\n")
-	fmt.Fprintf(op, "    label=\"Groups\";\n")
-	fmt.Fprintf(op, "    color=green;\n")
-	fmt.Fprintf(op, "    node [shape=house fixedsize=true width=3 height=2];\n")
-	for gid, gobj := range ri.groups {
-		fmt.Fprintf(op, "    %q [label=%q];\n", gid, gobj.Name)
-	}
-	fmt.Fprintf(op, "  }\n")
-	fmt.Fprintf(op, "  All [shape=doublecircle width=2 height=2 fixedsize=true];\n")
-	fmt.Fprintf(op, "  subgraph cluster_statements {\n")
-	fmt.Fprintf(op, "    label=\"Statements\";\n")
-	fmt.Fprintf(op, "    color=red;\n")
-	fmt.Fprintf(op, "    node [shape=oval fixedsize=true width=2 height=1];\n")
-	for sid, sobj := range ri.statements {
-		fmt.Fprintf(op, "    %q [label=%q];\n", sid, dotStmtName(sobj))
-	}
-	fmt.Fprintf(op, "  }\n")
-	for _, gmobj := range ri.groupmembers {
+func plotFullConnectivity(ri *rbacInstanceState) *rbacGraph {
+	g := newRbacGraph("newrank=true", "rankdir=LR", "ranksep=10")
+	g.cluster("users", "Users", "blue", "shape=box fixedsize=true width=3 height=1")
+	g.cluster("groups", "Groups", "green", "shape=house fixedsize=true width=3 height=2")
+	g.cluster("statements", "Statements", "red", "shape=oval fixedsize=true width=2 height=1")
+	for _, uid := range sorted(maps.Keys(ri.users)) {
+		g.node(fmt.Sprintf("u_%d", uid), ri.users[uid].Name, "user", "users")
+	}
+	for _, gid := range sorted(maps.Keys(ri.groups)) {
+		g.node(gid, ri.groups[gid].Name, "group", "groups")
+	}
+	g.node("All", "All", "all", "")
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		g.node(sid, dotStmtName(ri.statements[sid]), "statement", "statements")
+	}
+	memberIds := sorted(maps.Keys(ri.groupmembers))
+	for _, mid := range memberIds {
+		gmobj := ri.groupmembers[mid]
 		if gmobj.MemberUserId != nil && ri.users[*gmobj.MemberUserId] != nil {
-			fmt.Fprintf(op, "  u_%d -> %q [weight=1];\n", *gmobj.MemberUserId, gmobj.GroupId)
+			g.edge(fmt.Sprintf("u_%d", *gmobj.MemberUserId), gmobj.GroupId, "", 1)
 		}
 	}
-	for _, gmobj := range ri.groupmembers {
+	for _, mid := range memberIds {
+		gmobj := ri.groupmembers[mid]
 		if gmobj.MemberGroupId != nil {
-			fmt.Fprintf(op, "  %q -> %q [weight=2];\n", *gmobj.MemberGroupId, gmobj.GroupId)
+			g.edge(*gmobj.MemberGroupId, gmobj.GroupId, "", 2)
 		}
 	}
-	for _, sobj := range ri.statements {
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		sobj := ri.statements[sid]
 		if sobj.SubjectUser != nil {
-			fmt.Fprintf(op, "  %q -> u_%d [weight=3];\n", sobj.Id, *sobj.SubjectUser)
+			g.edge(sobj.Id, fmt.Sprintf("u_%d", *sobj.SubjectUser), "", 3)
 		} else if sobj.SubjectGroup != nil {
-			fmt.Fprintf(op, "  %q -> %q [weight=2];\n", sobj.Id, *sobj.SubjectGroup)
+			g.edge(sobj.Id, *sobj.SubjectGroup, "", 2)
 		} else {
-			fmt.Fprintf(op, "  %q -> All [weight=1];\n", sobj.Id)
+			g.edge(sobj.Id, "All", "", 1)
+		}
+	}
+	return g
+}
+
+// The focused plots below flow from users, through groups, to the
+// statements that grant them roles.
+
+func (ri *rbacInstanceState) plotUser(g *rbacGraph, uid int64) string {
+	id := fmt.Sprintf("u_%d", uid)
+	label := strconv.FormatInt(uid, 10)
+	if u, has := ri.users[uid]; has {
+		label = u.Name
+	}
+	g.node(id, label, "user", "")
+	return id
+}
+
+// plotMembers adds the users and groups that are, transitively, in the
+// group.
+func (ri *rbacInstanceState) plotMembers(g *rbacGraph, gid string, done map[string]bool) {
+	if done[gid] {
+		return
+	}
+	done[gid] = true
+	g.node(gid, ri.groupName(gid), "group", "")
+	for _, mid := range sorted(maps.Keys(ri.groupmembers)) {
+		m := ri.groupmembers[mid]
+		if m.GroupId != gid {
+			continue
+		}
+		if m.MemberUserId != nil {
+			g.edge(ri.plotUser(g, *m.MemberUserId), gid, "", 0)
+		} else if m.MemberGroupId != nil {
+			ri.plotMembers(g, *m.MemberGroupId, done)
+			g.edge(*m.MemberGroupId, gid, "", 0)
+		}
+	}
+}
+
+// plotSubject adds the statement, and who it grants to.
+func (ri *rbacInstanceState) plotSubject(g *rbacGraph, s *objectRbacstatement, done map[string]bool) {
+	g.node(s.Id, dotStmtName(s), "statement", "")
+	switch {
+	case s.SubjectUser != nil:
+		g.edge(ri.plotUser(g, *s.SubjectUser), s.Id, "", 0)
+	case s.SubjectGroup != nil:
+		ri.plotMembers(g, *s.SubjectGroup, done)
+		g.edge(*s.SubjectGroup, s.Id, "", 0)
+	default:
+		g.node("All", "All", "all", "")
+		g.edge("All", s.Id, "", 0)
+	}
+}
+
+func plotGroup(ri *rbacInstanceState, gid string) *rbacGraph {
+	g := newRbacGraph("node [shape=box]", "rankdir=LR", "ranksep=1.5")
+	ri.plotMembers(g, gid, map[string]bool{})
+	// the groups it's in, and so the grants it gets through them
+	grantees := map[string]bool{gid: true}
+	todo := []string{gid}
+	memberIds := sorted(maps.Keys(ri.groupmembers))
+	for len(todo) > 0 {
+		cur := todo[0]
+		todo = todo[1:]
+		for _, mid := range memberIds {
+			m := ri.groupmembers[mid]
+			if m.MemberGroupId != nil && *m.MemberGroupId == cur {
+				g.node(m.GroupId, ri.groupName(m.GroupId), "group", "")
+				g.edge(cur, m.GroupId, "", 0)
+				if !grantees[m.GroupId] {
+					grantees[m.GroupId] = true
+					todo = append(todo, m.GroupId)
+				}
+			}
+		}
+	}
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		s := ri.statements[sid]
+		if s.SubjectGroup != nil && grantees[*s.SubjectGroup] {
+			g.node(s.Id, dotStmtName(s), "statement", "")
+			g.edge(*s.SubjectGroup, s.Id, "", 0)
+		}
+	}
+	return g
+}
+
+func plotObject(ri *rbacInstanceState, target *rbacTarget) *rbacGraph {
+	g := newRbacGraph("node [shape=box]", "rankdir=LR", "ranksep=1.5")
+	const objId = "object"
+	g.node(objId, target.String(), "object", "")
+	done := map[string]bool{}
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		s := ri.statements[sid]
+		if s.ObjectOwner != nil && *s.ObjectOwner {
+			// only the owner gets access through owner statements
+			if target.ownerId == nil {
+				continue
+			}
+			if s.SubjectAll == nil || !*s.SubjectAll {
+				if _, ok := rbacSubjectChain(s, *target.ownerId, ri.groupPaths(*target.ownerId)); !ok {
+					continue
+				}
+			}
+		} else if !rbacCovers(s, target, 0) {
+			continue
+		}
+		ri.plotSubject(g, s, done)
+		g.edge(s.Id, objId, s.Role, 0)
+	}
+	return g
+}
+
+func plotRole(ri *rbacInstanceState, role string) *rbacGraph {
+	g := newRbacGraph("node [shape=box]", "rankdir=LR", "ranksep=1.5")
+	done := map[string]bool{}
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		if s := ri.statements[sid]; strings.EqualFold(s.Role, role) {
+			ri.plotSubject(g, s, done)
 		}
 	}
-	fmt.Fprintf(op, "}\n")
+	return g
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -62,3 +63,130 @@ func TestCmdRbacDot(t *testing.T) {
 		t.Error("unexpected data output:", diff)
 	}
 }
+
+func startRbacStateFixture(t *testing.T, before ...testRequest) *testFixture {
+	return startFixture(t, append(before,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, testRbacStatements},
+	)...)
+}
+
+func TestCmdRbacDotGroup(t *testing.T) {
+	fix := startRbacStateFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac-dot", "--group", "reader"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `digraph {
+  node [shape=box];
+  rankdir=LR;
+  ranksep=1.5;
+  "o::101:rbacgroup:8000001001" [label="reader"];
+  "u_3" [label="Jane"];
+  "u_3" -> "o::101:rbacgroup:8000001001";
+  "o::101:rbacgroup:8000001002" [label="writer"];
+  "o::101:rbacgroup:8000001001" -> "o::101:rbacgroup:8000001002";
+  "o::101:rbacstatement:8000002002" [label="Editor wks 4100" shape=oval];
+  "o::101:rbacgroup:8000001002" -> "o::101:rbacstatement:8000002002";
+}
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacDotObjectMermaid(t *testing.T) {
+	fix := startRbacStateFixture(t, testRequest{"/v1/meta", 200, testRbacDataset})
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac-dot", "--object", "dataset:41007104", "--format", "mermaid"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `flowchart LR
+  n0[("dataset 41007104 (logs)")]
+  n1(["Lister All"])
+  n2(("All"))
+  n2 --> n1
+  n1 -->|Lister| n0
+  n3(["Editor wks 4100"])
+  n4{{"writer"}}
+  n5{{"reader"}}
+  n6["Jane"]
+  n6 --> n5
+  n5 --> n4
+  n4 --> n3
+  n3 -->|Editor| n0
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacDotRoleJSONGraph(t *testing.T) {
+	fix := startRbacStateFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac-dot", "--role", "manager", "--format", "json-graph"}, fix.hc)
+	fix.Assert()
+	var doc struct {
+		Graph struct {
+			Directed bool
+			Nodes    map[string]jsonGraphNode
+			Edges    []jsonGraphEdge
+		}
+	}
+	if err := json.Unmarshal(fix.op.OutputBuf.Bytes(), &doc); err != nil {
+		t.Fatal("bad JSON:", err, fix.op.OutputBuf.String())
+	}
+	if diff := cmp.Diff(doc.Graph.Nodes, map[string]jsonGraphNode{
+		"o::101:rbacstatement:8000002003": {"Manager obj 999", map[string]string{"kind": "statement"}},
+		"u_5":                             {"Joe", map[string]string{"kind": "user"}},
+	}); diff != "" {
+		t.Error("unexpected nodes:", diff)
+	}
+	if diff := cmp.Diff(doc.Graph.Edges, []jsonGraphEdge{{"u_5", "o::101:rbacstatement:8000002003", ""}}); diff != "" {
+		t.Error("unexpected edges:", diff)
+	}
+}
+
+func TestCmdRbacDotUsage(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac-dot", "--all", "--format", "png"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacDotFormat.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac-dot", "--group", "reader", "--role", "viewer"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), "group and role") {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestPlotUserGroupsStable(t *testing.T) {
+	a, b, c, gone := "o::101:rbacgroup:1", "o::101:rbacgroup:2", "o::101:rbacgroup:3", "o::101:rbacgroup:9"
+	uid := int64(7)
+	groups := map[string]*objectRbacgroup{
+		a: {Id: a, Name: "a"},
+		b: {Id: b, Name: "b"},
+		c: {Id: c, Name: "c"},
+	}
+	members := map[string]*objectRbacgroupmember{
+		"m1": {Id: "m1", GroupId: a, MemberUserId: &uid},
+		"m2": {Id: "m2", GroupId: b, MemberUserId: &uid},
+		"m3": {Id: "m3", GroupId: c, MemberGroupId: &a},
+		"m4": {Id: "m4", GroupId: c, MemberGroupId: &b},
+		"m5": {Id: "m5", GroupId: gone, MemberUserId: &uid},
+	}
+	render := func() string {
+		var sb strings.Builder
+		if err := renderRbacMermaid(&sb, plotUserGroups(&objectUser{Id: uid, Name: "Jane"}, groups, members)); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+	first := render()
+	for i := 0; i != 20; i++ {
+		if diff := cmp.Diff(first, render()); diff != "" {
+			t.Fatal("output changed between runs:", diff)
+		}
+	}
+	if !strings.Contains(first, gone+" (missing)") {
+		t.Error("expected the missing group to be marked:", first)
+	}
+}
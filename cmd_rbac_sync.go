@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// `rbac sync` reconciles groups with a CSV export from an identity provider.
+// Only the groups named in the file, and only their user members, are
+// touched; memberships of groups in groups are left alone. Group names are
+// matched ignoring case, as identity providers and people disagree on it.
+
+var (
+	ErrRbacSyncUsage     = ObserveError{Msg: "usage: observe rbac sync --from <file.csv> [--dry-run | --apply] [--prune]"}
+	ErrRbacSyncCSV       = ObserveError{Msg: "the CSV file needs a header with group and email columns"}
+	ErrRbacSyncAmbiguous = ObserveError{Msg: "more than one group has the name"}
+	ErrRbacSyncFailed    = ObserveError{Msg: "some changes failed"}
+)
+
+type rbacSyncChange struct {
+	group    string // name
+	email    string
+	userId   int64
+	memberId string // for removals
+}
+
+type rbacSyncPlan struct {
+	// the IDs of the existing groups, by the name the file gives them
+	groupIds     map[string]string
+	createGroups []string
+	adds         []rbacSyncChange
+	removes      []rbacSyncChange
+}
+
+// readRbacSyncCSV returns the emails, lowercased, listed for each group. A
+// row with a group but no email declares a group that should exist. Group
+// names that differ only in case are the same group, named as first given.
+func readRbacSyncCSV(data []byte) (map[string]map[string]bool, error) {
+	rd := csv.NewReader(bytes.NewReader(data))
+	rd.TrimLeadingSpace = true
+	rows, err := rd.ReadAll()
+	if err != nil {
+		return nil, NewObserveError(err, "read CSV")
+	}
+	gcol, ecol := -1, -1
+	if len(rows) > 0 {
+		for i, h := range rows[0] {
+			switch strings.ToLower(strings.TrimSpace(h)) {
+			case "group":
+				gcol = i
+			case "email":
+				ecol = i
+			}
+		}
+	}
+	if gcol < 0 || ecol < 0 {
+		return nil, ErrRbacSyncCSV
+	}
+	ret := map[string]map[string]bool{}
+	spelling := map[string]string{}
+	for i, row := range rows[1:] {
+		group := strings.TrimSpace(row[gcol])
+		if group == "" {
+			return nil, NewObserveError(ErrRbacSyncCSV, "line %d has no group", i+2)
+		}
+		if first, has := spelling[strings.ToLower(group)]; has {
+			group = first
+		}
+		spelling[strings.ToLower(group)] = group
+		if ret[group] == nil {
+			ret[group] = map[string]bool{}
+		}
+		if email := strings.ToLower(strings.TrimSpace(row[ecol])); email != "" {
+			ret[group][email] = true
+		}
+	}
+	return ret, nil
+}
+
+// findSyncGroup finds the group with the name, preferring an exact match to
+// one that differs in case. Two groups that match equally well are an error,
+// rather than one of them being picked.
+func (ri *rbacInstanceState) findSyncGroup(name string) (string, bool, error) {
+	var exact, folded []*ObjectInfo
+	for _, gid := range sorted(maps.Keys(ri.groups)) {
+		g := ri.groups[gid]
+		switch {
+		case g.Name == name:
+			exact = append(exact, g.GetInfo())
+		case strings.EqualFold(g.Name, name):
+			folded = append(folded, g.GetInfo())
+		}
+	}
+	for _, infos := range [][]*ObjectInfo{exact, folded} {
+		switch len(infos) {
+		case 0:
+		case 1:
+			return infos[0].Id, true, nil
+		default:
+			return "", false, NewObserveError(ErrRbacSyncAmbiguous, "%q could be %s", name, quotedNames(infos))
+		}
+	}
+	return "", false, nil
+}
+
+func (ri *rbacInstanceState) planSync(op Output, desired map[string]map[string]bool) (*rbacSyncPlan, error) {
+	byEmail := map[string]*objectUser{}
+	for _, u := range ri.users {
+		byEmail[strings.ToLower(u.Email)] = u
+	}
+	plan := &rbacSyncPlan{groupIds: map[string]string{}}
+	for _, name := range sorted(maps.Keys(desired)) {
+		gid, exists, err := ri.findSyncGroup(name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			plan.groupIds[name] = gid
+		} else {
+			plan.createGroups = append(plan.createGroups, name)
+		}
+		current := map[int64]string{}
+		if exists {
+			for mid, m := range ri.groupmembers {
+				if m.GroupId == gid && m.MemberUserId != nil {
+					current[*m.MemberUserId] = mid
+				}
+			}
+		}
+		want := map[int64]bool{}
+		for _, email := range sorted(maps.Keys(desired[name])) {
+			u := byEmail[email]
+			if u == nil {
+				op.Info("no user has email %q; skipping it for group %q\n", email, name)
+				continue
+			}
+			want[u.Id] = true
+			if _, has := current[u.Id]; !has {
+				plan.adds = append(plan.adds, rbacSyncChange{group: name, email: u.Email, userId: u.Id})
+			}
+		}
+		for _, uid := range sorted(maps.Keys(current)) {
+			if !want[uid] {
+				email := strconv.FormatInt(uid, 10)
+				if u, has := ri.users[uid]; has {
+					email = u.Email
+				}
+				plan.removes = append(plan.removes, rbacSyncChange{group: name, email: email, userId: uid, memberId: current[uid]})
+			}
+		}
+	}
+	return plan, nil
+}
+
+func cmdRbacSync(fa FuncArgs) error {
+	if len(fa.args) != 1 || flagRbacSyncFrom == "" || (flagRbacSyncApply && flagRbacSyncDryRun) {
+		return ErrRbacSyncUsage
+	}
+	data, err := fa.fs.ReadFile(flagRbacSyncFrom)
+	if err != nil {
+		return NewObserveError(err, "read %s", flagRbacSyncFrom)
+	}
+	desired, err := readRbacSyncCSV(data)
+	if err != nil {
+		return err
+	}
+	// don't trust cached listings when changing things
+	fa.cfg.CacheRefresh = true
+	ri := &rbacInstanceState{}
+	if err := ri.fillUsers(fa); err != nil {
+		return err
+	}
+	if err := ri.fillGroups(fa); err != nil {
+		return err
+	}
+	if err := ri.fillGroupMembers(fa); err != nil {
+		return err
+	}
+	plan, err := ri.planSync(fa.op, desired)
+	if err != nil {
+		return err
+	}
+	if !flagRbacSyncPrune && len(plan.removes) > 0 {
+		fa.op.Info("keeping %d members that aren't in the file; use --prune to remove them\n", len(plan.removes))
+		plan.removes = nil
+	}
+
+	for _, name := range plan.createGroups {
+		fmt.Fprintf(fa.op, "create group %q\n", name)
+	}
+	for _, c := range plan.adds {
+		fmt.Fprintf(fa.op, "add %s to group %q\n", c.email, c.group)
+	}
+	for _, c := range plan.removes {
+		fmt.Fprintf(fa.op, "remove %s from group %q\n", c.email, c.group)
+	}
+	nchanges := len(plan.createGroups) + len(plan.adds) + len(plan.removes)
+	if nchanges == 0 {
+		fa.op.Info("nothing to do\n")
+		return nil
+	}
+	if !flagRbacSyncApply {
+		fa.op.Info("dry run; use --apply to make these %d changes\n", nchanges)
+		return nil
+	}
+
+	// keep going past a failure, so that one bad change doesn't stop the
+	// others, and say what was and wasn't done
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	failed := 0
+	fail := func(err error) {
+		failed++
+		fa.op.Error("%s\n", err)
+	}
+	for _, name := range plan.createGroups {
+		obj, err := ObjectTypeRbacgroup.Create(fa.cfg, fa.op, fa.hc, object{"name": name})
+		if err != nil {
+			fail(NewObserveError(err, "create group %q", name))
+			continue
+		}
+		plan.groupIds[name] = obj.GetInfo().Id
+	}
+	for _, c := range plan.adds {
+		gid, has := plan.groupIds[c.group]
+		if !has {
+			fail(NewObserveError(nil, "add %s to group %q: the group wasn't created", c.email, c.group))
+			continue
+		}
+		if _, err := ObjectTypeRbacgroupmember.Create(fa.cfg, fa.op, fa.hc, object{"groupid": gid, "memberuserid": c.userId}); err != nil {
+			fail(NewObserveError(err, "add %s to group %q", c.email, c.group))
+		}
+	}
+	for _, c := range plan.removes {
+		if err := ObjectTypeRbacgroupmember.Delete(fa.cfg, fa.op, fa.hc, c.memberId); err != nil {
+			fail(NewObserveError(err, "remove %s from group %q", c.email, c.group))
+		}
+	}
+	if failed > 0 {
+		return NewObserveError(ErrRbacSyncFailed, "made %d of %d changes; %d failed", nchanges-failed, nchanges, failed)
+	}
+	fa.op.Info("made %d changes\n", nchanges)
+	return nil
+}
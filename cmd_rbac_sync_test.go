@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdRbacSyncDryRun(t *testing.T) {
+	// a dry run is the default
+	for _, args := range [][]string{
+		{"rbac", "sync", "--from", "groups.csv"},
+		{"rbac", "sync", "--from", "groups.csv", "--dry-run"},
+	} {
+		fix := startFixture(t,
+			testRequest{"/v1/meta", 200, testRbacUsers},
+			testRequest{"/v1/meta", 200, testRbacGroups},
+			testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		)
+		fix.fs.WriteFile("groups.csv", []byte("Group,Email\nreader,Jane@example.com\nsre,joe@example.com\nsre,nobody@example.com\n"), 0644)
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, args, fix.hc)
+		fix.Assert()
+		if diff := cmp.Diff(fix.op.OutputBuf.String(), "create group \"sre\"\nadd joe@example.com to group \"sre\"\n"); diff != "" {
+			t.Error(args, "unexpected output:", diff)
+		}
+		info := fix.op.InfoBuf.String()
+		if !strings.Contains(info, `no user has email "nobody@example.com"`) || !strings.Contains(info, "dry run; use --apply to make these 2 changes") {
+			t.Error(args, "unexpected info output:", info)
+		}
+	}
+}
+
+func TestCmdRbacSyncApplyPrune(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, `{"data":{"createRbacGroupmember":{"id":"o::101:rbacgroupmember:8000001020","description":"","groupid":"o::101:rbacgroup:8000001001","membergroupid":null,"memberuserid":"5"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"deleteRbacGroupmember":{"success":true,"errorMessage":""}}}`},
+	)
+	fix.fs.WriteFile("groups.csv", []byte("group,email\nreader,joe@example.com\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "sync", "--from", "groups.csv", "--apply", "--prune"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "add joe@example.com to group \"reader\"\nremove jane@example.com from group \"reader\"\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "made 2 changes") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+}
+
+func TestCmdRbacSyncNoPrune(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+	)
+	fix.fs.WriteFile("groups.csv", []byte("group,email\nreader,\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "sync", "--from", "groups.csv", "--apply"}, fix.hc)
+	fix.Assert()
+	if fix.op.OutputBuf.String() != "" {
+		t.Error("unexpected output:", fix.op.OutputBuf.String())
+	}
+	info := fix.op.InfoBuf.String()
+	if !strings.Contains(info, "keeping 1 members that aren't in the file") || !strings.Contains(info, "nothing to do") {
+		t.Error("unexpected info output:", info)
+	}
+}
+
+func TestCmdRbacSyncFoldCase(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+	)
+	fix.fs.WriteFile("groups.csv", []byte("group,email\nREADER,joe@example.com\nReader,jane@example.com\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "sync", "--from", "groups.csv"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "add joe@example.com to group \"READER\"\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacSyncAmbiguous(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, `{"data":{"rbacGroups":[
+			{"id":"o::101:rbacgroup:8000001001","name":"sre","description":""},
+			{"id":"o::101:rbacgroup:8000001002","name":"SRE","description":""}
+		]}}`},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+	)
+	fix.fs.WriteFile("groups.csv", []byte("group,email\nSre,joe@example.com\n"), 0644)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "sync", "--from", "groups.csv"}, fix.hc)
+	})
+	fix.Assert()
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacSyncAmbiguous.Msg) || !strings.Contains(fix.op.ErrorBuf.String(), `"SRE" (o::101:rbacgroup:8000001002)`) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdRbacSyncApplyFailure(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 500, `{"message":"internal error"}`},
+		testRequest{"/v1/meta", 200, `{"data":{"createRbacGroupmember":{"id":"o::101:rbacgroupmember:8000001020","description":"","groupid":"o::101:rbacgroup:8000001001","membergroupid":null,"memberuserid":"5"}}}`},
+	)
+	fix.fs.WriteFile("groups.csv", []byte("group,email\nreader,joe@example.com\nsre,jane@example.com\n"), 0644)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "sync", "--from", "groups.csv", "--apply"}, fix.hc)
+	})
+	fix.Assert()
+	errs := fix.op.ErrorBuf.String()
+	for _, want := range []string{`create group "sre"`, `add jane@example.com to group "sre": the group wasn't created`, "made 1 of 3 changes; 2 failed"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("expected %q in error output: %s", want, errs)
+		}
+	}
+}
+
+func TestCmdRbacSyncDryRunAndApply(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "sync", "--from", "groups.csv", "--dry-run", "--apply"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacSyncUsage.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestReadRbacSyncCSV(t *testing.T) {
+	if _, err := readRbacSyncCSV([]byte("team,mail\nsre,a@b.c\n")); err != ErrRbacSyncCSV {
+		t.Error("expected a header error, got", err)
+	}
+	got, err := readRbacSyncCSV([]byte("email,group,name\nA@B.C,sre,A\n,empty,\nd@e.f,SRE,D\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, map[string]map[string]bool{"sre": {"a@b.c": true, "d@e.f": true}, "empty": {}}); diff != "" {
+		t.Error("unexpected groups:", diff)
+	}
+}
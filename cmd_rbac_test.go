@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testRbacGroups = `{"data":{"rbacGroups":[
+	{"id":"o::101:rbacgroup:8000001001","name":"reader","description":"for reading"},
+	{"id":"o::101:rbacgroup:8000001002","name":"writer","description":"for writing"}
+]}}`
+
+const testRbacGroupmembers = `{"data":{"rbacGroupmembers":[
+	{"id":"o::101:rbacgroupmember:8000001005","description":"","groupid":"o::101:rbacgroup:8000001001","membergroupid":null,"memberuserid":"3"},
+	{"id":"o::101:rbacgroupmember:8000001006","description":"","groupid":"o::101:rbacgroup:8000001002","membergroupid":"o::101:rbacgroup:8000001001","memberuserid":null}
+]}}`
+
+func TestCmdRbacGroupAddMember(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, `{"data":{"createRbacGroupmember":{"id":"o::101:rbacgroupmember:8000001007","description":"","groupid":"o::101:rbacgroup:8000001002","membergroupid":null,"memberuserid":"3"}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "group", "add-member", "Writer", "--user", "3"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "added user \"3\" (3) to group \"writer\" (o::101:rbacgroupmember:8000001007)\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacGroupAddMemberExisting(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "group", "add-member", "writer", "--group", "reader"}, fix.hc)
+	fix.Assert()
+	if !strings.Contains(fix.op.InfoBuf.String(), `group "reader" (o::101:rbacgroup:8000001001) is already a member of group "writer"`) {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+	if fix.op.OutputBuf.String() != "" {
+		t.Error("unexpected output:", fix.op.OutputBuf.String())
+	}
+}
+
+func TestCmdRbacGroupRemoveMember(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, testRbacGroupmembers},
+		testRequest{"/v1/meta", 200, `{"data":{"deleteRbacGroupmember":{"success":true,"errorMessage":""}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "group", "remove-member", "reader", "--user", "3"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "removed user \"3\" (3) from group \"reader\"\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacUsage(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacGroups},
+	)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "group"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), "usage: observe rbac group <add-member|remove-member>") {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "group", "add-member", "reader"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacOneMember.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdRbacGrant(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacGroups},
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"4100","name":"Default"},{"id":"4200","name":"Staging"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"createRbacStatement":{"id":"o::101:rbacstatement:8000002001","description":"Editor for group writer on workspace Default","subject":{"userId":null,"groupId":"o::101:rbacgroup:8000001002","all":null},"object":{"objectId":null,"folderId":null,"workspaceId":"4100","type":null,"name":null,"owner":null,"all":null},"role":"Editor"}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "grant", "--role", "editor", "--group", "writer", "--workspace", "4100"}, fix.hc)
+	fix.Assert()
+	out := fix.op.OutputBuf.String()
+	for _, want := range []string{
+		`id: "o::101:rbacstatement:8000002001"`,
+		`subjectgroupid: "o::101:rbacgroup:8000001002"`,
+		`objectworkspaceid: 4100`,
+		`role: "Editor"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCmdRbacGrantValidation(t *testing.T) {
+	fix := startFixture(t)
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"rbac", "grant", "--role", "owner", "--all-users", "--all-objects"}, "unknown role"},
+		{[]string{"rbac", "grant", "--role", "viewer", "--all-objects"}, ErrRbacGrantOneSubject.Msg},
+		{[]string{"rbac", "grant", "--role", "viewer", "--all-users", "--user", "3", "--all-objects"}, ErrRbacGrantOneSubject.Msg},
+		{[]string{"rbac", "grant", "--role", "viewer", "--all-users"}, ErrRbacGrantOneObject.Msg},
+		{[]string{"rbac", "grant", "--role", "viewer", "--all-users", "--owner", "--folder", "41"}, ErrRbacGrantOneObject.Msg},
+		{[]string{"rbac", "grant", "--role", "viewer", "--all-users", "--all-objects", "--name", "x"}, ErrRbacGrantNameType.Msg},
+		{[]string{"rbac", "revoke"}, ErrRbacRevokeUsage.Msg},
+	} {
+		mustPanic(t, func() {
+			RunCommandWithConfig(fix.cfg, fix.fs, fix.op, tc.args, fix.hc)
+		})
+		if !strings.Contains(fix.op.ErrorBuf.String(), tc.err) {
+			t.Errorf("%v: unexpected error output: %s", tc.args, fix.op.ErrorBuf.String())
+		}
+		fix.op.ErrorBuf.Reset()
+	}
+	fix.Assert()
+}
+
+func TestCmdRbacRevoke(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"deleteRbacStatement":{"success":true,"errorMessage":""}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "revoke", "o::101:rbacstatement:8000002001"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "revoked o::101:rbacstatement:8000002001\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
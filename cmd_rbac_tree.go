@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/maps"
+)
+
+// `rbac tree` is rbac-dot for people without GraphViz: the groups a user or
+// group is in, expanded level by level, with the statements granted at each.
+
+var (
+	ErrRbacTreeUsage = ObserveError{Msg: "usage: observe rbac tree --user <user> | --group <group> [--ascii]"}
+	ErrRbacTreeOne   = ObserveError{Msg: "exactly one of --user and --group is required"}
+)
+
+func treeStatementLabel(s *objectRbacstatement) string {
+	return fmt.Sprintf("%s on %s: %q (%s)", s.Role, rbacScope(s), s.Description, s.Id)
+}
+
+// treeGroup expands the groups that gid is in. A group that is already on
+// the path from the root is a cycle, and is marked rather than expanded.
+func (ri *rbacInstanceState) treeGroup(parent *treeNode, gid string, path map[string]bool) {
+	n := parent.add(fmt.Sprintf("group %q (%s)", ri.groupName(gid), gid))
+	if path[gid] {
+		n.label += " (cycle)"
+		return
+	}
+	path[gid] = true
+	defer delete(path, gid)
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		if s := ri.statements[sid]; s.SubjectGroup != nil && *s.SubjectGroup == gid {
+			n.add(treeStatementLabel(s))
+		}
+	}
+	for _, mid := range sorted(maps.Keys(ri.groupmembers)) {
+		if m := ri.groupmembers[mid]; m.MemberGroupId != nil && *m.MemberGroupId == gid {
+			ri.treeGroup(n, m.GroupId, path)
+		}
+	}
+}
+
+func (ri *rbacInstanceState) treeUser(u *objectUser) *treeNode {
+	root := &treeNode{label: fmt.Sprintf("user %q (%d)", u.Name, u.Id)}
+	for _, sid := range sorted(maps.Keys(ri.statements)) {
+		s := ri.statements[sid]
+		switch {
+		case s.SubjectUser != nil && *s.SubjectUser == u.Id:
+			root.add(treeStatementLabel(s))
+		case s.SubjectAll != nil && *s.SubjectAll:
+			root.add(treeStatementLabel(s) + ", for all users")
+		}
+	}
+	for _, mid := range sorted(maps.Keys(ri.groupmembers)) {
+		if m := ri.groupmembers[mid]; m.MemberUserId != nil && *m.MemberUserId == u.Id {
+			ri.treeGroup(root, m.GroupId, map[string]bool{})
+		}
+	}
+	return root
+}
+
+func cmdRbacTree(fa FuncArgs) error {
+	if len(fa.args) != 1 {
+		return ErrRbacTreeUsage
+	}
+	if (flagRbacTreeUser == "") == (flagRbacTreeGroup == "") {
+		return ErrRbacTreeOne
+	}
+	ri := &rbacInstanceState{}
+	if err := ri.fillAll(fa); err != nil {
+		return err
+	}
+	var root *treeNode
+	if flagRbacTreeUser != "" {
+		u, err := ri.findUser(flagRbacTreeUser)
+		if err != nil {
+			return err
+		}
+		root = ri.treeUser(u)
+	} else {
+		gid, err := ri.findGroup(flagRbacTreeGroup)
+		if err != nil {
+			return err
+		}
+		// treeGroup adds a child, so the group itself is the only child of
+		// an empty root
+		top := &treeNode{}
+		ri.treeGroup(top, gid, map[string]bool{})
+		root = top.children[0]
+	}
+	glyphs := treeUnicode
+	if flagRbacTreeASCII {
+		glyphs = treeASCII
+	}
+	return writeTree(fa.op, root, glyphs)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdRbacTreeUser(t *testing.T) {
+	fix := startRbacStateFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "tree", "--user", "Jane"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `user "Jane" (3)
+├── Lister on all objects: "everyone lists" (o::101:rbacstatement:8000002001), for all users
+└── group "reader" (o::101:rbacgroup:8000001001)
+    └── group "writer" (o::101:rbacgroup:8000001002)
+        └── Editor on workspace 4100: "writers edit" (o::101:rbacstatement:8000002002)
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacTreeGroupCycle(t *testing.T) {
+	fix := startRbacAuditFixture(t)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "tree", "--group", "reader", "--ascii"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `group "reader" (o::101:rbacgroup:8000001001)
+`+"`"+`-- group "writer" (o::101:rbacgroup:8000001002)
+    |-- Manager on all objects: "writers manage" (o::101:rbacstatement:8000002001)
+    `+"`"+`-- group "reader" (o::101:rbacgroup:8000001001) (cycle)
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdRbacTreeUsage(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"rbac", "tree", "--user", "3", "--group", "reader"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrRbacTreeOne.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
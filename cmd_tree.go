@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	flagsTree     *pflag.FlagSet
+	flagTreeTypes []string
+	flagTreeMatch string
+	flagTreeASCII bool
+)
+
+// treeObjectTypes are the types of object that live in folders, in the order
+// tree prints them.
+var treeObjectTypes = []ObjectType{ObjectTypeDataset, ObjectTypeWorksheet, ObjectTypeDashboard, ObjectTypeMonitor}
+
+func init() {
+	var names []string
+	for _, ot := range treeObjectTypes {
+		names = append(names, ot.TypeName())
+	}
+	flagsTree = pflag.NewFlagSet("tree", pflag.ContinueOnError)
+	flagsTree.StringSliceVar(&flagTreeTypes, "type", nil, "only show objects of these types: "+strings.Join(names, ", "))
+	flagsTree.StringVar(&flagTreeMatch, "match", "", "only show objects whose name or ID contains this, ignoring case")
+	flagsTree.BoolVar(&flagTreeASCII, "ascii", false, "draw the tree with ASCII rather than Unicode")
+	flagsTree.Lookup("ascii").NoOptDefVal = "true"
+	RegisterCommand(&Command{
+		Name:  "tree",
+		Help:  "Show the folders of a workspace, and the objects in each.",
+		Flags: flagsTree,
+		Func:  cmdTree,
+		// the workspace may also be given as the argument
+		WorkspaceScoped: true,
+	})
+}
+
+var (
+	ErrTreeUsage = ObserveError{Msg: "usage: observe tree [<workspace>] [--type <type>,...] [--match <substring>] [--ascii]"}
+	ErrTreeType  = ObserveError{Msg: "--type takes dataset, worksheet, dashboard, or monitor"}
+)
+
+// treePlace returns the workspace and folder of an object that lives in a
+// folder.
+func treePlace(info *ObjectInfo) (int64, int64) {
+	switch o := info.Object.(type) {
+	case *objectDataset:
+		return o.WorkspaceId, o.FolderId
+	case *objectWorksheet:
+		return o.WorkspaceId, o.FolderId
+	case *objectDashboard:
+		return o.WorkspaceId, o.FolderId
+	case *objectMonitor:
+		return o.WorkspaceId, o.FolderId
+	}
+	return 0, 0
+}
+
+func treeMatches(info *ObjectInfo, match string) bool {
+	return match == "" ||
+		strings.Contains(strings.ToLower(info.Id), match) ||
+		strings.Contains(strings.ToLower(info.Name), match)
+}
+
+func cmdTree(fa FuncArgs) error {
+	// the workspace is the argument or --workspace, not both
+	if len(fa.args) > 2 || (len(fa.args) == 2 && flagsTree.Lookup("workspace").Changed) {
+		return ErrTreeUsage
+	}
+	if len(fa.args) == 2 {
+		fa.cfg.WorkspaceIdOrName = fa.args[1]
+	}
+	types := treeObjectTypes
+	if len(flagTreeTypes) > 0 {
+		types = nil
+		for _, name := range flagTreeTypes {
+			i := slices.IndexFunc(treeObjectTypes, func(ot ObjectType) bool { return ot.TypeName() == name })
+			if i < 0 {
+				return NewObserveError(ErrTreeType, "%q", name)
+			}
+			types = append(types, treeObjectTypes[i])
+		}
+	}
+	match := strings.ToLower(flagTreeMatch)
+	filtered := len(flagTreeTypes) > 0 || match != ""
+
+	ws, err := ResolveWorkspace(fa.cfg, fa.fs, fa.op, fa.hc)
+	if err != nil {
+		return err
+	}
+	wsId, _ := strconv.ParseInt(ws.Id, 10, 64)
+	folders, err := ObjectTypeFolder.List(fa.cfg, fa.fs, fa.op, fa.hc)
+	if err != nil {
+		return NewObserveError(err, "list folders")
+	}
+	root := &treeNode{label: fmt.Sprintf("workspace %q (%s)", ws.Name, ws.Id)}
+	nodes := map[int64]*treeNode{}
+	for _, f := range folders {
+		if f.Object.(*objectFolder).WorkspaceId == wsId {
+			id, _ := strconv.ParseInt(f.Id, 10, 64)
+			nodes[id] = &treeNode{label: fmt.Sprintf("folder %q (%s)", f.Name, f.Id)}
+		}
+	}
+	// objects whose folder isn't one we know of still show, under a node of
+	// their own
+	var unfiled *treeNode
+	for _, ot := range types {
+		infos, err := ot.List(fa.cfg, fa.fs, fa.op, fa.hc)
+		if err != nil {
+			return NewObserveError(err, "list %ss", ot.TypeName())
+		}
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+		for _, info := range infos {
+			w, folder := treePlace(info)
+			if w != wsId || !treeMatches(info, match) {
+				continue
+			}
+			n := nodes[folder]
+			if n == nil {
+				if unfiled == nil {
+					unfiled = &treeNode{label: "(no folder)"}
+				}
+				n = unfiled
+			}
+			n.add(fmt.Sprintf("%s %q (%s)", ot.TypeName(), info.Name, info.Id))
+		}
+	}
+	folderIds := maps.Keys(nodes)
+	sort.Slice(folderIds, func(i, j int) bool { return nodes[folderIds[i]].label < nodes[folderIds[j]].label })
+	for _, id := range folderIds {
+		if n := nodes[id]; len(n.children) > 0 || !filtered {
+			root.children = append(root.children, n)
+		}
+	}
+	if unfiled != nil {
+		root.children = append(root.children, unfiled)
+	}
+	glyphs := treeUnicode
+	if flagTreeASCII {
+		glyphs = treeASCII
+	}
+	return writeTree(fa.op, root, glyphs)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testTreeWorkspaces = `{"data":{"currentUser":{"workspaces":[{"id":"41000001","name":"Default"},{"id":"41000002","name":"Staging"}]}}}`
+	testTreeFolders    = `{"data":{"folders":[` +
+		`{"id":"41000300","name":"API","workspaceId":"41000001","description":null},` +
+		`{"id":"41000301","name":"Billing","workspaceId":"41000001","description":null},` +
+		`{"id":"41000302","name":"API","workspaceId":"41000002","description":null}]}}`
+	testTreeDatasets = `{"data":{"datasetSearch":[` +
+		`{"dataset":{"id":"41007104","name":"logs","path":"logs","workspaceId":"41000001","folderId":"41000300"}},` +
+		`{"dataset":{"id":"41007105","name":"invoices","path":"billing/invoices","workspaceId":"41000001","folderId":"41000301"}},` +
+		`{"dataset":{"id":"41007106","name":"scratch","path":"scratch","workspaceId":"41000001","folderId":"0"}},` +
+		`{"dataset":{"id":"42007104","name":"logs","path":"logs","workspaceId":"41000002","folderId":"41000302"}}]}}`
+	testTreeWorksheets = `{"data":{"worksheetSearch":{"worksheets":[{"worksheet":{"id":"41000500","name":"Errors by service","workspaceId":"41000001","folderId":"41000300"}}]}}}`
+	testTreeDashboards = `{"data":{"dashboardSearch":{"dashboards":[{"dashboard":{"id":"41000700","name":"Errors","workspaceId":"41000001","folderId":"41000300"}}]}}}`
+	testTreeMonitors   = `{"data":{"monitors":[{` + testMonitorFields + `,"mutedUntil":null,"muteReason":null}]}}`
+)
+
+func TestCmdTree(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testTreeWorkspaces},
+		testRequest{"/v1/meta", 200, testTreeFolders},
+		testRequest{"/v1/meta", 200, testTreeDatasets},
+		testRequest{"/v1/meta", 200, testTreeWorksheets},
+		testRequest{"/v1/meta", 200, testTreeDashboards},
+		testRequest{"/v1/meta", 200, testTreeMonitors},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"tree", "Default"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `workspace "Default" (41000001)
+├── folder "API" (41000300)
+│   ├── dataset "logs" (41007104)
+│   ├── worksheet "Errors by service" (41000500)
+│   ├── dashboard "Errors" (41000700)
+│   └── monitor "API errors" (41000123)
+├── folder "Billing" (41000301)
+│   └── dataset "invoices" (41007105)
+└── (no folder)
+    └── dataset "scratch" (41007106)
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdTreeFiltered(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testTreeWorkspaces},
+		testRequest{"/v1/meta", 200, testTreeFolders},
+		testRequest{"/v1/meta", 200, testTreeDatasets},
+		testRequest{"/v1/meta", 200, testTreeMonitors},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"tree", "--workspace", "default", "--type", "dataset,monitor", "--match", "LOG", "--ascii"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `workspace "Default" (41000001)
+`+"`"+`-- folder "API" (41000300)
+    `+"`"+`-- dataset "logs" (41007104)
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdTreeBadType(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"tree", "--type", "folder"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrTreeType.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdTreeWorkspaceTwice(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"tree", "Default", "--workspace", "41000001"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrTreeUsage.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
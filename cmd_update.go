@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsUpdate          *pflag.FlagSet
+	flagUpdateDefinition string
+)
+
+func init() {
+	flagsUpdate = pflag.NewFlagSet("update", pflag.ContinueOnError)
+	flagsUpdate.StringVarP(&flagUpdateDefinition, "definition", "d", "", "YAML or JSON file with the properties to change; '-' for stdin")
+	RegisterCommand(&Command{
+		Name:            "update",
+		Help:            "Update an object identified by type and id from a definition file.",
+		Flags:           flagsUpdate,
+		Func:            cmdUpdate,
+		WorkspaceScoped: true,
+	})
+}
+
+var (
+	ErrUpdateUsage  = ObserveError{Msg: "usage: observe update <object type> <object id> --definition <file>"}
+	ErrCannotUpdate = ObserveError{Msg: "cannot update this object type"}
+)
+
+func cmdUpdate(fa FuncArgs) error {
+	if len(fa.args) != 3 {
+		return ErrUpdateUsage
+	}
+	otyp := GetObjectType(fa.args[1])
+	if otyp == nil {
+		return ErrUnknownObjectType
+	}
+	if !otyp.CanUpdate() {
+		return ErrCannotUpdate
+	}
+	in, err := parseInput(fa.fs, fa.op, flagUpdateDefinition)
+	if err != nil {
+		return err
+	}
+	config, err := definitionConfig(otyp, in)
+	if err != nil {
+		return err
+	}
+	obj, err := otyp.Update(fa.cfg, fa.op, fa.hc, fa.args[2], config)
+	if err != nil {
+		return NewObserveError(err, "update %s", otyp.TypeName())
+	}
+	invalidateCache(fa.cfg, fa.fs, fa.op)
+	return obj.PrintToYaml(fa.op, otyp, obj)
+}
@@ -14,17 +14,25 @@ var (
 	flagsUpload          *pflag.FlagSet
 	flagUploadAsFilename string
 	flagUploadDocumentId string
+	flagUploadPrimaryKey []string
+	flagUploadSync       string
+	flagUploadPrune      bool
 )
 
-var ErrUploadUsage = ObserveError{Msg: "usage: observe upload <document usage> <document name>"}
+var ErrUploadUsage = ObserveError{Msg: "usage: observe upload <document usage> <document name> [--primary-key <column>,...] | --sync <directory> [--prune]"}
 var ErrUnsupportedType = ObserveError{Msg: "the document usage kind is not supported"}
 var ErrNotTextFile = ObserveError{Msg: "the document is not a text file"}
 var ErrFileNotReadable = ObserveError{Msg: "the file is not readable"}
+var ErrUploadPrimaryKey = ObserveError{Msg: "--primary-key is only for reference tables"}
 
 func init() {
 	flagsUpload = pflag.NewFlagSet("upload", pflag.ContinueOnError)
 	flagsUpload.StringVarP(&flagUploadAsFilename, "as-filename", "f", "", "use this as uploaded name instead of the source file path")
 	flagsUpload.StringVarP(&flagUploadDocumentId, "document-id", "d", "", "replace this particular document id, rather than matching on name")
+	flagsUpload.StringSliceVar(&flagUploadPrimaryKey, "primary-key", nil, "for a reference table, the columns that identify each row")
+	flagsUpload.StringVar(&flagUploadSync, "sync", "", "upload the new and changed files in this directory, rather than one file")
+	flagsUpload.BoolVar(&flagUploadPrune, "prune", false, "with --sync, delete documents whose files are gone")
+	flagsUpload.Lookup("prune").NoOptDefVal = "true"
 	RegisterCommand(&Command{
 		Name:  "upload",
 		Help:  "Put (or overwrite) a document of some sort, identified by filename or id.",
@@ -35,38 +43,49 @@ func init() {
 
 // 'upload' and 'document' are somewhat intertwined
 func cmdUpload(fa FuncArgs) error {
-	if len(fa.args) != 3 {
+	if len(fa.args) < 2 {
 		return ErrUploadUsage
 	}
 	kind, has := docTypes[fa.args[1]]
 	if !has {
 		return ObserveError{Msg: "supported document usage kinds are: " + strings.Join(sorted(maps.Keys(docTypes)), ", ")}.WithInner(ErrUnsupportedType)
 	}
+	if flagUploadSync != "" {
+		return cmdUploadSync(fa, kind)
+	}
+	if flagUploadPrune {
+		return ErrUploadPruneNoSync
+	}
+	if len(fa.args) != 3 {
+		return ErrUploadUsage
+	}
+	uploader, isUploader := kind.(documentUploader)
+	if len(flagUploadPrimaryKey) > 0 && !isUploader {
+		return ErrUploadPrimaryKey
+	}
 	data, err := fa.fs.ReadFile(fa.args[2])
 	if err != nil {
 		return ErrFileNotReadable.WithInner(err)
 	}
-	mimetype, err := kind.SniffMimetype(data)
-	if err != nil {
-		return ErrNotTextFile.WithInner(err)
-	}
-	// is this a "create" or a "replace"?
 	asName := fa.args[2]
 	if flagUploadAsFilename != "" {
 		asName = flagUploadAsFilename
 	}
 	fa.op.Debug("as_name=%s\n", asName)
+	if isUploader {
+		return uploader.Upload(fa, asName, data)
+	}
+	mimetype, err := kind.SniffMimetype(data)
+	if err != nil {
+		return ErrNotTextFile.WithInner(err)
+	}
+	// is this a "create" or a "replace"?
 	prevId := flagUploadDocumentId
 	if prevId == "" {
 		prevId = determinePreviousDocumentId(fa.cfg, fa.op, fa.hc, asName)
 	}
 	fa.op.Debug("prev_id=%s\n", prevId)
-	var obj object
-	if prevId == "" {
-		obj, err = Query(fa.hc).Config(fa.cfg).Output(fa.op).Path("/v1/document/").Args(map[string]string{"name": asName, "usage": kind.Kind()}).Header(headers("content-type", mimetype)).Body(bytes.NewReader(data)).PropMap(propertyMapDocument).Post()
-	} else {
-		obj, err = Query(fa.hc).Config(fa.cfg).Output(fa.op).Path("/v1/document/" + url.QueryEscape(prevId)).Args(map[string]string{"name": asName, "usage": kind.Kind()}).Header(headers("content-type", mimetype)).Body(bytes.NewReader(data)).PropMap(propertyMapDocument).Put()
-	}
+	obj, err := putDocument(fa, kind, asName, mimetype, prevId, data)
 	if err != nil {
 		return err
 	}
@@ -76,3 +95,12 @@ func cmdUpload(fa FuncArgs) error {
 	}
 	return nil
 }
+
+// putDocument creates the document, or replaces the one with the ID prevId.
+func putDocument(fa FuncArgs, kind documentKind, name string, mimetype string, prevId string, data []byte) (object, error) {
+	q := Query(fa.hc).Config(fa.cfg).Output(fa.op).Args(map[string]string{"name": name, "usage": kind.Kind()}).Header(headers("content-type", mimetype)).Body(bytes.NewReader(data)).PropMap(propertyMapDocument)
+	if prevId == "" {
+		return q.Path("/v1/document/").Post()
+	}
+	return q.Path("/v1/document/" + url.QueryEscape(prevId)).Put()
+}
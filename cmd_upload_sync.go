@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// `upload --sync` keeps the documents of a kind the same as the files in a
+// directory, such as prompts kept in a repository. Documents are matched to
+// files by name, and only uploaded when their content differs.
+
+var (
+	ErrUploadSyncUsage    = ObserveError{Msg: "usage: observe upload <document usage> --sync <directory> [--prune]"}
+	ErrUploadSyncFlags    = ObserveError{Msg: "--as-filename, --document-id, and --primary-key don't go with --sync"}
+	ErrUploadSyncKind     = ObserveError{Msg: "this document usage kind can't be synced"}
+	ErrUploadPruneNoSync  = ObserveError{Msg: "--prune only goes with --sync"}
+	ErrUploadSyncConflict = ObserveError{Msg: "more than one document has the name"}
+)
+
+// syncFile is a file to upload, and what happened to it.
+type syncFile struct {
+	name     string
+	data     []byte
+	mimetype string
+	status   string
+	id       string
+}
+
+// readSyncDir reads and checks every file before anything is uploaded, so a
+// bad file doesn't leave the documents half synced. Subdirectories, and files
+// whose names start with a dot, are skipped.
+func readSyncDir(fa FuncArgs, kind documentKind, dir string) (map[string]*syncFile, error) {
+	entries, err := fa.fs.ReadDir(dir)
+	if err != nil {
+		return nil, ErrFileNotReadable.WithInner(err)
+	}
+	ret := map[string]*syncFile{}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := path.Join(dir, e.Name())
+		data, err := fa.fs.ReadFile(p)
+		if err != nil {
+			return nil, ErrFileNotReadable.WithInner(err)
+		}
+		mimetype, err := kind.SniffMimetype(data)
+		if err != nil {
+			return nil, NewObserveError(ErrNotTextFile.WithInner(err), "%s", p)
+		}
+		ret[e.Name()] = &syncFile{name: e.Name(), data: data, mimetype: mimetype}
+	}
+	return ret, nil
+}
+
+// sameDocument tells whether the document already has the data. The size
+// tells most changes apart; otherwise, the document is downloaded to compare
+// hashes.
+func sameDocument(fa FuncArgs, doc *objectDocument, data []byte) (bool, error) {
+	if doc.Size != int64(len(data)) {
+		return false, nil
+	}
+	remote, _, err := Query(fa.hc).Config(fa.cfg).Output(fa.op).Path(doc.Url).Send("GET")
+	if err != nil {
+		return false, NewObserveError(err, "download document %s", doc.Id)
+	}
+	return sha256.Sum256(remote) == sha256.Sum256(data), nil
+}
+
+func cmdUploadSync(fa FuncArgs, kind documentKind) error {
+	if len(fa.args) != 2 {
+		return ErrUploadSyncUsage
+	}
+	if flagUploadAsFilename != "" || flagUploadDocumentId != "" || len(flagUploadPrimaryKey) > 0 {
+		return ErrUploadSyncFlags
+	}
+	if _, is := kind.(documentUploader); is {
+		return NewObserveError(ErrUploadSyncKind, "%s", kind.Kind())
+	}
+	files, err := readSyncDir(fa, kind, flagUploadSync)
+	if err != nil {
+		return err
+	}
+	infos, err := ObjectTypeDocument.List(fa.cfg, fa.fs, fa.op, fa.hc)
+	if err != nil {
+		return NewObserveError(err, "list documents")
+	}
+	// documents are matched by name, so two with the same name can't be
+	// told apart, and are left alone
+	docs := map[string][]*objectDocument{}
+	for _, info := range infos {
+		if d := info.Object.(*objectDocument); d.Usage == kind.Kind() {
+			docs[d.Name] = append(docs[d.Name], d)
+		}
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	counts := map[string]int{}
+	var rows [][]string
+	// the table says what was done, even when something fails part way
+	finish := func(err error) error {
+		out := &ColumnFormatter{Output: fa.op, OmitLineDrawing: true, LiteralStrings: true}
+		out.SetColumnNames([]string{"name", "status", "id"})
+		for _, r := range rows {
+			out.AddRow(r)
+		}
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		fa.op.Info("%d new, %d changed, %d unchanged, %d deleted, %d not local\n", counts["new"], counts["changed"], counts["unchanged"], counts["deleted"], counts["not local"])
+		if err == nil && counts["conflict"] > 0 {
+			err = NewObserveError(ErrUploadSyncConflict, "%d names", counts["conflict"])
+		}
+		return err
+	}
+	conflict := func(name string, dups []*objectDocument) {
+		ids := make([]string, len(dups))
+		for i, d := range dups {
+			ids[i] = d.Id
+		}
+		counts["conflict"]++
+		rows = append(rows, []string{name, "conflict", strings.Join(sorted(ids), ",")})
+	}
+	for _, name := range sorted(maps.Keys(files)) {
+		f := files[name]
+		prevId := ""
+		f.status = "new"
+		switch dups := docs[name]; len(dups) {
+		case 0:
+		case 1:
+			doc := dups[0]
+			prevId, f.id = doc.Id, doc.Id
+			same, err := sameDocument(fa, doc, f.data)
+			if err != nil {
+				return finish(err)
+			}
+			f.status = "changed"
+			if same {
+				f.status = "unchanged"
+			}
+		default:
+			conflict(name, dups)
+			continue
+		}
+		if f.status != "unchanged" {
+			obj, err := putDocument(fa, kind, name, f.mimetype, prevId, f.data)
+			if err != nil {
+				return finish(NewObserveError(err, "upload %s", name))
+			}
+			f.id = fmt.Sprint(obj["id"])
+		}
+		counts[f.status]++
+		rows = append(rows, []string{name, f.status, f.id})
+	}
+	for _, name := range sorted(maps.Keys(docs)) {
+		if _, has := files[name]; has {
+			continue
+		}
+		if len(docs[name]) > 1 {
+			conflict(name, docs[name])
+			continue
+		}
+		doc := docs[name][0]
+		status := "not local"
+		if flagUploadPrune {
+			if err := ObjectTypeDocument.Delete(fa.cfg, fa.op, fa.hc, doc.Id); err != nil {
+				return finish(NewObserveError(err, "delete document %s", doc.Id))
+			}
+			status = "deleted"
+		}
+		counts[status]++
+		rows = append(rows, []string{name, status, doc.Id})
+	}
+	return finish(nil)
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"io/fs"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestCmdUploadPrompt(t *testing.T) {
@@ -39,3 +42,166 @@ func TestCmdUploadPrompt(t *testing.T) {
 		t.Error("return value didn't contain id:", fix.op.OutputBuf.String())
 	}
 }
+
+func TestCmdUploadReferenceTable(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/referencetables\?label=lookup`, 200, `{"ok":true,"data":[]}`},
+		testRequest{`/v1/referencetables`, 200, `{"ok":true,"data":{"meta":{"id":"41009001"},"config":{"label":"lookup"},"state":{"dataset":"41009000"}}}`},
+		testRequest{`/v1/referencetables\?label=lookup`, 200, `{"ok":true,"data":[{"meta":{"id":"41009001"},"config":{"label":"lookup"},"state":{"dataset":"41009000"}}]}`},
+		testRequest{`/v1/referencetables/41009001`, 200, `{"ok":true,"data":{"meta":{"id":"41009001"},"config":{"label":"lookup"},"state":{"dataset":"41009000"}}}`},
+	)
+	if err := fix.fs.WriteFile("cmdb/lookup.csv", []byte("host,owner,cores\nweb-1,ops,8\ndb-1,dba,32\n"), fs.FileMode(0666)); err != nil {
+		t.Fatal("couldn't set up file:", err)
+	}
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "reference-table", "cmdb/lookup.csv", "--primary-key", "host"}, fix.hc)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "reference-table", "cmdb/lookup.csv", "--primary-key", "host"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `created reference table "lookup" (41009001) with 2 rows; its dataset is 41009000
+replaced reference table "lookup" (41009001) with 2 rows; its dataset is 41009000
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "columns: host string, owner string, cores int64\n") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+}
+
+func TestCmdUploadReferenceTableBadKey(t *testing.T) {
+	fix := startFixture(t)
+	if err := fix.fs.WriteFile("lookup.csv", []byte("host,owner\nweb-1,ops\nweb-1,dba\n"), fs.FileMode(0666)); err != nil {
+		t.Fatal("couldn't set up file:", err)
+	}
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "reference-table", "lookup.csv", "--primary-key", "host"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrCsvDuplicateKey.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "prompt", "lookup.csv", "--primary-key", "host"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrUploadPrimaryKey.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func testDocumentJSON(id, name, usage string, size int) string {
+	return fmt.Sprintf(`{"meta":{"id":"o::1234:document:%s"},"config":{"name":%q,"usage":%q},"state":{"createdBy":"1","createdDate":"2023-04-20T16:20:00Z","updatedBy":"1","updatedDate":"2023-04-20T16:20:00Z","url":"/v1/document/download/o::1234:document:%s","mimetype":"text/markdown","size":"%d"}}`, id, name, usage, id, size)
+}
+
+func TestCmdUploadSync(t *testing.T) {
+	oncall := "# on-call\n\nPage the API team for 5xx errors.\n"
+	runbook := "# runbook\n\nRestart the pod.\n"
+	fix := startFixture(t,
+		testRequest{`/v1/document/`, 200, `{"ok":true,"data":[` +
+			testDocumentJSON("80000000021", "oncall.md", "prompt", len(oncall)) + `,` +
+			testDocumentJSON("80000000022", "runbook.md", "prompt", 12) + `,` +
+			testDocumentJSON("80000000023", "retired.md", "prompt", 30) + `,` +
+			testDocumentJSON("80000000024", "other.md", "backdrop", 30) + `]}`},
+		testRequest{`/v1/document/\?name=glossary\.md\&usage=prompt`, 200, `{"ok":true,"data":` + testDocumentJSON("80000000025", "glossary.md", "prompt", 24) + `}`},
+		testRequest{`/v1/document/download/o::1234:document:80000000021`, 200, oncall},
+		testRequest{`/v1/document/o%3A%3A1234%3Adocument%3A80000000022\?name=runbook\.md\&usage=prompt`, 200, `{"ok":true,"data":` + testDocumentJSON("80000000022", "runbook.md", "prompt", len(runbook)) + `}`},
+		testRequest{`/v1/document/o%3A%3A1234%3Adocument%3A80000000023`, 200, `{"ok":true}`},
+	)
+	for name, text := range map[string]string{
+		"prompts/oncall.md":      oncall,
+		"prompts/runbook.md":     runbook,
+		"prompts/glossary.md":    "# glossary\n\nSLO: goal\n",
+		"prompts/.gitkeep":       "",
+		"prompts/drafts/next.md": "# next\n",
+	} {
+		if err := fix.fs.WriteFile(name, []byte(text), fs.FileMode(0666)); err != nil {
+			t.Fatal("couldn't set up file:", err)
+		}
+	}
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "prompt", "--sync", "prompts/", "--prune"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `name        status    id                          
+glossary.md new       o::1234:document:80000000025
+oncall.md   unchanged o::1234:document:80000000021
+runbook.md  changed   o::1234:document:80000000022
+retired.md  deleted   o::1234:document:80000000023
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.InfoBuf.String(), "1 new, 1 changed, 1 unchanged, 1 deleted, 0 not local\n") {
+		t.Error("unexpected info output:", fix.op.InfoBuf.String())
+	}
+}
+
+func TestCmdUploadSyncPartial(t *testing.T) {
+	runbook := "# runbook\n\nRestart the pod.\n"
+	fix := startFixture(t,
+		testRequest{`/v1/document/`, 200, `{"ok":true,"data":[` +
+			testDocumentJSON("80000000021", "oncall.md", "prompt", 30) + `,` +
+			testDocumentJSON("80000000022", "runbook.md", "prompt", len(runbook)) + `,` +
+			testDocumentJSON("80000000023", "oncall.md", "prompt", 30) + `]}`},
+		testRequest{`/v1/document/download/o::1234:document:80000000022`, 200, runbook},
+		testRequest{`/v1/document/\?name=zebra\.md\&usage=prompt`, 500, `{"ok":false,"message":"storage is full"}`},
+	)
+	for name, text := range map[string]string{
+		"prompts/oncall.md":  "# on-call\n",
+		"prompts/runbook.md": runbook,
+		"prompts/zebra.md":   "# zebra\n",
+	} {
+		if err := fix.fs.WriteFile(name, []byte(text), fs.FileMode(0666)); err != nil {
+			t.Fatal("couldn't set up file:", err)
+		}
+	}
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "prompt", "--sync", "prompts"}, fix.hc)
+	})
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `name       status    id                                                       
+oncall.md  conflict  o::1234:document:80000000021,o::1234:document:80000000023
+runbook.md unchanged o::1234:document:80000000022                             
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	if !strings.Contains(fix.op.ErrorBuf.String(), "upload zebra.md") {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdUploadSyncConflict(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/document/`, 200, `{"ok":true,"data":[` +
+			testDocumentJSON("80000000021", "oncall.md", "prompt", 30) + `,` +
+			testDocumentJSON("80000000023", "oncall.md", "prompt", 30) + `]}`},
+	)
+	if err := fix.fs.WriteFile("prompts/.gitkeep", nil, fs.FileMode(0666)); err != nil {
+		t.Fatal("couldn't set up file:", err)
+	}
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"upload", "prompt", "--sync", "prompts", "--prune"}, fix.hc)
+	})
+	fix.Assert()
+	if !strings.Contains(fix.op.OutputBuf.String(), "oncall.md conflict o::1234:document:80000000021,o::1234:document:80000000023\n") {
+		t.Error("unexpected output:", fix.op.OutputBuf.String())
+	}
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrUploadSyncConflict.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdUploadSyncErrors(t *testing.T) {
+	fix := startFixture(t)
+	fix.fs.WriteFile("prompts/binary.md", []byte("\x00\x01\n"), fs.FileMode(0666))
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"upload", "prompt", "--sync", "prompts", "x.md"}, ErrUploadSyncUsage.Msg},
+		{[]string{"upload", "prompt", "--sync", "prompts", "--as-filename", "x.md"}, ErrUploadSyncFlags.Msg},
+		{[]string{"upload", "reference-table", "--sync", "prompts"}, ErrUploadSyncKind.Msg},
+		{[]string{"upload", "prompt", "x.md", "--prune"}, ErrUploadPruneNoSync.Msg},
+		{[]string{"upload", "prompt", "--sync", "prompts"}, "prompts/binary.md: " + ErrNotTextFile.Msg},
+	} {
+		mustPanic(t, func() {
+			RunCommandWithConfig(fix.cfg, fix.fs, fix.op, tc.args, fix.hc)
+		})
+		if !strings.Contains(fix.op.ErrorBuf.String(), tc.err) {
+			t.Errorf("%v: expected %q in error output: %s", tc.args, tc.err, fix.op.ErrorBuf.String())
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagsUserInvite     *pflag.FlagSet
+	flagsUserCreate     *pflag.FlagSet
+	flagsUserSetRole    *pflag.FlagSet
+	flagsUserDeactivate *pflag.FlagSet
+	flagsUserReactivate *pflag.FlagSet
+	flagUserEmail       string
+	flagUserName        string
+	flagUserRole        string
+	flagUserDefinition  string
+)
+
+func newUserFlags(name string, withProperties bool) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	if withProperties {
+		flags.StringVar(&flagUserEmail, "email", "", "email address of the new user")
+		flags.StringVar(&flagUserName, "name", "", "name of the new user")
+		flags.StringVar(&flagUserRole, "role", "", "role of the new user: one of "+strings.Join(userRoles, ", "))
+	}
+	flags.StringVarP(&flagUserDefinition, "definition", "d", "", "YAML or JSON file with the users to change, for batches; '-' for stdin")
+	return flags
+}
+
+func init() {
+	flagsUserInvite = newUserFlags("invite", true)
+	flagsUserCreate = newUserFlags("create", true)
+	flagsUserSetRole = newUserFlags("set-role", false)
+	flagsUserDeactivate = newUserFlags("deactivate", false)
+	flagsUserReactivate = newUserFlags("reactivate", false)
+	RegisterCommand(&Command{
+		Name: "user",
+		Help: "Manage the lifecycle of users: invite, change roles, and deactivate.",
+		Subcommands: []*Command{
+			{
+				Name:  "invite",
+				Help:  "Create a user and email them an invitation.",
+				Flags: flagsUserInvite,
+				Func:  cmdUserInvite,
+			},
+			{
+				Name:  "create",
+				Help:  "Create a user without sending an invitation.",
+				Flags: flagsUserCreate,
+				Func:  cmdUserCreate,
+			},
+			{
+				Name:  "set-role",
+				Help:  "Change the role of a user.",
+				Flags: flagsUserSetRole,
+				Func:  cmdUserSetRole,
+			},
+			{
+				Name:  "deactivate",
+				Help:  "Deactivate users, so they can no longer log in.",
+				Flags: flagsUserDeactivate,
+				Func:  cmdUserDeactivate,
+			},
+			{
+				Name:  "reactivate",
+				Help:  "Reactivate deactivated users.",
+				Flags: flagsUserReactivate,
+				Func:  cmdUserReactivate,
+			},
+		},
+	})
+}
+
+var (
+	ErrUserNewUsage        = ObserveError{Msg: "usage: observe user invite|create --email <email> [--name <name>] [--role <role>] | --definition <file>"}
+	ErrUserSetRoleUsage    = ObserveError{Msg: "usage: observe user set-role <user> <role> | --definition <file>"}
+	ErrUserStatusUsage     = ObserveError{Msg: "usage: observe user deactivate|reactivate <user>... | --definition <file>"}
+	ErrUserDefinitionUsers = ObserveError{Msg: "params.users in the definition must be a list of objects"}
+	ErrUserEntryNoUser     = ObserveError{Msg: "each user needs an id or email"}
+	ErrUserChangesFailed   = ObserveError{Msg: "some changes failed"}
+)
+
+// userEntries reads the users a --definition applies to. A batch is a list
+// of objects under params.users; otherwise, the object config, as create
+// takes it, is a batch of one.
+func userEntries(fa FuncArgs) ([]object, error) {
+	in, err := parseInput(fa.fs, fa.op, flagUserDefinition)
+	if err != nil {
+		return nil, err
+	}
+	if list, has := in.Params["users"]; has {
+		items, is := list.([]any)
+		if !is {
+			return nil, ErrUserDefinitionUsers
+		}
+		var ret []object
+		for _, item := range items {
+			o, is := item.(object)
+			if !is {
+				return nil, ErrUserDefinitionUsers
+			}
+			ret = append(ret, o)
+		}
+		return ret, nil
+	}
+	config, err := definitionConfig(ObjectTypeUser, in)
+	if err != nil {
+		return nil, err
+	}
+	return []object{config}, nil
+}
+
+// userChange is a validated change to an existing user.
+type userChange struct {
+	id    string
+	input object
+}
+
+// resolveUserChanges validates every change, and finds every user, before
+// anything is changed, so a bad entry doesn't leave a batch half done.
+func resolveUserChanges(fa FuncArgs, entries []object, input func(object) object) ([]userChange, error) {
+	var ret []userChange
+	for i, e := range entries {
+		in, err := checkUserInput(input(e))
+		if err != nil {
+			return nil, NewObserveError(err, "user %d", i+1)
+		}
+		// users named on the command line can be an ID, email, or name
+		var idOrName string
+		for _, k := range []string{"user", "id", "email"} {
+			if v, has := e[k]; has && idOrName == "" {
+				idOrName = fmt.Sprint(v)
+			}
+		}
+		if idOrName == "" {
+			return nil, NewObserveError(ErrUserEntryNoUser, "user %d", i+1)
+		}
+		info, err := resolveUser(fa.cfg, fa.fs, fa.op, fa.hc, idOrName)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, userChange{info.Id, in})
+	}
+	return ret, nil
+}
+
+// applyUserChanges keeps going past a failure, so that one bad change
+// doesn't stop the others, and says what was and wasn't done.
+func applyUserChanges(fa FuncArgs, changes []userChange, report func(u *objectUser) string) error {
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	failed := 0
+	for _, c := range changes {
+		obj, err := ObjectTypeUser.Update(fa.cfg, fa.op, fa.hc, c.id, c.input)
+		if err != nil {
+			failed++
+			fa.op.Error("%s\n", NewObserveError(err, "update user %s", c.id))
+			continue
+		}
+		u := obj.(*objectUser)
+		fmt.Fprintf(fa.op, "user %q (%d) %s\n", u.Name, u.Id, report(u))
+	}
+	if failed > 0 {
+		return NewObserveError(ErrUserChangesFailed, "changed %d of %d users; %d failed", len(changes)-failed, len(changes), failed)
+	}
+	return nil
+}
+
+func newUsers(fa FuncArgs, verb string, create func(*Config, Output, httpClient, object) (ObjectInstance, error)) error {
+	var entries []object
+	if flagUserDefinition != "" {
+		if len(fa.args) != 1 || flagUserEmail != "" || flagUserName != "" || flagUserRole != "" {
+			return ErrUserNewUsage
+		}
+		var err error
+		if entries, err = userEntries(fa); err != nil {
+			return err
+		}
+	} else {
+		if len(fa.args) != 1 || flagUserEmail == "" {
+			return ErrUserNewUsage
+		}
+		e := object{"email": flagUserEmail}
+		if flagUserName != "" {
+			e["name"] = flagUserName
+		}
+		if flagUserRole != "" {
+			e["role"] = flagUserRole
+		}
+		entries = []object{e}
+	}
+	// validate them all before creating any
+	for i, e := range entries {
+		if _, err := newUserInput(ObjectTypeUser, e); err != nil {
+			return NewObserveError(err, "user %d", i+1)
+		}
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	for _, e := range entries {
+		obj, err := create(fa.cfg, fa.op, fa.hc, e)
+		if err != nil {
+			return NewObserveError(err, "%s %v", verb, e["email"])
+		}
+		u := obj.(*objectUser)
+		fmt.Fprintf(fa.op, "%s %s as user %d with role %s\n", verb, u.Email, u.Id, u.Role)
+	}
+	return nil
+}
+
+func cmdUserInvite(fa FuncArgs) error {
+	return newUsers(fa, "invited", inviteUser)
+}
+
+func cmdUserCreate(fa FuncArgs) error {
+	return newUsers(fa, "created", ObjectTypeUser.Create)
+}
+
+func cmdUserSetRole(fa FuncArgs) error {
+	var entries []object
+	switch {
+	case flagUserDefinition != "" && len(fa.args) == 1:
+		var err error
+		if entries, err = userEntries(fa); err != nil {
+			return err
+		}
+	case flagUserDefinition == "" && len(fa.args) == 3:
+		entries = []object{{"user": fa.args[1], "role": fa.args[2]}}
+	default:
+		return ErrUserSetRoleUsage
+	}
+	changes, err := resolveUserChanges(fa, entries, func(e object) object {
+		return object{"role": e["role"]}
+	})
+	if err != nil {
+		return err
+	}
+	return applyUserChanges(fa, changes, func(u *objectUser) string {
+		return "now has role " + u.Role
+	})
+}
+
+func setUserStatus(fa FuncArgs, status string, verb string) error {
+	var entries []object
+	switch {
+	case flagUserDefinition != "" && len(fa.args) == 1:
+		var err error
+		if entries, err = userEntries(fa); err != nil {
+			return err
+		}
+	case flagUserDefinition == "" && len(fa.args) > 1:
+		for _, id := range fa.args[1:] {
+			entries = append(entries, object{"user": id})
+		}
+	default:
+		return ErrUserStatusUsage
+	}
+	changes, err := resolveUserChanges(fa, entries, func(object) object {
+		return object{"status": status}
+	})
+	if err != nil {
+		return err
+	}
+	return applyUserChanges(fa, changes, func(*objectUser) string {
+		return verb
+	})
+}
+
+func cmdUserDeactivate(fa FuncArgs) error {
+	return setUserStatus(fa, "UserStatusDeactivated", "deactivated")
+}
+
+func cmdUserReactivate(fa FuncArgs) error {
+	return setUserStatus(fa, "UserStatusActive", "reactivated")
+}
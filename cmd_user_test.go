@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testUserJane = `{"data":{"user":{"id":"3","name":"Jane","email":"jane@example.com","status":"UserStatusActive","role":"reader"}}}`
+
+func TestCmdUserInvite(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"inviteUser":{"id":"12","name":"Kim","email":"kim@example.com","status":"UserStatusInvited","role":"editor"}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"user", "invite", "--email", "kim@example.com", "--name", "Kim", "--role", "Editor"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "invited kim@example.com as user 12 with role editor\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdUserSetRole(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testUserJane},
+		testRequest{"/v1/meta", 200, `{"data":{"updateUser":{"id":"3","name":"Jane","email":"jane@example.com","status":"UserStatusActive","role":"editor"}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"user", "set-role", "3", "EDITOR"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "user \"Jane\" (3) now has role editor\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdUserValidation(t *testing.T) {
+	fix := startFixture(t)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"user", "set-role", "3", "owner"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrUserBadRole.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+	// nothing is invited when any user in the batch is bad
+	fix.fs.WriteFile("users.yaml", []byte("params:\n  users:\n    - email: a@example.com\n    - name: no email\n"), 0644)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"user", "invite", "-d", "users.yaml"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), "user 2: "+ErrUserNeedsEmail.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+	fix.Assert()
+}
+
+func TestCmdUserDeactivateBatch(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, testRbacUsers},
+		testRequest{"/v1/meta", 200, testUserJane},
+		testRequest{"/v1/meta", 200, `{"data":{"updateUser":{"id":"3","name":"Jane","email":"jane@example.com","status":"UserStatusDeactivated","role":"reader"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"user":{"id":"7","name":"Max","email":"max@example.com","status":"UserStatusActive","role":"reader"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"updateUser":{"id":"7","name":"Max","email":"max@example.com","status":"UserStatusDeactivated","role":"reader"}}}`},
+	)
+	fix.fs.WriteFile("leavers.yaml", []byte("params:\n  users:\n    - email: jane@example.com\n    - id: 7\n"), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"user", "deactivate", "--definition", "leavers.yaml"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "user \"Jane\" (3) deactivated\nuser \"Max\" (7) deactivated\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdUserDeactivateFailure(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 500, `{"message":"internal error"}`},
+		testRequest{"/v1/meta", 200, `{"data":{"user":{"id":"7","name":"Max","email":"max@example.com","status":"UserStatusActive","role":"reader"}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"updateUser":{"id":"7","name":"Max","email":"max@example.com","status":"UserStatusDeactivated","role":"reader"}}}`},
+	)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"user", "deactivate", "3", "7"}, fix.hc)
+	})
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "user \"Max\" (7) deactivated\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	errs := fix.op.ErrorBuf.String()
+	for _, want := range []string{"update user 3", "changed 1 of 2 users; 1 failed"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("expected %q in error output: %s", want, errs)
+		}
+	}
+}
+
+func TestMergeUserInput(t *testing.T) {
+	// an invited user's status isn't one that can be sent back
+	cur := &objectUser{Id: 12, Name: "Kim", Email: "kim@example.com", Status: "UserStatusInvited", Role: "reader"}
+	if diff := cmp.Diff(mergeUserInput(cur, object{"role": "editor"}), object{"label": "Kim", "email": "kim@example.com", "role": "editor"}); diff != "" {
+		t.Error("unexpected input:", diff)
+	}
+	if diff := cmp.Diff(mergeUserInput(cur, object{"status": "UserStatusActive"}), object{"label": "Kim", "email": "kim@example.com", "role": "reader", "status": "UserStatusActive"}); diff != "" {
+		t.Error("unexpected input:", diff)
+	}
+}
+
+func TestCheckUserInput(t *testing.T) {
+	got, err := checkUserInput(object{"role": "Admin", "status": "deactivated", "name": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, object{"role": "admin", "status": "UserStatusDeactivated", "name": "x"}); diff != "" {
+		t.Error("unexpected input:", diff)
+	}
+	if _, err := checkUserInput(object{"status": "UserStatusInvited"}); err == nil || !strings.Contains(err.Error(), ErrUserBadStatus.Msg) {
+		t.Error("expected a status error, got", err)
+	}
+}
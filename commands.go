@@ -22,6 +22,13 @@ type Command struct {
 	Unauthenticated bool
 	// This command isn't shown as part of help
 	Unlisted bool
+	// This command works on workspace-scoped objects, and thus also accepts
+	// --workspace after the command name
+	WorkspaceScoped bool
+	// Subcommands are chosen by the next argument, as in "observe rbac grant",
+	// and may have subcommands of their own. Their flags are parsed instead of
+	// the parent's, and Func (if any) is only called when none matches.
+	Subcommands []*Command
 	// Loaded large documentation blob
 	Docs []byte
 }
@@ -48,6 +55,19 @@ func RegisterCommand(cmd *Command) {
 			panic(fmt.Sprintf("There cannot be two commands named %q!", cmd.Name))
 		}
 	}
+	prepareCommandFlags(cmd)
+	cmd.Docs, _ = ReadDocFile(cmd.Name)
+	// Remember it for later lookup
+	allCommands = append(allCommands, cmd)
+}
+
+func prepareCommandFlags(cmd *Command) {
+	if cmd.WorkspaceScoped {
+		if cmd.Flags == nil {
+			cmd.Flags = pflag.NewFlagSet(cmd.Name, pflag.ContinueOnError)
+		}
+		cmd.Flags.String("workspace", "", "Workspace to use, by ID or name, overriding the configured workspace.")
+	}
 	// Sanity check flags
 	if cmd.Flags != nil {
 		cmd.Flags.VisitAll(func(f *pflag.Flag) {
@@ -56,9 +76,54 @@ func RegisterCommand(cmd *Command) {
 			}
 		})
 	}
-	cmd.Docs, _ = ReadDocFile(cmd.Name)
-	// Remember it for later lookup
-	allCommands = append(allCommands, cmd)
+	for _, sub := range cmd.Subcommands {
+		if strings.ToLower(sub.Name) != sub.Name {
+			panic(fmt.Sprintf("Subcommands should have lower-case names! %q is not that!", sub.Name))
+		}
+		prepareCommandFlags(sub)
+	}
+}
+
+// FindSubcommand returns the subcommand of cmd with the given name, or nil.
+func (cmd *Command) FindSubcommand(name string) *Command {
+	for _, sub := range cmd.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// subcommandUsage is the error for a command that needs a subcommand.
+func subcommandUsage(path string, cmd *Command) error {
+	names := make([]string, len(cmd.Subcommands))
+	for i, sub := range cmd.Subcommands {
+		names[i] = sub.Name
+	}
+	return NewObserveError(nil, "usage: observe %s <%s> ...", path, strings.Join(names, "|"))
+}
+
+// visitCommandFlags calls fn for the flags of cmd and of all its subcommands.
+func visitCommandFlags(cmd *Command, fn func(cmd *Command, f *pflag.Flag)) {
+	if cmd.Flags != nil {
+		cmd.Flags.VisitAll(func(f *pflag.Flag) { fn(cmd, f) })
+	}
+	for _, sub := range cmd.Subcommands {
+		visitCommandFlags(sub, fn)
+	}
+}
+
+// resetFlags puts the flags back to their defaults, so that a command that
+// runs again doesn't see the previous run's options.
+func resetFlags(flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if sv, is := f.Value.(pflag.SliceValue); is {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
 }
 
 func FindCommand(name string) *Command {
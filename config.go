@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,12 +15,25 @@ import (
 var ErrCouldNotParseConfig = ObserveError{Msg: "could not parse config"}
 
 type Config struct {
-	CustomerIdStr     string `json:"customerid" yaml:"customerid"`
-	SiteStr           string `json:"site" yaml:"site"`
-	AuthtokenStr      string `json:"authtoken" yaml:"authtoken"`
-	Quiet             bool   `json:"quiet" yaml:"quiet"`
-	Debug             bool   `json:"debug" yaml:"debug"`
-	WorkspaceIdOrName string `json:"workspace" yaml:"workspace"`
+	CustomerIdStr     string   `json:"customerid" yaml:"customerid"`
+	SiteStr           string   `json:"site" yaml:"site"`
+	AuthtokenStr      string   `json:"authtoken" yaml:"authtoken"`
+	Quiet             bool     `json:"quiet" yaml:"quiet"`
+	Debug             bool     `json:"debug" yaml:"debug"`
+	WorkspaceIdOrName string   `json:"workspace" yaml:"workspace"`
+	QueryInputs       []string `json:"input" yaml:"input"`
+	QueryRelative     string   `json:"relative" yaml:"relative"`
+	// Transport settings for deployments that need more than the defaults
+	Scheme                string `json:"scheme" yaml:"scheme"`
+	TLSCAFile             string `json:"tls_ca_file" yaml:"tls_ca_file"`
+	TLSCertFile           string `json:"tls_cert_file" yaml:"tls_cert_file"`
+	TLSKeyFile            string `json:"tls_key_file" yaml:"tls_key_file"`
+	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify" yaml:"tls_insecure_skip_verify"`
+	Proxy                 string `json:"proxy" yaml:"proxy"`
+	// On-disk metadata cache; an empty directory disables it
+	CacheDir     string `json:"cache_dir" yaml:"cache_dir"`
+	CacheTTL     string `json:"cache_ttl" yaml:"cache_ttl"`
+	CacheRefresh bool   `json:"refresh" yaml:"-"`
 	// Don't forget to add new fields into ParseConfig(), they're not
 	// automatically read into this struct!
 }
@@ -60,24 +75,7 @@ func ParseConfig(data []byte, cfg *Config, path string, profile string, required
 		return nil
 	}
 	if s, has := cy.Profile[profile]; has {
-		if s.CustomerIdStr != "" {
-			cfg.CustomerIdStr = s.CustomerIdStr
-		}
-		if s.SiteStr != "" {
-			cfg.SiteStr = s.SiteStr
-		}
-		if s.AuthtokenStr != "" {
-			cfg.AuthtokenStr = s.AuthtokenStr
-		}
-		if s.Quiet {
-			cfg.Quiet = s.Quiet
-		}
-		if s.Debug {
-			cfg.Debug = s.Debug
-		}
-		if s.WorkspaceIdOrName != "" {
-			cfg.WorkspaceIdOrName = s.WorkspaceIdOrName
-		}
+		mergeConfig(cfg, &s)
 		return nil
 	}
 	if required {
@@ -86,6 +84,195 @@ func ParseConfig(data []byte, cfg *Config, path string, profile string, required
 	return nil
 }
 
+// mergeConfig copies each field that is set in src over the same field in
+// dst, leaving fields that are not set in src alone. It returns the names of
+// the fields that were copied.
+func mergeConfig(dst *Config, src *Config) []string {
+	var set []string
+	if src.CustomerIdStr != "" {
+		dst.CustomerIdStr = src.CustomerIdStr
+		set = append(set, "customerid")
+	}
+	if src.SiteStr != "" {
+		dst.SiteStr = src.SiteStr
+		set = append(set, "site")
+	}
+	if src.AuthtokenStr != "" {
+		dst.AuthtokenStr = src.AuthtokenStr
+		set = append(set, "authtoken")
+	}
+	if src.Quiet {
+		dst.Quiet = src.Quiet
+		set = append(set, "quiet")
+	}
+	if src.Debug {
+		dst.Debug = src.Debug
+		set = append(set, "debug")
+	}
+	if src.WorkspaceIdOrName != "" {
+		dst.WorkspaceIdOrName = src.WorkspaceIdOrName
+		set = append(set, "workspace")
+	}
+	if len(src.QueryInputs) != 0 {
+		dst.QueryInputs = src.QueryInputs
+		set = append(set, "input")
+	}
+	if src.QueryRelative != "" {
+		dst.QueryRelative = src.QueryRelative
+		set = append(set, "relative")
+	}
+	if src.Scheme != "" {
+		dst.Scheme = src.Scheme
+		set = append(set, "scheme")
+	}
+	if src.TLSCAFile != "" {
+		dst.TLSCAFile = src.TLSCAFile
+		set = append(set, "tls_ca_file")
+	}
+	if src.TLSCertFile != "" {
+		dst.TLSCertFile = src.TLSCertFile
+		set = append(set, "tls_cert_file")
+	}
+	if src.TLSKeyFile != "" {
+		dst.TLSKeyFile = src.TLSKeyFile
+		set = append(set, "tls_key_file")
+	}
+	if src.TLSInsecureSkipVerify {
+		dst.TLSInsecureSkipVerify = src.TLSInsecureSkipVerify
+		set = append(set, "tls_insecure_skip_verify")
+	}
+	if src.Proxy != "" {
+		dst.Proxy = src.Proxy
+		set = append(set, "proxy")
+	}
+	if src.CacheDir != "" {
+		dst.CacheDir = src.CacheDir
+		set = append(set, "cache_dir")
+	}
+	if src.CacheTTL != "" {
+		dst.CacheTTL = src.CacheTTL
+		set = append(set, "cache_ttl")
+	}
+	return set
+}
+
+// ConfigSources remembers, for each config field name, where the value in
+// effect came from ("flag --site", "env OBSERVE_SITE", and so on.)
+type ConfigSources map[string]string
+
+func (cs ConfigSources) set(names []string, source string) {
+	for _, n := range names {
+		cs[n] = source
+	}
+}
+
+// ConfigEnvName returns the environment variable that can set the named
+// config field.
+func ConfigEnvName(name string) string {
+	return "OBSERVE_" + strings.ToUpper(name)
+}
+
+// ReadConfigFromEnv assigns each config field for which the environment has a
+// non-empty OBSERVE_<FIELD> variable, and returns the names of the fields
+// assigned. Unlike profiles, the environment can turn quiet and debug off.
+func ReadConfigFromEnv(cfg *Config, getenv func(string) string) ([]string, error) {
+	var set []string
+	str := func(name string, dst *string) {
+		if v := getenv(ConfigEnvName(name)); v != "" {
+			*dst = v
+			set = append(set, name)
+		}
+	}
+	boolean := func(name string, dst *bool) error {
+		if v := getenv(ConfigEnvName(name)); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return NewObserveError(err, "environment %s", ConfigEnvName(name))
+			}
+			*dst = b
+			set = append(set, name)
+		}
+		return nil
+	}
+	str("customerid", &cfg.CustomerIdStr)
+	str("site", &cfg.SiteStr)
+	str("authtoken", &cfg.AuthtokenStr)
+	if err := boolean("quiet", &cfg.Quiet); err != nil {
+		return nil, err
+	}
+	if err := boolean("debug", &cfg.Debug); err != nil {
+		return nil, err
+	}
+	str("workspace", &cfg.WorkspaceIdOrName)
+	if v := getenv(ConfigEnvName("input")); v != "" {
+		// same comma separation as the --input flag
+		cfg.QueryInputs = strings.Split(v, ",")
+		set = append(set, "input")
+	}
+	str("relative", &cfg.QueryRelative)
+	str("scheme", &cfg.Scheme)
+	str("tls_ca_file", &cfg.TLSCAFile)
+	str("tls_cert_file", &cfg.TLSCertFile)
+	str("tls_key_file", &cfg.TLSKeyFile)
+	if err := boolean("tls_insecure_skip_verify", &cfg.TLSInsecureSkipVerify); err != nil {
+		return nil, err
+	}
+	str("proxy", &cfg.Proxy)
+	str("cache_dir", &cfg.CacheDir)
+	str("cache_ttl", &cfg.CacheTTL)
+	return set, nil
+}
+
+// ProjectConfigFileName is looked for in the current directory and each of
+// its parents, so a repository can pin its own tenant profile and workspace.
+const ProjectConfigFileName = ".observe.yaml"
+
+// The project config file is flat (no profile sections); it may name the
+// profile to read from the user config, and any config field, which then
+// layers over whatever that profile says.
+type ProjectConfig struct {
+	Profile string `json:"profile" yaml:"profile"`
+	Config  `yaml:",inline"`
+}
+
+// FindProjectConfigPath walks upward from dir, returning the path of the
+// first project config file found, or the empty string if there is none.
+func FindProjectConfigPath(fs fileSystem, dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		p := filepath.Join(dir, ProjectConfigFileName)
+		if _, err := fs.Stat(p); err == nil {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func ParseProjectConfig(data []byte, path string) (*ProjectConfig, error) {
+	var pc ProjectConfig
+	if len(data) == 0 {
+		return &pc, nil
+	}
+	dec := yaml.NewDecoder(bytes.NewBuffer(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&pc); err != nil {
+		return nil, NewObserveError(err, "could not parse project config %q", path)
+	}
+	return &pc, nil
+}
+
+func ReadProjectConfig(fs fileSystem, path string) (*ProjectConfig, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, NewObserveError(err, "could not read project config")
+	}
+	return ParseProjectConfig(data, path)
+}
+
 func ReadUntypedConfigFromFile(fs fileSystem, strPath string, required bool) (map[string]any, error) {
 	data, err := fs.ReadFile(strPath)
 	if err != nil {
@@ -121,56 +308,94 @@ func SaveUntypedConfig(fs fileSystem, strPath string, config map[string]any) err
 	return nil
 }
 
-// mustGetWorkspaceId will attempt to fetch the workspace.id from the config, else it will
-// query the workspaces in the customer and attempt to find it by name, else it will default
-// to the first workspace in the list.
-func mustGetWorkspaceId(cfg *Config, hc httpClient) string {
-	// Maybe the ID is already configured.
-	if cfg.WorkspaceIdOrName != "" {
-		_, err := strconv.Atoi(cfg.WorkspaceIdOrName)
-		isId := err == nil
-		if isId {
-			return cfg.WorkspaceIdOrName
-		}
+var (
+	ErrNoWorkspaces         = ObserveError{Msg: "the tenant has no workspaces"}
+	ErrWorkspaceUnspecified = ObserveError{Msg: "the tenant has more than one workspace; choose one with --workspace"}
+)
+
+// ResolveWorkspace finds the configured workspace, which may be given by ID
+// or by name. A name that doesn't match exactly may still match ignoring
+// case, as long as that's not ambiguous. If no workspace is configured, the
+// tenant must have exactly one. Anything else is an error, because running
+// against the wrong workspace is worse than not running at all.
+func ResolveWorkspace(cfg *Config, fs fileSystem, op Output, hc httpClient) (*ObjectInfo, error) {
+	workspaces, err := ObjectTypeWorkspace.List(cfg, fs, op, hc)
+	if err != nil {
+		return nil, NewObserveError(err, "list workspaces")
 	}
-	// WorkspaceIdOrName is a Name or empty.
-	// Get all the workspaces, attempt to find one with the name=`cfg.WorkspaceIdOrName`
-	var op DefaultOutput
-	workspaces, err := ObjectTypeWorkspace.List(cfg, op, hc)
+	ws, err := matchWorkspace(cfg.WorkspaceIdOrName, workspaces)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to load workspaces for customer:%s", cfg.CustomerIdStr))
+		return nil, err
 	}
-	for _, workspace := range workspaces {
-		if workspace.Name == cfg.WorkspaceIdOrName {
-			return workspace.Id
+	op.Debug("workspace=%s (%s)\n", ws.Id, ws.Name)
+	return ws, nil
+}
+
+func matchWorkspace(idOrName string, workspaces []*ObjectInfo) (*ObjectInfo, error) {
+	if len(workspaces) == 0 {
+		return nil, ErrNoWorkspaces
+	}
+	if idOrName == "" {
+		if len(workspaces) != 1 {
+			return nil, NewObserveError(ErrWorkspaceUnspecified, "workspaces are %s", quotedNames(workspaces))
 		}
+		return workspaces[0], nil
 	}
-	return workspaces[0].Id
+	return matchObject("workspace", idOrName, workspaces)
 }
 
-// mustGetWorkspaceName will attempt to fetch the workspace.name from the config, else it will
-// query the workspaces in the customer and attempt to find it by id, else it will default
-// to the first workspace in the list.
-func mustGetWorkspaceName(cfg *Config, hc httpClient) string {
-	// Maybe the Name is already configured.
-	if cfg.WorkspaceIdOrName != "" {
-		_, err := strconv.Atoi(cfg.WorkspaceIdOrName)
-		isName := err != nil
-		if isName {
-			return cfg.WorkspaceIdOrName
+// matchObject finds the object with the given ID or name, falling back to a
+// unique case-insensitive name match. The errors suggest similar names.
+func matchObject(kind string, idOrName string, infos []*ObjectInfo) (*ObjectInfo, error) {
+	for _, info := range infos {
+		if info.Id == idOrName || info.Name == idOrName {
+			return info, nil
 		}
 	}
-	// WorkspaceIdOrName is an ID or empty.
-	// Get all the workspaces, attempt to find one with the id=`cfg.WorkspaceIdOrName`
-	var op DefaultOutput
-	workspaces, err := ObjectTypeWorkspace.List(cfg, op, hc)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to load workspaces for customer:%s", cfg.CustomerIdStr))
+	var folded []*ObjectInfo
+	for _, info := range infos {
+		if strings.EqualFold(info.Name, idOrName) {
+			folded = append(folded, info)
+		}
 	}
-	for _, workspace := range workspaces {
-		if workspace.Id == cfg.WorkspaceIdOrName {
-			return workspace.Name
+	switch len(folded) {
+	case 1:
+		return folded[0], nil
+	case 0:
+		if similar := similarNames(idOrName, infos); len(similar) > 0 {
+			return nil, NewObserveError(nil, "%s %q not found; did you mean %s?", kind, idOrName, quotedNames(similar))
+		}
+		if len(infos) > 20 {
+			return nil, NewObserveError(nil, "%s %q not found", kind, idOrName)
+		}
+		return nil, NewObserveError(nil, "%s %q not found; %ss are %s", kind, idOrName, kind, quotedNames(infos))
+	default:
+		return nil, NewObserveError(nil, "%s %q is ambiguous; it could be %s", kind, idOrName, quotedNames(folded))
+	}
+}
+
+// similarNames returns the infos whose names are a few typos away from name,
+// or contain it, or are contained in it, ignoring case.
+func similarNames(name string, infos []*ObjectInfo) []*ObjectInfo {
+	lname := strings.ToLower(name)
+	var ret []*ObjectInfo
+	for _, i := range infos {
+		lother := strings.ToLower(i.Name)
+		maxDist := len(lname) / 3
+		if maxDist < 2 {
+			maxDist = 2
+		}
+		if editDistance(lname, lother) <= maxDist || strings.Contains(lother, lname) || strings.Contains(lname, lother) {
+			ret = append(ret, i)
 		}
 	}
-	return workspaces[0].Name
+	return ret
+}
+
+func quotedNames(infos []*ObjectInfo) string {
+	strs := make([]string, len(infos))
+	for i, info := range infos {
+		strs[i] = fmt.Sprintf("%q (%s)", info.Name, info.Id)
+	}
+	return strings.Join(strs, ", ")
 }
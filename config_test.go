@@ -31,30 +31,33 @@ func TestConfigYaml(t *testing.T) {
 }
 
 func TestConfigYamlWorkspaceIdOrName(t *testing.T) {
-	resultObject := `{ "currentUser": { "workspaces": [{ "id": "1", "name": "name-1" }, { "id": "2", "name": "name-2" }] } }`
+	resultObject := `{ "currentUser": { "workspaces": [{ "id": "1", "name": "name-1" }, { "id": "2", "name": "name-2" }, { "id": "3", "name": "Payments" }] } }`
 
 	testcases := []struct {
 		description       string
 		workspaceIdOrName string
 		expectedId        string
 		expectedName      string
+		expectedError     string
 	}{
 		{
-			description:  "no configured workspace, default to first",
-			expectedId:   "1",
-			expectedName: "name-1",
+			description:   "no configured workspace, more than one to choose from",
+			expectedError: "more than one workspace",
 		},
 		{
-			description:       "configured 2nd workspace incorrectly by name, default to first for id",
+			description:       "configured workspace incorrectly by name",
 			workspaceIdOrName: "bleh",
-			expectedId:        "1",
-			expectedName:      "bleh",
+			expectedError:     `workspace "bleh" not found; workspaces are "name-1" (1), "name-2" (2), "Payments" (3)`,
 		},
 		{
-			description:       "configured 2nd workspace incorrectly by id, default to first for name",
+			description:       "configured workspace with a typo",
+			workspaceIdOrName: "Paymnets",
+			expectedError:     `workspace "Paymnets" not found; did you mean "Payments" (3)?`,
+		},
+		{
+			description:       "configured workspace incorrectly by id",
 			workspaceIdOrName: "0",
-			expectedId:        "0",
-			expectedName:      "name-1",
+			expectedError:     `workspace "0" not found`,
 		},
 		{
 			description:       "configured 2nd workspace by id",
@@ -68,21 +71,96 @@ func TestConfigYamlWorkspaceIdOrName(t *testing.T) {
 			expectedId:        "2",
 			expectedName:      "name-2",
 		},
+		{
+			description:       "configured workspace by name in other case",
+			workspaceIdOrName: "payments",
+			expectedId:        "3",
+			expectedName:      "Payments",
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.description, func(t *testing.T) {
 			fix := startFixture(t,
 				testRequest{"/v1/meta", 200, fmt.Sprintf(`{"data":%s}`, resultObject)},
-				testRequest{"/v1/meta", 200, fmt.Sprintf(`{"data":%s}`, resultObject)},
 			)
 
 			fix.cfg.WorkspaceIdOrName = tc.workspaceIdOrName
 
-			id := mustGetWorkspaceId(fix.cfg, fix.hc)
-			assert.Equal(t, tc.expectedId, id)
-
-			name := mustGetWorkspaceName(fix.cfg, fix.hc)
-			assert.Equal(t, tc.expectedName, name)
+			ws, err := ResolveWorkspace(fix.cfg, fix.fs, fix.op, fix.hc)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedId, ws.Id)
+			assert.Equal(t, tc.expectedName, ws.Name)
 		})
 	}
 }
+
+func TestMatchWorkspaceAmbiguous(t *testing.T) {
+	workspaces := []*ObjectInfo{{Id: "1", Name: "Default"}, {Id: "2", Name: "default"}}
+	_, err := matchWorkspace("DEFAULT", workspaces)
+	assert.ErrorContains(t, err, "ambiguous")
+	ws, err := matchWorkspace("", workspaces[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, "1", ws.Id)
+	_, err = matchWorkspace("", nil)
+	assert.ErrorIs(t, err, ErrNoWorkspaces)
+}
+
+func TestProjectConfig(t *testing.T) {
+	fs := NewFakeFs()
+	fs.WriteFile("/src/repo/.observe.yaml", []byte(`profile: testing
+workspace: Payments
+input:
+  - "Payments.kubernetes/Container Logs"
+relative: 4h
+`), 0644)
+	assert.Equal(t, "/src/repo/.observe.yaml", FindProjectConfigPath(fs, "/src/repo/service/cmd"))
+	assert.Equal(t, "/src/repo/.observe.yaml", FindProjectConfigPath(fs, "/src/repo"))
+	assert.Equal(t, "", FindProjectConfigPath(fs, "/src/other"))
+
+	pc, err := ReadProjectConfig(fs, "/src/repo/.observe.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "testing", pc.Profile)
+
+	var cfg Config
+	assert.NoError(t, ParseConfig([]byte(testConfig), &cfg, "some path", "default", true))
+	cfg.WorkspaceIdOrName = "Default"
+	mergeConfig(&cfg, &pc.Config)
+	assert.Equal(t, Config{
+		CustomerIdStr:     "101",
+		SiteStr:           "observe-eng.com",
+		AuthtokenStr:      "not-an-authtoken",
+		WorkspaceIdOrName: "Payments",
+		QueryInputs:       []string{"Payments.kubernetes/Container Logs"},
+		QueryRelative:     "4h",
+	}, cfg)
+
+	_, err = ParseProjectConfig([]byte("customer: 101\n"), "bad.yaml")
+	assert.Error(t, err)
+}
+
+func TestReadConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OBSERVE_CUSTOMERID": "102",
+		"OBSERVE_WORKSPACE":  "Staging",
+		"OBSERVE_QUIET":      "false",
+		"OBSERVE_INPUT":      "41007104,pods=41007085",
+	}
+	cfg := Config{CustomerIdStr: "101", SiteStr: "observe-eng.com", Quiet: true}
+	set, err := ReadConfigFromEnv(&cfg, func(k string) string { return env[k] })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"customerid", "quiet", "workspace", "input"}, set)
+	assert.Equal(t, Config{
+		CustomerIdStr:     "102",
+		SiteStr:           "observe-eng.com",
+		WorkspaceIdOrName: "Staging",
+		QueryInputs:       []string{"41007104", "pods=41007085"},
+	}, cfg)
+
+	env["OBSERVE_DEBUG"] = "maybe"
+	_, err = ReadConfigFromEnv(&cfg, func(k string) string { return env[k] })
+	assert.ErrorContains(t, err, "OBSERVE_DEBUG")
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+// A reference table is a CSV file that becomes a dataset, for OPAL to look
+// things up in. It isn't stored as a document; it has an API of its own.
+
+var (
+	ErrCsvNoHeader      = ObserveError{Msg: "the CSV file needs a header line naming the columns"}
+	ErrCsvNoRows        = ObserveError{Msg: "the CSV file has no rows after the header"}
+	ErrCsvColumnName    = ObserveError{Msg: "each column needs a unique, non-empty name"}
+	ErrCsvPrimaryKey    = ObserveError{Msg: "the primary key must name columns of the CSV file"}
+	ErrCsvDuplicateKey  = ObserveError{Msg: "primary key values must be unique"}
+	ErrCsvEmptyKey      = ObserveError{Msg: "primary key values can't be empty"}
+	ErrReferenceTableId = ObserveError{Msg: "the reference table API didn't return an ID"}
+)
+
+const MaxReferenceTableFileSize = 100000000
+
+type docKindReferenceTable struct {
+}
+
+func init() {
+	addDocumenKind(&docKindReferenceTable{})
+}
+
+var _ documentUploader = &docKindReferenceTable{}
+
+func (d *docKindReferenceTable) Kind() string {
+	return "reference-table"
+}
+
+func (d *docKindReferenceTable) SniffMimetype(data []byte) (string, error) {
+	if _, err := parseReferenceCsv(data); err != nil {
+		return "", err
+	}
+	return "text/csv", nil
+}
+
+type referenceCsv struct {
+	columns []referenceColumn
+	rows    [][]string
+}
+
+type referenceColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// csvTypes are the types a column can have, most specific first. A column has
+// the first type that all of its non-empty values parse as, unless some of
+// them are codes, which keep it a string.
+var csvTypes = []struct {
+	name  string
+	parse func(string) bool
+}{
+	{"int64", func(s string) bool { _, err := strconv.ParseInt(s, 10, 64); return err == nil }},
+	{"float64", func(s string) bool { _, err := strconv.ParseFloat(s, 64); return err == nil }},
+	{"bool", func(s string) bool { _, err := strconv.ParseBool(s); return err == nil }},
+	{"timestamp", func(s string) bool { _, err := time.Parse(time.RFC3339Nano, s); return err == nil }},
+}
+
+// isCode tells whether a value is written like a number, but is a code,
+// such as a ZIP code or asset tag, whose leading zeros or + sign would be
+// lost as a number: "00123" and "+44" are codes, but "0" and "0.5" aren't.
+func isCode(s string) bool {
+	if strings.HasPrefix(s, "+") {
+		return true
+	}
+	s = strings.TrimPrefix(s, "-")
+	return len(s) > 1 && s[0] == '0' && s[1] >= '0' && s[1] <= '9'
+}
+
+// parseReferenceCsv checks that the data is a CSV file that can be a
+// reference table, and works out the type of each column.
+func parseReferenceCsv(data []byte) (*referenceCsv, error) {
+	if len(data) > MaxReferenceTableFileSize {
+		return nil, ErrFileTooLarge
+	}
+	if !utf8.Valid(data) {
+		return nil, ErrInvalidUtf8
+	}
+	if isBOM(data) {
+		data = data[3:]
+	}
+	rd := csv.NewReader(bytes.NewReader(data))
+	records, err := rd.ReadAll()
+	if err != nil {
+		// the csv errors have the line, and what's wrong with it
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			return nil, NewObserveError(nil, "line %d: %s", pe.Line, pe.Err)
+		}
+		return nil, NewObserveError(err, "read CSV")
+	}
+	if len(records) == 0 {
+		return nil, ErrCsvNoHeader
+	}
+	header := records[0]
+	seen := map[string]bool{}
+	for _, name := range header {
+		if name = strings.TrimSpace(name); name == "" || seen[name] {
+			return nil, NewObserveError(ErrCsvColumnName, "column %q", name)
+		}
+		seen[name] = true
+	}
+	if len(records) == 1 {
+		return nil, ErrCsvNoRows
+	}
+	ret := &referenceCsv{rows: records[1:]}
+	for i, name := range header {
+		col := referenceColumn{Name: strings.TrimSpace(name), Type: "string"}
+		if slices.ContainsFunc(ret.rows, func(r []string) bool { return isCode(strings.TrimSpace(r[i])) }) {
+			ret.columns = append(ret.columns, col)
+			continue
+		}
+		for _, t := range csvTypes {
+			all, some := true, false
+			for _, r := range ret.rows {
+				if v := strings.TrimSpace(r[i]); v != "" {
+					some = true
+					if !t.parse(v) {
+						all = false
+						break
+					}
+				}
+			}
+			if all && some {
+				col.Type = t.name
+				break
+			}
+		}
+		ret.columns = append(ret.columns, col)
+	}
+	return ret, nil
+}
+
+// encode writes the CSV file as it was parsed, so that what's uploaded has
+// the column names that were checked, and no byte order mark.
+func (rc *referenceCsv) encode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	header := make([]string, len(rc.columns))
+	for i, c := range rc.columns {
+		header[i] = c.Name
+	}
+	w.Write(header)
+	w.WriteAll(rc.rows)
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkPrimaryKey checks that the key columns exist, and that their values
+// identify each row.
+func checkPrimaryKey(rc *referenceCsv, key []string) error {
+	if len(key) == 0 {
+		return nil
+	}
+	var idx []int
+	for _, k := range key {
+		i := slices.IndexFunc(rc.columns, func(c referenceColumn) bool { return c.Name == k })
+		if i < 0 {
+			return NewObserveError(ErrCsvPrimaryKey, "%q", k)
+		}
+		idx = append(idx, i)
+	}
+	seen := map[string]int{}
+	for n, r := range rc.rows {
+		var vals []string
+		for _, i := range idx {
+			if strings.TrimSpace(r[i]) == "" {
+				return NewObserveError(ErrCsvEmptyKey, "row %d", n+1)
+			}
+			vals = append(vals, r[i])
+		}
+		k := strings.Join(vals, "\x00")
+		if prev, has := seen[k]; has {
+			return NewObserveError(ErrCsvDuplicateKey, "rows %d and %d", prev, n+1)
+		}
+		seen[k] = n + 1
+	}
+	return nil
+}
+
+// Currently mapping from meta/config/state tuple, to flat, like documents.
+var propertyMapReferenceTable = PropertyMap{
+	"id":        mkpath("meta.id"),
+	"name":      mkpath("config.label"),
+	"datasetId": mkpath("state.dataset"),
+}
+
+func determinePreviousReferenceTableId(cfg *Config, op Output, hc httpClient, name string) string {
+	lst, err := Query(hc).Config(cfg).Output(op).Path("/v1/referencetables").Args(map[string]string{"label": name}).PropMap(propertyMapReferenceTable).GetList()
+	if err != nil || len(lst) < 1 {
+		return ""
+	}
+	if str, is := lst[0].(object)["id"].(string); is {
+		return str
+	}
+	return ""
+}
+
+// referenceTableBody is the multipart form the reference table API takes: the
+// metadata, as JSON, and the file, re-encoded from what was parsed.
+func referenceTableBody(name string, rc *referenceCsv, key []string) (*bytes.Buffer, string, error) {
+	meta, err := json.Marshal(object{"label": name, "primaryKey": key, "schema": rc.columns})
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := rc.encode()
+	if err != nil {
+		return nil, "", err
+	}
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	mh := textproto.MIMEHeader{}
+	mh.Set("Content-Disposition", `form-data; name="metadata"`)
+	mh.Set("Content-Type", "application/json")
+	part, err := mw.CreatePart(mh)
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(meta)
+	fh := textproto.MIMEHeader{}
+	fh.Set("Content-Disposition", fmt.Sprintf(`form-data; name="upload"; filename=%q`, name+".csv"))
+	fh.Set("Content-Type", "text/csv")
+	if part, err = mw.CreatePart(fh); err != nil {
+		return nil, "", err
+	}
+	part.Write(data)
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, mw.FormDataContentType(), nil
+}
+
+// Upload creates the reference table, or replaces the one of the same name,
+// or the one given with --document-id. The name is the file name without
+// its extension, unless --as-filename gives one.
+func (d *docKindReferenceTable) Upload(fa FuncArgs, name string, data []byte) error {
+	if flagUploadAsFilename == "" {
+		name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+	rc, err := parseReferenceCsv(data)
+	if err != nil {
+		return err
+	}
+	if err := checkPrimaryKey(rc, flagUploadPrimaryKey); err != nil {
+		return err
+	}
+	var cols []string
+	for _, c := range rc.columns {
+		cols = append(cols, c.Name+" "+c.Type)
+	}
+	fa.op.Info("columns: %s\n", strings.Join(cols, ", "))
+	prevId := flagUploadDocumentId
+	if prevId == "" {
+		prevId = determinePreviousReferenceTableId(fa.cfg, fa.op, fa.hc, name)
+	}
+	fa.op.Debug("prev_id=%s\n", prevId)
+	body, contentType, err := referenceTableBody(name, rc, flagUploadPrimaryKey)
+	if err != nil {
+		return NewObserveError(err, "reference table %q", name)
+	}
+	q := Query(fa.hc).Config(fa.cfg).Output(fa.op).Header(headers("content-type", contentType)).Body(body).PropMap(propertyMapReferenceTable)
+	var obj object
+	verb := "created"
+	if prevId == "" {
+		obj, err = q.Path("/v1/referencetables").Post()
+	} else {
+		verb = "replaced"
+		obj, err = q.Path("/v1/referencetables/" + url.QueryEscape(prevId)).Put()
+	}
+	if err != nil {
+		return err
+	}
+	id, _ := obj["id"].(string)
+	if id == "" {
+		return ErrReferenceTableId
+	}
+	defer invalidateCache(fa.cfg, fa.fs, fa.op)
+	fmt.Fprintf(fa.op, "%s reference table %q (%s) with %d rows; its dataset is %v\n", verb, name, id, len(rc.rows), obj["datasetId"])
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReferenceCsv(t *testing.T) {
+	rc, err := parseReferenceCsv([]byte("\xef\xbb\xbfhost,cores,load,managed,seen\n" +
+		"web-1,8,0.5,true,2026-10-01T12:00:00Z\n" +
+		"web-2,,1,false,\n" +
+		"\"db, primary\",32,2.25,TRUE,2026-10-02T08:30:00.5Z\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rc.columns, []referenceColumn{
+		{"host", "string"},
+		{"cores", "int64"},
+		{"load", "float64"},
+		{"managed", "bool"},
+		{"seen", "timestamp"},
+	}); diff != "" {
+		t.Error("unexpected columns:", diff)
+	}
+	if len(rc.rows) != 3 {
+		t.Error("unexpected rows:", rc.rows)
+	}
+}
+
+func TestParseReferenceCsvCodes(t *testing.T) {
+	rc, err := parseReferenceCsv([]byte("zip,phone,count,ratio,delta\n" +
+		"00123,+4420,0,0.5,-1\n" +
+		"94107,+1415,12,1.25,-0.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rc.columns, []referenceColumn{
+		{"zip", "string"},
+		{"phone", "string"},
+		{"count", "int64"},
+		{"ratio", "float64"},
+		{"delta", "float64"},
+	}); diff != "" {
+		t.Error("unexpected columns:", diff)
+	}
+}
+
+func TestParseReferenceCsvErrors(t *testing.T) {
+	for i, tc := range []struct {
+		input string
+		err   string
+	}{
+		{"", ErrCsvNoHeader.Msg},
+		{"host,cores\n", ErrCsvNoRows.Msg},
+		{"host,,cores\nweb-1,a,8\n", ErrCsvColumnName.Msg},
+		{"host,host\nweb-1,web-2\n", ErrCsvColumnName.Msg},
+		{"host,cores\nweb-1,8\nweb-2\n", "line 3: wrong number of fields"},
+		{"host,cores\nweb-1,\"8\n", "line 2"},
+		{"host\n\xc0web-1\n", ErrInvalidUtf8.Msg},
+	} {
+		if _, err := parseReferenceCsv([]byte(tc.input)); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("case %d: expected %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestCheckPrimaryKey(t *testing.T) {
+	rc, err := parseReferenceCsv([]byte("host,port,owner\nweb-1,80,ops\nweb-1,443,web\nweb-2,80,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		key []string
+		err string
+	}{
+		{nil, ""},
+		{[]string{"host", "port"}, ""},
+		{[]string{"host"}, "rows 1 and 2: " + ErrCsvDuplicateKey.Msg},
+		{[]string{"owner"}, "row 3: " + ErrCsvEmptyKey.Msg},
+		{[]string{"ip"}, ErrCsvPrimaryKey.Msg},
+	} {
+		err := checkPrimaryKey(rc, tc.key)
+		if (err == nil) != (tc.err == "") || (err != nil && !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("case %d: expected %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestReferenceTableBody(t *testing.T) {
+	rc, err := parseReferenceCsv([]byte("\xef\xbb\xbf host , cores\r\n\"db, primary\",32\r\nweb-1,8\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, err := referenceTableBody("lookup", rc, []string{"host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(part)
+		parts[part.FormName()] = string(data)
+	}
+	if diff := cmp.Diff(parts, map[string]string{
+		"metadata": `{"label":"lookup","primaryKey":["host"],"schema":[{"name":"host","type":"string"},{"name":"cores","type":"int64"}]}`,
+		"upload":   "host,cores\n\"db, primary\",32\nweb-1,8\n",
+	}); diff != "" {
+		t.Error("unexpected body:", diff)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -18,12 +19,29 @@ func compileGqlQuery(q string, path ...string) compiledGqlQuery {
 }
 
 type compiledGqlQuery struct {
-	q     string
-	path  []string
-	remap remapPrepped
+	q      string
+	path   []string
+	remap  remapPrepped
+	cached bool
 }
 
 func (cq compiledGqlQuery) query(cfg *Config, op Output, hc httpClient, args object) (any, error) {
+	return cq.queryCached(cfg, nil, op, hc, args)
+}
+
+// queryCached is query, but for a query compiled WithCache(), it uses the
+// metadata cache in fs; with a nil fs, it's the same as query.
+func (cq compiledGqlQuery) queryCached(cfg *Config, fs fileSystem, op Output, hc httpClient, args object) (any, error) {
+	var cache *metadataCache
+	if cq.cached && fs != nil {
+		var err error
+		if cache, err = cacheFor(cfg, fs, op); err != nil {
+			return nil, err
+		}
+		if data, ok := cache.Read(op, cq.q, args); ok {
+			return cq.unmarshalDecode(data)
+		}
+	}
 	// format arguments as GraphQL request object
 	obj := object{"query": cq.q, "variables": args}
 	buf := bytes.Buffer{}
@@ -36,7 +54,11 @@ func (cq compiledGqlQuery) query(cfg *Config, op Output, hc httpClient, args obj
 		op.Debug("err=%s\n", err)
 		return nil, err
 	}
-	return cq.unmarshalDecode(data)
+	ret, err := cq.unmarshalDecode(data)
+	if err == nil && cache != nil {
+		cache.Write(op, cq.q, args, data)
+	}
+	return ret, err
 }
 
 func (cq compiledGqlQuery) unmarshalDecode(data []byte) (any, error) {
@@ -50,14 +72,52 @@ func (cq compiledGqlQuery) unmarshalDecode(data []byte) (any, error) {
 		return nil, NewObserveError(nil, "response is malformed: not an object")
 	}
 	if errs, has := retObj["errors"]; has {
-		if y, is := errs.(array); is {
-			return nil, NewObserveError(nil, "response JSON error: %v", y[0])
-		}
-		return nil, NewObserveError(nil, "response JSON error: %v", errs)
+		return nil, NewObserveError(nil, "response JSON error: %s", formatGqlErrors(errs))
 	}
 	return cq.decodePath(ret)
 }
 
+// formatGqlErrors describes every entry in a GraphQL "errors" array, with the
+// path and source location when the server provides them, one per line.
+func formatGqlErrors(errs any) string {
+	y, is := errs.(array)
+	if !is {
+		return fmt.Sprintf("%v", errs)
+	}
+	var lines []string
+	for _, e := range y {
+		o, is := e.(object)
+		if !is {
+			lines = append(lines, fmt.Sprintf("%v", e))
+			continue
+		}
+		line := fmt.Sprintf("%v", o["message"])
+		var where []string
+		if p, is := o["path"].(array); is && len(p) > 0 {
+			parts := make([]string, len(p))
+			for i, pi := range p {
+				parts[i] = fmt.Sprintf("%v", pi)
+			}
+			where = append(where, "path "+strings.Join(parts, "."))
+		}
+		if locs, is := o["locations"].(array); is {
+			for _, l := range locs {
+				if lo, is := l.(object); is {
+					where = append(where, fmt.Sprintf("line %v column %v", lo["line"], lo["column"]))
+				}
+			}
+		}
+		if len(where) > 0 {
+			line += " (" + strings.Join(where, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return fmt.Sprintf("%d errors:\n  %s", len(lines), strings.Join(lines, "\n  "))
+}
+
 func (cq compiledGqlQuery) decodePath(ret any) (any, error) {
 	for i, key := range cq.path {
 		if a, is := ret.(array); is {
@@ -131,7 +191,57 @@ func prepRemap(r remap) remapPrepped {
 	return ret
 }
 
+// nest is the inverse of the remapping: it moves flat properties back to the
+// nested paths the API uses, for building input objects. Properties that
+// aren't remapped are kept as they are.
+func (r remap) nest(flat object) object {
+	ret := object{}
+	for k, v := range flat {
+		src, has := r[k]
+		if !has {
+			ret[k] = v
+			continue
+		}
+		path := strings.Split(src, ".")
+		o := ret
+		for _, p := range path[:len(path)-1] {
+			inner, is := o[p].(object)
+			if !is {
+				inner = object{}
+				o[p] = inner
+			}
+			o = inner
+		}
+		o[path[len(path)-1]] = v
+	}
+	return ret
+}
+
 func (cq compiledGqlQuery) WithRemap(r remap) compiledGqlQuery {
 	cq.remap = prepRemap(r)
 	return cq
 }
+
+// WithCache makes the query use the on-disk metadata cache, when it's run
+// with queryCached. Only use this for queries that list slowly-changing
+// metadata.
+func (cq compiledGqlQuery) WithCache() compiledGqlQuery {
+	cq.cached = true
+	return cq
+}
+
+// resultStatus turns the ResultStatus that delete mutations return into an
+// error.
+func resultStatus(ret any) error {
+	o, is := ret.(object)
+	if !is {
+		return NewObserveError(nil, "response is malformed: not a result status")
+	}
+	if ok, _ := o["success"].(bool); ok {
+		return nil
+	}
+	if msg, _ := o["errorMessage"].(string); msg != "" {
+		return NewObserveError(nil, "%s", msg)
+	}
+	return NewObserveError(nil, "the operation did not succeed")
+}
@@ -0,0 +1,452 @@
+// Command otgen generates the data parts of object types -- the struct, with
+// the observe tags that RegisterObjectType derives the properties from, and
+// the gqlList/gqlGet queries -- from a GraphQL introspection result and a
+// small mapping file. The methods of each type (GetInfo, List, Get, ...) stay
+// hand-written in ot_<name>.go, and the generated code goes in
+// ot_<name>_gen.go next to it.
+//
+// It emits tags rather than propertyDesc tables on purpose: the hand-written
+// types declare their properties with tags, and RegisterObjectType builds
+// the same PropertyDesc tables from them, so generating the tables would
+// leave two ways of doing one thing.
+//
+// It is run through go generate from the top of the repository:
+//
+//	go run ./internal/otgen -schema schema/introspection.json -types schema/objecttypes.yaml
+//
+// The introspection result is the output of schema/introspection.graphql,
+// which can be fetched with:
+//
+//	observe gql -f schema/introspection.graphql > schema/introspection.json
+//
+// The checked-in copy may be trimmed to the types that are generated, to keep
+// the diffs reviewable.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "schema/introspection.json", "GraphQL introspection result")
+	typesPath := flag.String("types", "schema/objecttypes.yaml", "object type mapping file")
+	outDir := flag.String("out", ".", "directory to write ot_<name>_gen.go files to")
+	flag.Parse()
+
+	files, err := generateFiles(*schemaPath, *typesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "otgen: %s\n", err)
+		os.Exit(1)
+	}
+	for _, name := range sortedKeys(files) {
+		if err := os.WriteFile(filepath.Join(*outDir, name), files[name], 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "otgen: %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// generateFiles returns the contents of each generated file, by file name.
+func generateFiles(schemaPath, typesPath string) (map[string][]byte, error) {
+	schemaData, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	sch, err := parseSchema(schemaData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", schemaPath, err)
+	}
+	typesData, err := os.ReadFile(typesPath)
+	if err != nil {
+		return nil, err
+	}
+	var mapping mappingFile
+	dec := yaml.NewDecoder(bytes.NewReader(typesData))
+	dec.KnownFields(true)
+	if err := dec.Decode(&mapping); err != nil {
+		return nil, fmt.Errorf("%s: %w", typesPath, err)
+	}
+	source := fmt.Sprintf("%s and %s", filepath.ToSlash(schemaPath), filepath.ToSlash(typesPath))
+	ret := map[string][]byte{}
+	for _, mt := range mapping.Types {
+		gt, err := resolveType(sch, mt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: type %q: %w", typesPath, mt.Name, err)
+		}
+		data, err := renderType(gt, source)
+		if err != nil {
+			return nil, fmt.Errorf("type %q: %w", mt.Name, err)
+		}
+		ret["ot_"+mt.Name+"_gen.go"] = data
+	}
+	return ret, nil
+}
+
+// The mapping file format; see schema/objecttypes.yaml.
+type mappingFile struct {
+	Types []mappingType `yaml:"types"`
+}
+
+type mappingType struct {
+	Name       string            `yaml:"name"`
+	Type       string            `yaml:"type"`
+	List       *mappingList      `yaml:"list"`
+	Get        *mappingGet       `yaml:"get"`
+	Properties []mappingProperty `yaml:"properties"`
+}
+
+type mappingList struct {
+	// Field is a dotted path of Query fields that ends in a list of Type.
+	Field string `yaml:"field"`
+	// Properties is the subset of properties to list; all if empty.
+	Properties []string `yaml:"properties"`
+	Cache      bool     `yaml:"cache"`
+}
+
+type mappingGet struct {
+	// Field is a Query field taking a single required id argument.
+	Field string `yaml:"field"`
+}
+
+type mappingProperty struct {
+	Name string `yaml:"name"`
+	// Field is the GraphQL field name, if it differs from Name.
+	Field    string `yaml:"field"`
+	Id       bool   `yaml:"id"`
+	Computed bool   `yaml:"computed"`
+}
+
+// The subset of the introspection result that otgen needs.
+type schemaDoc struct {
+	Schema struct {
+		QueryType struct {
+			Name string `json:"name"`
+		} `json:"queryType"`
+		Types []*schemaType `json:"types"`
+	} `json:"__schema"`
+}
+
+type schemaType struct {
+	Kind   string        `json:"kind"`
+	Name   string        `json:"name"`
+	Fields []schemaField `json:"fields"`
+}
+
+type schemaField struct {
+	Name string       `json:"name"`
+	Args []schemaArg  `json:"args"`
+	Type *schemaTyRef `json:"type"`
+}
+
+type schemaArg struct {
+	Name string       `json:"name"`
+	Type *schemaTyRef `json:"type"`
+}
+
+type schemaTyRef struct {
+	Kind   string       `json:"kind"`
+	Name   string       `json:"name"`
+	OfType *schemaTyRef `json:"ofType"`
+}
+
+// named strips NON_NULL and LIST wrappers.
+func (r *schemaTyRef) named() *schemaTyRef {
+	for r.OfType != nil {
+		r = r.OfType
+	}
+	return r
+}
+
+func (r *schemaTyRef) isList() bool {
+	if r.Kind == "NON_NULL" {
+		r = r.OfType
+	}
+	return r.Kind == "LIST"
+}
+
+// String formats the type the way it's written in a query.
+func (r *schemaTyRef) String() string {
+	switch r.Kind {
+	case "NON_NULL":
+		return r.OfType.String() + "!"
+	case "LIST":
+		return "[" + r.OfType.String() + "]"
+	default:
+		return r.Name
+	}
+}
+
+type schema struct {
+	query string
+	types map[string]*schemaType
+}
+
+func parseSchema(data []byte) (*schema, error) {
+	var doc schemaDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Schema.QueryType.Name == "" {
+		// the output of observe gql --path, or of a raw /v1/meta request
+		var wrapped struct {
+			Data schemaDoc `json:"data"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err == nil {
+			doc = wrapped.Data
+		}
+	}
+	if doc.Schema.QueryType.Name == "" {
+		return nil, fmt.Errorf("not a GraphQL introspection result")
+	}
+	sch := &schema{query: doc.Schema.QueryType.Name, types: map[string]*schemaType{}}
+	for _, t := range doc.Schema.Types {
+		sch.types[t.Name] = t
+	}
+	return sch, nil
+}
+
+func (s *schema) field(typeName, fieldName string) (*schemaField, error) {
+	t, has := s.types[typeName]
+	if !has {
+		return nil, fmt.Errorf("type %q is not in the schema", typeName)
+	}
+	for i := range t.Fields {
+		if t.Fields[i].Name == fieldName {
+			return &t.Fields[i], nil
+		}
+	}
+	return nil, fmt.Errorf("type %q has no field %q", typeName, fieldName)
+}
+
+// Scalars map to the Go types that the observe tags turn into property types.
+// Only scalars whose JSON encoding matches the PropertyType's FromGQL are
+// allowed; notably, Int is a JSON number, which PropertyTypeInteger doesn't
+// take.
+var scalarTypes = map[string]string{
+	"ObjectId": "int64",
+	"UserId":   "int64",
+	"Int64":    "int64",
+	"String":   "string",
+	"Time":     "string",
+	"Boolean":  "bool",
+}
+
+// genType is a resolved mapping, ready to render.
+type genType struct {
+	Name       string
+	Struct     string
+	Suffix     string
+	Properties []genProperty
+	List       *genQuery
+	Get        *genQuery
+}
+
+type genProperty struct {
+	Name     string
+	GoName   string
+	GoType   string
+	Nullable bool
+	Computed bool
+	Id       bool
+}
+
+// Tag is the struct tag that declares the property; see propertytags.go.
+func (gp genProperty) Tag() string {
+	tag := gp.Name
+	if gp.Id {
+		tag += ",id"
+	}
+	if gp.Computed {
+		tag += ",computed"
+	}
+	return fmt.Sprintf("`observe:%q`", tag)
+}
+
+type genQuery struct {
+	Text  string
+	Path  []string
+	Cache bool
+}
+
+func resolveType(sch *schema, mt mappingType) (*genType, error) {
+	if mt.Name == "" || mt.Type == "" {
+		return nil, fmt.Errorf("name and type are required")
+	}
+	if t, has := sch.types[mt.Type]; !has || t.Kind != "OBJECT" {
+		return nil, fmt.Errorf("type %q is not in the schema", mt.Type)
+	}
+	suffix := exportName(mt.Name)
+	gt := &genType{
+		Name:   mt.Name,
+		Struct: "object" + suffix,
+		Suffix: suffix,
+	}
+	selections := map[string]string{}
+	for _, mp := range mt.Properties {
+		field := mp.Field
+		if field == "" {
+			field = mp.Name
+		}
+		sf, err := sch.field(mt.Type, field)
+		if err != nil {
+			return nil, err
+		}
+		if sf.Type.isList() {
+			return nil, fmt.Errorf("property %q: list fields are not supported", mp.Name)
+		}
+		named := sf.Type.named()
+		goType, has := scalarTypes[named.Name]
+		if named.Kind != "SCALAR" || !has {
+			return nil, fmt.Errorf("property %q: unsupported type %s", mp.Name, sf.Type)
+		}
+		gp := genProperty{
+			Name:     mp.Name,
+			GoName:   exportName(mp.Name),
+			GoType:   goType,
+			Nullable: sf.Type.Kind != "NON_NULL",
+			Computed: mp.Computed,
+			Id:       mp.Id,
+		}
+		if gp.Nullable {
+			gp.GoType = "*" + gp.GoType
+		}
+		gt.Properties = append(gt.Properties, gp)
+		if field == mp.Name {
+			selections[mp.Name] = field
+		} else {
+			selections[mp.Name] = mp.Name + ":" + field
+		}
+	}
+	selectAll := func(names []string) (string, error) {
+		if len(names) == 0 {
+			for _, gp := range gt.Properties {
+				names = append(names, gp.Name)
+			}
+		}
+		var parts []string
+		for _, n := range names {
+			sel, has := selections[n]
+			if !has {
+				return "", fmt.Errorf("%q is not a property", n)
+			}
+			parts = append(parts, sel)
+		}
+		return strings.Join(parts, " "), nil
+	}
+	if mt.List != nil {
+		path := strings.Split(mt.List.Field, ".")
+		owner := sch.query
+		var sf *schemaField
+		for _, p := range path {
+			var err error
+			if sf, err = sch.field(owner, p); err != nil {
+				return nil, fmt.Errorf("list: %w", err)
+			}
+			owner = sf.Type.named().Name
+		}
+		if !sf.Type.isList() || owner != mt.Type {
+			return nil, fmt.Errorf("list: %s is %s, not a list of %s", mt.List.Field, sf.Type, mt.Type)
+		}
+		sel, err := selectAll(mt.List.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("list: %w", err)
+		}
+		text := sel
+		for i := len(path) - 1; i >= 0; i-- {
+			text = path[i] + " { " + text + " }"
+		}
+		gt.List = &genQuery{
+			Text:  fmt.Sprintf("query %s_List { %s }", suffix, text),
+			Path:  append([]string{"data"}, path...),
+			Cache: mt.List.Cache,
+		}
+	}
+	if mt.Get != nil {
+		sf, err := sch.field(sch.query, mt.Get.Field)
+		if err != nil {
+			return nil, fmt.Errorf("get: %w", err)
+		}
+		if len(sf.Args) != 1 || sf.Args[0].Name != "id" {
+			return nil, fmt.Errorf("get: %s must take exactly one argument, id", mt.Get.Field)
+		}
+		if sf.Type.named().Name != mt.Type || sf.Type.isList() {
+			return nil, fmt.Errorf("get: %s is %s, not %s", mt.Get.Field, sf.Type, mt.Type)
+		}
+		sel, err := selectAll(nil)
+		if err != nil {
+			return nil, fmt.Errorf("get: %w", err)
+		}
+		gt.Get = &genQuery{
+			Text: fmt.Sprintf("query %s_Get_Id($id: %s) { %s(id: $id) { %s } }", suffix, sf.Args[0].Type, mt.Get.Field, sel),
+			Path: []string{"data", mt.Get.Field},
+		}
+	}
+	return gt, nil
+}
+
+func exportName(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"quote": func(s []string) string {
+		q := make([]string, len(s))
+		for i, si := range s {
+			q[i] = fmt.Sprintf("%q", si)
+		}
+		return strings.Join(q, ", ")
+	},
+}).Parse(`// Code generated by otgen from {{.Source}}. DO NOT EDIT.
+
+package main
+{{with .Type}}
+type {{.Struct}} struct {
+{{- range .Properties}}
+	{{.GoName}} {{.GoType}} {{.Tag}}
+{{- end}}
+}
+{{with .List}}
+var gqlList{{$.Type.Suffix}} = compileGqlQuery(` + "`{{.Text}}`" + `, {{quote .Path}}){{if .Cache}}.WithCache(){{end}}
+{{end}}
+{{- with .Get}}
+var gqlGet{{$.Type.Suffix}} = compileGqlQuery(` + "`{{.Text}}`" + `, {{quote .Path}})
+{{end}}
+{{- end}}`))
+
+func renderType(gt *genType, source string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, struct {
+		Source string
+		Type   *genType
+	}{source, gt}); err != nil {
+		return nil, err
+	}
+	ret, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, buf.Bytes())
+	}
+	return ret, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The generated files must be checked in; this catches forgetting to run go
+// generate after changing the schema, the mapping, or otgen itself.
+func TestGeneratedUpToDate(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	files, err := generateFiles("schema/introspection.json", "schema/objecttypes.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("expected some generated files")
+	}
+	for name, data := range files {
+		have, err := os.ReadFile(name)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if string(have) != string(data) {
+			t.Errorf("%s is out of date; run go generate", name)
+		}
+	}
+}
+
+const testSchema = `{"__schema":{"queryType":{"name":"Query"},"types":[
+	{"kind":"OBJECT","name":"Query","fields":[
+		{"name":"thing","args":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ObjectId"}}}],"type":{"kind":"OBJECT","name":"Thing"}},
+		{"name":"things","args":[],"type":{"kind":"LIST","ofType":{"kind":"OBJECT","name":"Thing"}}}
+	]},
+	{"kind":"OBJECT","name":"Thing","fields":[
+		{"name":"id","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ObjectId"}}},
+		{"name":"label","args":[],"type":{"kind":"SCALAR","name":"String"}},
+		{"name":"count","args":[],"type":{"kind":"SCALAR","name":"Int"}},
+		{"name":"tags","args":[],"type":{"kind":"LIST","ofType":{"kind":"SCALAR","name":"String"}}}
+	]}
+]}}`
+
+func TestResolveType(t *testing.T) {
+	sch, err := parseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gt, err := resolveType(sch, mappingType{
+		Name:       "thing",
+		Type:       "Thing",
+		List:       &mappingList{Field: "things", Properties: []string{"id"}},
+		Get:        &mappingGet{Field: "thing"},
+		Properties: []mappingProperty{{Name: "id", Id: true}, {Name: "name", Field: "label"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gt.Properties[1].GoType != "*string" || !gt.Properties[1].Nullable {
+		t.Errorf("expected nullable name: %+v", gt.Properties[1])
+	}
+	if gt.List.Text != "query Thing_List { things { id } }" {
+		t.Error("unexpected list query:", gt.List.Text)
+	}
+	if gt.Get.Text != "query Thing_Get_Id($id: ObjectId!) { thing(id: $id) { id name:label } }" {
+		t.Error("unexpected get query:", gt.Get.Text)
+	}
+	data, err := renderType(gt, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Name *string `observe:\"name\"`") || !strings.Contains(string(data), "`observe:\"id,id\"`") {
+		t.Errorf("unexpected output:\n%s", data)
+	}
+
+	for i, tc := range []struct {
+		mt  mappingType
+		err string
+	}{
+		{mappingType{Name: "thing", Type: "Nope"}, `type "Nope" is not in the schema`},
+		{mappingType{Name: "thing", Type: "Thing", Properties: []mappingProperty{{Name: "bogus"}}}, `no field "bogus"`},
+		{mappingType{Name: "thing", Type: "Thing", Properties: []mappingProperty{{Name: "count"}}}, `unsupported type Int`},
+		{mappingType{Name: "thing", Type: "Thing", Properties: []mappingProperty{{Name: "tags"}}}, `list fields are not supported`},
+		{mappingType{Name: "thing", Type: "Thing", Get: &mappingGet{Field: "things"}}, `must take exactly one argument`},
+		{mappingType{Name: "thing", Type: "Thing", List: &mappingList{Field: "thing"}}, `not a list of Thing`},
+	} {
+		_, err := resolveType(sch, tc.mt)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: expected error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 
 	"github.com/spf13/pflag"
@@ -11,8 +10,6 @@ import (
 
 // TODO: add more object types:
 // - lsetting
-// - rbacgroup, rbacmember, rbacstatement
-// - user
 // others?
 func main() {
 	ParseFlags()
@@ -21,7 +18,10 @@ func main() {
 	}
 	var cfg Config
 	var op DefaultOutput
-	InitConfigFromFileAndFlags(&cfg, &op)
+	sources := InitConfigFromFileAndFlags(&cfg, &op)
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = DefaultCacheDir()
+	}
 	if *FlagOutput != "" && *FlagOutput != "-" {
 		defer SendOutputToFile(*FlagOutput, &op)()
 	}
@@ -29,11 +29,16 @@ func main() {
 		m := json.NewEncoder(op)
 		m.SetIndent("", "  ")
 		m.SetEscapeHTML(false)
-		err := m.Encode(cfg)
+		// the config fields stay at the top level, as they always were
+		err := m.Encode(struct {
+			Config
+			Sources ConfigSources `json:"sources"`
+		}{cfg, sources})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s\n", err)
 			op.Exit(2)
 		}
 	}
-	RunCommandWithConfig(&cfg, newFs(), op, pflag.Args(), http.DefaultClient)
+	fs := newFs()
+	RunCommandWithConfig(&cfg, fs, op, pflag.Args(), &lazyHttpClient{cfg: &cfg, fs: fs})
 }
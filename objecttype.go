@@ -11,7 +11,7 @@ type ObjectType interface {
 	Help() string
 
 	CanList() bool
-	List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error)
+	List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error)
 	CanGet() bool
 	Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error)
 	CanCreate() bool
@@ -38,15 +38,29 @@ type ObjectInstance interface {
 	PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error
 }
 
+// Object types listed in schema/objecttypes.yaml get their struct, with the
+// tags its properties come from, and queries generated into ot_<name>_gen.go.
+//go:generate go run ./internal/otgen -schema schema/introspection.json -types schema/objecttypes.yaml
+
 var objectTypes = map[string]ObjectType{}
 
 type object = map[string]any
 type array = []any
 
+// RegisterObjectType makes the object type available to commands. If the
+// template struct has `observe` tags, its properties are derived from them;
+// see propertytags.go.
 func RegisterObjectType(t ObjectType, tmpl any) {
 	if _, has := objectTypes[t.TypeName()]; has {
 		panic(fmt.Errorf("Attempt to re-register object type %q", t.TypeName()))
 	}
+	props, err := propertyDescsFromTags(tmpl)
+	if err != nil {
+		panic(fmt.Errorf("object type %q: %w", t.TypeName(), err))
+	}
+	if len(props) > 0 {
+		objectTypeProperties[t.TypeName()] = props
+	}
 	objectTypes[t.TypeName()] = t
 }
 
@@ -57,6 +71,15 @@ func GetObjectType(name string) ObjectType {
 	return nil
 }
 
+// resolveObject finds an object of type ot by ID or name, listing them all.
+func resolveObject(cfg *Config, fs fileSystem, op Output, hc httpClient, ot ObjectType, idOrName string) (*ObjectInfo, error) {
+	infos, err := ot.List(cfg, fs, op, hc)
+	if err != nil {
+		return nil, NewObserveError(err, "list %s", ot.TypeName())
+	}
+	return matchObject(ot.TypeName(), idOrName, infos)
+}
+
 func GetObjectTypes() []ObjectType {
 	l := make([]ObjectType, 0, len(objectTypes))
 	for _, typ := range objectTypes {
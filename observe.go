@@ -16,17 +16,18 @@ import (
 )
 
 var FlagProfile = pflag.StringP("profile", "P", "default", "The name of a section in the ~/.config/observe.yaml config file. Make empty to read no profile. Can also be specified in environment OBSERVE_PROFILE")
-var FlagCustomerId = pflag.StringP("customerid", "C", "", "The numeric ID of your Observe tenant.")
-var FlagSiteStr = pflag.StringP("site", "S", "", "The domain of your Observe tenant site. Can include :port if needed.")
-var FlagAuthtokenStr = pflag.StringP("authtoken", "A", "", "The bearer token for Observe authorization. May be two-part with a space separator. Does not include 'Bearer' word.")
+var FlagCustomerId = pflag.StringP("customerid", "C", "", "The numeric ID of your Observe tenant. Can also be specified in environment OBSERVE_CUSTOMERID.")
+var FlagSiteStr = pflag.StringP("site", "S", "", "The domain of your Observe tenant site. Can include :port if needed. Can also be specified in environment OBSERVE_SITE.")
+var FlagAuthtokenStr = pflag.StringP("authtoken", "A", "", "The bearer token for Observe authorization. May be two-part with a space separator. Does not include 'Bearer' word. Can also be specified in environment OBSERVE_AUTHTOKEN.")
 var FlagOutput = pflag.StringP("output", "O", "", "The output file name for data output. If empty or '-', output goes to stdout.")
 var FlagQuiet = pflag.BoolP("quiet", "Q", false, "Don't output info logs.")
 var FlagDebug = pflag.BoolP("debug", "D", false, "Output extra debug logs.")
 var FlagLog = pflag.BoolP("timestamp", "T", false, "Timestamp output to make better log files.")
 var FlagHelp = pflag.BoolP("help", "h", false, "Print help.")
-var FlagShowConfig = pflag.BoolP("show-config", "", false, "Print configuration before running command.")
+var FlagShowConfig = pflag.BoolP("show-config", "", false, "Print configuration, and where each value came from, before running command.")
 var FlagConfigFile = pflag.String("config", "", "Read configuration from given file rather than ~/config/observe.yaml. Can also be specified in environment OBSERVE_CONFIG.")
-var FlagWorkspace = pflag.String("workspace", "", "Default workspace to assume for objects if none is specified.")
+var FlagWorkspace = pflag.String("workspace", "", "Default workspace to assume for objects if none is specified. Can also be specified in environment OBSERVE_WORKSPACE.")
+var FlagRefresh = pflag.Bool("refresh", false, "Ignore the local metadata cache, and re-fetch workspace, dataset, user, and group lists.")
 var FlagQuietExit = pflag.BoolP("quiet-exit", "E", false, "Return successful exit code even on failure.")
 
 var flagsParsed = false
@@ -45,13 +46,15 @@ func ParseFlags() {
 			}
 		})
 		IterateCommands(func(cmd *Command) {
-			if cmd.Flags != nil {
-				cmd.Flags.VisitAll(func(f *pflag.Flag) {
-					if pflag.CommandLine.Lookup(f.Name) != nil {
-						panic(fmt.Sprintf("Command %q flag %q clashes with global flag of same name!", cmd.Name, f.Name))
-					}
-				})
-			}
+			visitCommandFlags(cmd, func(cmd *Command, f *pflag.Flag) {
+				if cmd.WorkspaceScoped && f.Name == "workspace" {
+					// deliberately the same as the global flag
+					return
+				}
+				if pflag.CommandLine.Lookup(f.Name) != nil {
+					panic(fmt.Sprintf("Command %q flag %q clashes with global flag of same name!", cmd.Name, f.Name))
+				}
+			})
 		})
 		flagsParsed = true
 		pflag.Lookup("help").NoOptDefVal = "true"
@@ -59,6 +62,7 @@ func ParseFlags() {
 		pflag.Lookup("debug").NoOptDefVal = "true"
 		pflag.Lookup("timestamp").NoOptDefVal = "true"
 		pflag.Lookup("quiet-exit").NoOptDefVal = "true"
+		pflag.Lookup("refresh").NoOptDefVal = "true"
 		pflag.SetInterspersed(false)
 		pflag.Parse()
 		envProfile := os.Getenv("OBSERVE_PROFILE")
@@ -72,31 +76,77 @@ func ParseFlags() {
 	}
 }
 
-func InitConfigFromFileAndFlags(cfg *Config, op *DefaultOutput) {
+// InitConfigFromFileAndFlags builds the config from, in increasing order of
+// precedence, the profile in the user config file, the project config file,
+// the environment, and the command line flags. It returns where each value
+// that was set came from.
+func InitConfigFromFileAndFlags(cfg *Config, op *DefaultOutput) ConfigSources {
+	sources := ConfigSources{}
+	// A project config file, found by looking upward from the current
+	// directory, may choose the profile, and layers over that profile.
+	var proj *ProjectConfig
+	var projPath string
+	if wd, err := os.Getwd(); err == nil {
+		if projPath = FindProjectConfigPath(newFs(), wd); projPath != "" {
+			RunRecoverWithTag("read project config", op, func(Output) error {
+				proj, err = ReadProjectConfig(newFs(), projPath)
+				return err
+			})
+		}
+	}
+	if proj != nil && proj.Profile != "" && !pflag.Lookup("profile").Changed && os.Getenv("OBSERVE_PROFILE") == "" {
+		*FlagProfile = proj.Profile
+	}
 	if *FlagProfile != "" {
 		RunRecoverWithTag("read config", op, func(Output) error {
-			return ReadConfig(cfg, GetConfigFilePath(), *FlagProfile, false)
+			var prof Config
+			if err := ReadConfig(&prof, GetConfigFilePath(), *FlagProfile, false); err != nil {
+				return err
+			}
+			sources.set(mergeConfig(cfg, &prof), fmt.Sprintf("profile %q in %s", *FlagProfile, GetConfigFilePath()))
+			return nil
 		})
 	}
+	if proj != nil {
+		sources.set(mergeConfig(cfg, &proj.Config), "project "+projPath)
+	}
+	RunRecoverWithTag("read environment", op, func(Output) error {
+		set, err := ReadConfigFromEnv(cfg, os.Getenv)
+		for _, n := range set {
+			sources[n] = "env " + ConfigEnvName(n)
+		}
+		return err
+	})
 	if *FlagCustomerId != "" {
 		cfg.CustomerIdStr = *FlagCustomerId
+		sources["customerid"] = "flag --customerid"
 	}
 	if *FlagSiteStr != "" {
 		cfg.SiteStr = *FlagSiteStr
+		sources["site"] = "flag --site"
 	}
 	if *FlagAuthtokenStr != "" {
 		cfg.AuthtokenStr = *FlagAuthtokenStr
+		sources["authtoken"] = "flag --authtoken"
 	}
 	if pflag.Lookup("quiet").Changed || *FlagQuiet {
 		cfg.Quiet = *FlagQuiet
+		sources["quiet"] = "flag --quiet"
 	}
 	if pflag.Lookup("debug").Changed || *FlagDebug {
 		cfg.Debug = *FlagDebug
+		sources["debug"] = "flag --debug"
 	}
 	if pflag.Lookup("workspace").Changed || *FlagWorkspace != "" {
 		cfg.WorkspaceIdOrName = *FlagWorkspace
+		sources["workspace"] = "flag --workspace"
+	}
+	if *FlagRefresh {
+		cfg.CacheRefresh = true
+		sources["refresh"] = "flag --refresh"
 	}
 	*op = DefaultOutput{EnableDebug: cfg.Debug, DisableInfo: cfg.Quiet, DataOutput: os.Stdout}
+	return sources
 }
 
 func SendOutputToFile(path string, op *DefaultOutput) func() {
@@ -157,17 +207,35 @@ func RunCommandWithConfig(cfg *Config, fs fileSystem, op Output, args []string,
 		os.Stderr.WriteString("\n")
 		help()
 	}
-	RunRecoverWithTag(cmd.Name, op, func(o Output) error {
+	path := cmd.Name
+	for len(args) > 1 {
+		sub := cmd.FindSubcommand(args[1])
+		if sub == nil {
+			break
+		}
+		path += " " + sub.Name
+		cmd = sub
+		args = args[1:]
+	}
+	RunRecoverWithTag(path, op, func(o Output) error {
+		if cmd.Func == nil {
+			return subcommandUsage(path, cmd)
+		}
 		if cmd.Flags != nil {
 			if err := cmd.Flags.Parse(args[1:]); err != nil {
-				fmt.Fprintf(os.Stderr, "%s: %s\n", cmd.Name, err)
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
 				fmt.Fprintf(os.Stderr, "%s", WrapPrefix(cmd.Help, "   ", 75))
 				if !strings.Contains(err.Error(), "pflag: help requested") {
 					cmd.Flags.PrintDefaults()
 				}
 				OsExit(2)
 			}
+			// flag sets live on between runs in unit tests
+			defer resetFlags(cmd.Flags)
 			args = append([]string{args[0]}, cmd.Flags.Args()...)
+			if f := cmd.Flags.Lookup("workspace"); cmd.WorkspaceScoped && f.Changed {
+				cfg.WorkspaceIdOrName = f.Value.String()
+			}
 		}
 		return cmd.Func(FuncArgs{cfg, fs, o, args, hc})
 	})
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -17,6 +18,8 @@ type fileSystem interface {
 	Remove(path string) error
 	Rename(oldPath, newPath string) error
 	MkdirAll(path string, perm fs.FileMode) error
+	ReadDir(path string) ([]fs.DirEntry, error)
+	RemoveAll(path string) error
 }
 
 type Fs struct{}
@@ -49,24 +52,20 @@ func (f Fs) MkdirAll(path string, perm fs.FileMode) error {
 	return os.MkdirAll(path, perm)
 }
 
-type workspaceObject struct {
-	Id          int64
-	Name        string
-	Description *string
-	IconUrl     *string
-	WorkspaceId int64
-	ManagedById *int64
+func (f Fs) ReadDir(path string) ([]fs.DirEntry, error) {
+	return os.ReadDir(path)
 }
 
-type folderObject struct {
-	FolderId int64
+func (f Fs) RemoveAll(path string) error {
+	return os.RemoveAll(path)
 }
 
+// Of the audit fields, only the updated date is a property.
 type auditedObject struct {
 	CreatedBy   int64
 	CreatedDate string
 	UpdatedBy   int64
-	UpdatedDate string
+	UpdatedDate string `observe:"updatedDate,computed"`
 }
 
 type InputObject struct {
@@ -114,3 +113,88 @@ func parseInput(fs fileSystem, op Output, flagInput string) (*InputObject, error
 	}
 	return &content, nil
 }
+
+var ErrDefinitionWrongType = ObserveError{Msg: "the definition is for a different object type"}
+var ErrDefinitionNoConfig = ObserveError{Msg: "the definition has no config section"}
+var ErrNotAConfigProperty = ObserveError{Msg: "not a configurable property"}
+
+// definitionConfig returns the config properties of a --definition, which is
+// in the same format that get prints.
+func definitionConfig(ot ObjectType, in *InputObject) (object, error) {
+	if t, has := in.Object["type"]; has && t != ot.TypeName() {
+		return nil, NewObserveError(ErrDefinitionWrongType, "%v", t)
+	}
+	cfg, is := in.Object["config"].(object)
+	if !is {
+		return nil, ErrDefinitionNoConfig
+	}
+	return cfg, nil
+}
+
+// gqlInput converts config properties into a GraphQL input object. The
+// fields map each property that can be set to its field in the input object.
+func gqlInput(ot ObjectType, config object, fields map[string]string) (object, error) {
+	ret := object{}
+	for k, v := range config {
+		field, has := fields[k]
+		if !has {
+			return nil, NewObserveError(ErrNotAConfigProperty, "%s %q", ot.TypeName(), k)
+		}
+		gv, err := toGqlValue(getpropdesc(ot, k).Type, v)
+		if err != nil {
+			return nil, NewObserveError(err, "property %q", k)
+		}
+		ret[field] = gv
+	}
+	return ret, nil
+}
+
+// gqlInputFromInstance is the GraphQL input object that would re-create obj.
+// Updates start from it, because the update mutations replace the whole
+// input rather than patching it.
+func gqlInputFromInstance(obj ObjectInstance, fields map[string]string) object {
+	ret := object{}
+	for _, v := range obj.GetValues() {
+		p := v.GetDesc()
+		if field, has := fields[p.Name]; has {
+			if gv, err := toGqlValue(p.Type, v.GetValue()); err == nil && gv != nil {
+				ret[field] = gv
+			}
+		}
+	}
+	return ret
+}
+
+// toGqlValue converts a property value, as read from YAML or JSON, into what
+// the GraphQL API takes. Integer IDs are sent as strings, like they're
+// received.
+func toGqlValue(pt PropertyType, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch pt {
+	case PropertyTypeInteger:
+		switch x := v.(type) {
+		case int:
+			return strconv.Itoa(x), nil
+		case int64:
+			return strconv.FormatInt(x, 10), nil
+		case string:
+			if _, err := pt.FromString(x); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+		return nil, ErrIsNotInteger
+	case PropertyTypeBoolean:
+		if b, is := v.(bool); is {
+			return b, nil
+		}
+		return nil, ErrIsNotBoolean
+	default:
+		if s, is := v.(string); is {
+			return s, nil
+		}
+		return nil, ErrIsNotString
+	}
+}
@@ -133,3 +133,17 @@ func TestParseInput(t *testing.T) {
 		})
 	}
 }
+
+func TestGqlInput(t *testing.T) {
+	in, err := gqlInput(ObjectTypeRbacgroupmember, object{"groupid": "o::101:rbacgroup:1", "memberuserid": 42}, inputFieldsRbacgroupmember)
+	assert.NoError(t, err)
+	assert.Equal(t, object{"groupId": "o::101:rbacgroup:1", "memberUserId": "42"}, in)
+
+	_, err = gqlInput(ObjectTypeRbacgroupmember, object{"memberuserid": "fortytwo"}, inputFieldsRbacgroupmember)
+	assert.ErrorIs(t, err, ErrIsNotInteger)
+	_, err = gqlInput(ObjectTypeRbacgroupmember, object{"id": "o::101:rbacgroupmember:1"}, inputFieldsRbacgroupmember)
+	assert.ErrorIs(t, err, ErrNotAConfigProperty)
+
+	cur := &objectRbacgroup{Id: "o::101:rbacgroup:1", Name: "readers", Description: "group"}
+	assert.Equal(t, object{"name": "readers", "description": "group"}, gqlInputFromInstance(cur, inputFieldsRbacgroup))
+}
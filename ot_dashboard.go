@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RegisterObjectType(ObjectTypeDashboard, &objectDashboard{})
+}
+
+// The layout, stages, and parameters of a dashboard are passed through as
+// they come from GraphQL; they're too deep to be properties. get prints them
+// after the properties, and create and update take them back.
+type objectDashboard struct {
+	Id          int64   `observe:"id,id"`
+	Name        string  `observe:"name"`
+	WorkspaceId int64   `observe:"workspaceId"`
+	FolderId    int64   `observe:"folderId"`
+	Description *string `observe:"description"`
+	CreatedBy   *int64  `observe:"createdBy,computed"`
+	layout      any
+	stages      any
+	parameters  any
+	// workspace.path of the datasets the dashboard reads, by ID, so that an
+	// import into another tenant can find the same datasets there
+	datasets map[string]string
+}
+
+var _ ObjectInstance = &objectDashboard{}
+
+// dashboardParts are the parts of the config that aren't properties.
+var dashboardParts = []string{"layout", "stages", "parameters"}
+
+// dashboardDatasetKeys are the keys, anywhere in the parts, whose values are
+// dataset IDs.
+var dashboardDatasetKeys = []string{"datasetId", "keyForDatasetId"}
+
+func (o *objectDashboard) GetInfo() *ObjectInfo {
+	return &ObjectInfo{
+		Id:           strconv.FormatInt(o.Id, 10),
+		Name:         o.Name,
+		Presentation: []string{strconv.FormatInt(o.Id, 10), o.Name, strconv.FormatInt(o.WorkspaceId, 10)},
+		Object:       o,
+	}
+}
+
+func (o *objectDashboard) GetValues() []PropertyInstance {
+	props := ObjectTypeDashboard.GetProperties()
+	r := make([]PropertyInstance, len(props))
+	for i, p := range props {
+		r[i] = &propertyInstance{p, o}
+	}
+	return r
+}
+
+func (o *objectDashboard) parts() object {
+	return object{"layout": o.layout, "stages": o.stages, "parameters": o.parameters}
+}
+
+func (o *objectDashboard) PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error {
+	err := printToYamlWithConfig(op, otyp, obj, func() error {
+		parts := o.parts()
+		for _, k := range dashboardParts {
+			if err := printYamlIndented(op, "    ", object{k: parts[k]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(o.datasets) > 0 {
+		return printYamlIndented(op, "  ", object{"datasets": o.datasets})
+	}
+	return nil
+}
+
+// printYamlIndented prints v as YAML, with each line indented, to nest it in
+// what printToYamlFromObjectInstance prints.
+func printYamlIndented(op Output, indent string, v any) error {
+	buf := bytes.NewBuffer(nil)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		fmt.Fprintf(op, "%s%s\n", indent, line)
+	}
+	return nil
+}
+
+type objectTypeDashboard struct{}
+
+var ObjectTypeDashboard ObjectType = &objectTypeDashboard{}
+
+func (*objectTypeDashboard) TypeName() string { return "dashboard" }
+func (*objectTypeDashboard) Help() string {
+	return "A dashboard lays out the results of OPAL stages as charts and tables."
+}
+func (*objectTypeDashboard) CanList() bool   { return true }
+func (*objectTypeDashboard) CanGet() bool    { return true }
+func (*objectTypeDashboard) CanCreate() bool { return true }
+func (*objectTypeDashboard) CanUpdate() bool { return true }
+func (*objectTypeDashboard) CanDelete() bool { return false }
+func (*objectTypeDashboard) GetPresentationLabels() []string {
+	return []string{"id", "name", "workspaceId"}
+}
+func (ot *objectTypeDashboard) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+const gqlDashboardFields = `id name workspaceId folderId description createdBy layout ` +
+	`stages { id pipeline layout input { inputName inputRole datasetId datasetPath stageId } } ` +
+	`parameters { id name defaultValue valueKind { type keyForDatasetId } }`
+
+func unpackDashboard(rsp object) *objectDashboard {
+	o := &objectDashboard{}
+	o.layout, o.stages, o.parameters = rsp["layout"], rsp["stages"], rsp["parameters"]
+	for _, k := range dashboardParts {
+		delete(rsp, k)
+	}
+	unpackObject(rsp, o, ObjectTypeDashboard.TypeName())
+	return o
+}
+
+// walkDatasetIds calls fn for each dataset ID in v, and sets the ID to what
+// it returns.
+func walkDatasetIds(v any, fn func(id string) string) {
+	switch x := v.(type) {
+	case object:
+		for k, child := range x {
+			if slices.Contains(dashboardDatasetKeys, k) && child != nil {
+				switch id := child.(type) {
+				case string:
+					x[k] = fn(id)
+				case int:
+					x[k], _ = strconv.ParseInt(fn(strconv.Itoa(id)), 10, 64)
+				case int64:
+					x[k], _ = strconv.ParseInt(fn(strconv.FormatInt(id, 10)), 10, 64)
+				}
+				continue
+			}
+			walkDatasetIds(child, fn)
+		}
+	case array:
+		for _, child := range x {
+			walkDatasetIds(child, fn)
+		}
+	}
+}
+
+// dashboardDatasetIds returns the dataset IDs the parts refer to, sorted.
+func dashboardDatasetIds(parts object) []string {
+	ids := map[string]bool{}
+	walkDatasetIds(parts, func(id string) string {
+		ids[id] = true
+		return id
+	})
+	return sorted(maps.Keys(ids))
+}
+
+var gqlListDashboard = compileGqlQuery(`query Dashboard_List { dashboardSearch(terms: {}) { dashboards { dashboard { id name workspaceId folderId } } } }`, "data", "dashboardSearch", "dashboards")
+
+func (ot *objectTypeDashboard) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListDashboard.query(cfg, op, hc, object{})
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	var ret []*ObjectInfo
+	for _, d := range obj.(array) {
+		if dashboard, found := d.(object)["dashboard"]; found {
+			d = dashboard
+		}
+		ret = append(ret, unpackDashboard(d.(object)).GetInfo())
+	}
+	return ret, nil
+}
+
+var gqlGetDashboard = compileGqlQuery(`query Dashboard_Get_Id($id: ObjectId!) { dashboard(id: $id) { `+gqlDashboardFields+` } }`, "data", "dashboard")
+
+func (ot *objectTypeDashboard) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
+	obj, err := gqlGetDashboard.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	o := unpackDashboard(obj.(object))
+	if ids := dashboardDatasetIds(o.parts()); len(ids) > 0 {
+		paths, err := datasetPaths(cfg, nil, op, hc)
+		if err != nil {
+			return nil, err
+		}
+		o.datasets = map[string]string{}
+		for _, id := range ids {
+			if p, has := paths[id]; has {
+				o.datasets[id] = p
+			}
+		}
+	}
+	return o, nil
+}
+
+// properties that go in DashboardInput, besides the parts
+var inputFieldsDashboard = map[string]string{
+	"name":        "name",
+	"workspaceId": "workspaceId",
+	"folderId":    "folderId",
+	"description": "description",
+}
+
+// dashboardInput splits the parts from the properties of the config, which
+// are checked and converted as usual.
+func dashboardInput(ot ObjectType, config object) (object, error) {
+	props := object{}
+	parts := object{}
+	for k, v := range config {
+		if slices.Contains(dashboardParts, k) {
+			parts[k] = v
+		} else {
+			props[k] = v
+		}
+	}
+	in, err := gqlInput(ot, props, inputFieldsDashboard)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range parts {
+		in[k] = v
+	}
+	return in, nil
+}
+
+var gqlSaveDashboard = compileGqlQuery(`mutation Dashboard_Save($input: DashboardInput!) { saveDashboard(dash: $input) { `+gqlDashboardFields+` } }`, "data", "saveDashboard")
+
+func (ot *objectTypeDashboard) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	in, err := dashboardInput(ot, input)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := gqlSaveDashboard.query(cfg, op, hc, object{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackDashboard(obj.(object)), nil
+}
+
+func (ot *objectTypeDashboard) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
+	cur, err := ot.Get(cfg, op, hc, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil {
+		return nil, NewObserveError(nil, "dashboard %s not found", id)
+	}
+	in, err := dashboardInput(ot, input)
+	if err != nil {
+		return nil, err
+	}
+	merged := gqlInputFromInstance(cur, inputFieldsDashboard)
+	for k, v := range cur.(*objectDashboard).parts() {
+		merged[k] = v
+	}
+	for k, v := range in {
+		merged[k] = v
+	}
+	merged["id"] = id
+	obj, err := gqlSaveDashboard.query(cfg, op, hc, object{"input": merged})
+	if err != nil {
+		return nil, err
+	}
+	return unpackDashboard(obj.(object)), nil
+}
+
+func (ot *objectTypeDashboard) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	return nil
+}
@@ -9,20 +9,22 @@ func init() {
 }
 
 type objectDataset struct {
-	workspaceObject
-	folderObject
-	objectDatasetBase
-}
-
-type objectDatasetBase struct {
-	Path           string
-	Kind           string
-	ValidFromField *string
-	ValidToField   *string
-	LabelField     *string
-	Version        string
-	UpdatedDate    string
-	PathCost       *int64
+	Id             int64   `observe:"id,id"`
+	Path           string  `observe:"path,computed"`
+	WorkspaceId    int64   `observe:"workspaceId"`
+	FolderId       int64   `observe:"folderId"`
+	Name           string  `observe:"name"`
+	Kind           string  `observe:"kind,computed"`
+	Description    *string `observe:"description"`
+	ManagedById    *int64  `observe:"managedById,computed"`
+	PathCost       *int64  `observe:"pathCost"`
+	ValidFromField *string `observe:"validFromField,computed"`
+	ValidToField   *string `observe:"validToField,computed"`
+	LabelField     *string `observe:"labelField,computed"`
+	IconUrl        *string `observe:"iconUrl"`
+	Version        string  `observe:"version,computed"`
+	UpdatedDate    string  `observe:"updatedDate,computed"`
+	CreatedBy      *int64  `observe:"createdBy,computed"`
 	// todo: compound property types
 	// CompilationError *CompilationError
 	// PrimaryKey []string
@@ -60,91 +62,22 @@ type objectTypeDataset struct{}
 
 var ObjectTypeDataset ObjectType = &objectTypeDataset{}
 
-// TODO: We should extract the input/output structs from GQL schema and use
-// guided codegen to generate these object type definitions.
-var propertyDescDataset = []PropertyDesc{
-	{"id", PropertyTypeInteger, false, true, func(o any) any { return o.(*objectDataset).Id }, func(o any, v any) { o.(*objectDataset).Id = v.(int64) }},
-	{"path", PropertyTypeString, true, false, func(o any) any { return o.(*objectDataset).Path }, func(o any, v any) { o.(*objectDataset).Path = v.(string) }},
-	{"workspaceId", PropertyTypeInteger, false, false, func(o any) any { return o.(*objectDataset).WorkspaceId }, func(o any, v any) { o.(*objectDataset).WorkspaceId = v.(int64) }},
-	{"folderId", PropertyTypeInteger, false, false, func(o any) any { return o.(*objectDataset).FolderId }, func(o any, v any) { o.(*objectDataset).FolderId = v.(int64) }},
-	{"name", PropertyTypeString, false, false, func(o any) any { return o.(*objectDataset).Name }, func(o any, v any) { o.(*objectDataset).Name = v.(string) }},
-	{"kind", PropertyTypeString, true, false, func(o any) any { return o.(*objectDataset).Kind }, func(o any, v any) { o.(*objectDataset).Kind = v.(string) }},
-	{"description", PropertyTypeString, false, false, func(o any) any { return maybe(o.(*objectDataset).Description) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectDataset).Description = vp
-	}},
-	{"managedById", PropertyTypeInteger, true, false, func(o any) any { return maybe(o.(*objectDataset).ManagedById) }, func(o any, v any) {
-		var vp *int64
-		if v != nil {
-			vs := v.(int64)
-			vp = &vs
-		}
-		o.(*objectDataset).ManagedById = vp
-	}},
-	{"pathCost", PropertyTypeInteger, false, false, func(o any) any { return maybe(o.(*objectDataset).PathCost) }, func(o any, v any) {
-		var vp *int64
-		if v != nil {
-			vs := v.(int64)
-			vp = &vs
-		}
-		o.(*objectDataset).PathCost = vp
-	}},
-	{"validFromField", PropertyTypeString, true, false, func(o any) any { return maybe(o.(*objectDataset).ValidFromField) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectDataset).ValidFromField = vp
-	}},
-	{"validToField", PropertyTypeString, true, false, func(o any) any { return maybe(o.(*objectDataset).ValidToField) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectDataset).ValidToField = vp
-	}},
-	{"labelField", PropertyTypeString, true, false, func(o any) any { return maybe(o.(*objectDataset).LabelField) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectDataset).LabelField = vp
-	}},
-	{"iconUrl", PropertyTypeString, false, false, func(o any) any { return maybe(o.(*objectDataset).IconUrl) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectDataset).IconUrl = vp
-	}},
-	{"version", PropertyTypeString, true, false, func(o any) any { return o.(*objectDataset).Version }, func(o any, v any) { o.(*objectDataset).Version = v.(string) }},
-	{"updatedDate", PropertyTypeString, true, false, func(o any) any { return o.(*objectDataset).UpdatedDate }, func(o any, v any) { o.(*objectDataset).UpdatedDate = v.(string) }},
-}
-
 func (*objectTypeDataset) TypeName() string { return "dataset" }
 func (*objectTypeDataset) Help() string {
 	return "A dataset contains processed data ready to be queried."
 }
-func (*objectTypeDataset) CanList() bool                   { return true }
-func (*objectTypeDataset) CanGet() bool                    { return true }
-func (*objectTypeDataset) CanCreate() bool                 { return false }
-func (*objectTypeDataset) CanUpdate() bool                 { return false }
-func (*objectTypeDataset) CanDelete() bool                 { return false }
-func (*objectTypeDataset) GetPresentationLabels() []string { return []string{"id", "path"} }
-func (*objectTypeDataset) GetProperties() []PropertyDesc   { return propertyDescDataset }
-
-var gqlListDataset = compileGqlQuery(`query Dataset_List { datasetSearch { dataset { id name path } } }`, "data", "datasetSearch")
-
-func (ot *objectTypeDataset) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
-	obj, err := gqlListDataset.query(cfg, op, hc, object{})
+func (*objectTypeDataset) CanList() bool                    { return true }
+func (*objectTypeDataset) CanGet() bool                     { return true }
+func (*objectTypeDataset) CanCreate() bool                  { return false }
+func (*objectTypeDataset) CanUpdate() bool                  { return false }
+func (*objectTypeDataset) CanDelete() bool                  { return false }
+func (*objectTypeDataset) GetPresentationLabels() []string  { return []string{"id", "path"} }
+func (ot *objectTypeDataset) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+var gqlListDataset = compileGqlQuery(`query Dataset_List { datasetSearch { dataset { id name path workspaceId folderId } } }`, "data", "datasetSearch").WithCache()
+
+func (ot *objectTypeDataset) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListDataset.queryCached(cfg, fs, op, hc, object{})
 	if err != nil || obj == nil {
 		return nil, err
 	}
@@ -160,7 +93,30 @@ func (ot *objectTypeDataset) List(cfg *Config, op Output, hc httpClient) ([]*Obj
 	return ret, nil
 }
 
-var gqlGetDataset = compileGqlQuery(`query Dataset_Get_Id($id: ObjectId!) { dataset(id: $id) { id name:label workspace:workspaceId path kind description validFromField validToField labelField iconUrl version updatedDate pathCost managedById folderId } }`, "data", "dataset")
+// datasetPaths returns the workspace.path of every dataset, by ID, which
+// names the same dataset in another tenant.
+func datasetPaths(cfg *Config, fs fileSystem, op Output, hc httpClient) (map[string]string, error) {
+	workspaces, err := ObjectTypeWorkspace.List(cfg, fs, op, hc)
+	if err != nil {
+		return nil, NewObserveError(err, "list workspaces")
+	}
+	wsNames := map[string]string{}
+	for _, ws := range workspaces {
+		wsNames[ws.Id] = ws.Name
+	}
+	datasets, err := ObjectTypeDataset.List(cfg, fs, op, hc)
+	if err != nil {
+		return nil, NewObserveError(err, "list datasets")
+	}
+	ret := map[string]string{}
+	for _, ds := range datasets {
+		d := ds.Object.(*objectDataset)
+		ret[ds.Id] = wsNames[strconv.FormatInt(d.WorkspaceId, 10)] + "." + d.Path
+	}
+	return ret, nil
+}
+
+var gqlGetDataset = compileGqlQuery(`query Dataset_Get_Id($id: ObjectId!) { dataset(id: $id) { id name:label workspaceId path kind description validFromField validToField labelField iconUrl version updatedDate pathCost managedById folderId createdBy } }`, "data", "dataset")
 
 func (ot *objectTypeDataset) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
 	obj, err := gqlGetDataset.query(cfg, op, hc, object{"id": id})
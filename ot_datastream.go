@@ -0,0 +1,166 @@
+package main
+
+import (
+	"strconv"
+)
+
+func init() {
+	RegisterObjectType(ObjectTypeDatastream, &objectDatastream{})
+}
+
+type objectDatastream struct {
+	Id          int64   `observe:"id,id"`
+	Name        string  `observe:"name"`
+	WorkspaceId int64   `observe:"workspaceId"`
+	Description *string `observe:"description"`
+	Disabled    bool    `observe:"disabled"`
+}
+
+var _ ObjectInstance = &objectDatastream{}
+
+func (o *objectDatastream) GetInfo() *ObjectInfo {
+	return &ObjectInfo{
+		Id:           strconv.FormatInt(o.Id, 10),
+		Name:         o.Name,
+		Presentation: []string{strconv.FormatInt(o.Id, 10), o.Name, strconv.FormatInt(o.WorkspaceId, 10), strconv.FormatBool(o.Disabled)},
+		Object:       o,
+	}
+}
+
+func (o *objectDatastream) GetValues() []PropertyInstance {
+	props := ObjectTypeDatastream.GetProperties()
+	r := make([]PropertyInstance, len(props))
+	for i, p := range props {
+		r[i] = &propertyInstance{p, o}
+	}
+	return r
+}
+
+func (o *objectDatastream) PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error {
+	return printToYamlFromObjectInstance(op, otyp, obj)
+}
+
+type objectTypeDatastream struct{}
+
+var ObjectTypeDatastream ObjectType = &objectTypeDatastream{}
+
+func (*objectTypeDatastream) TypeName() string { return "datastream" }
+func (*objectTypeDatastream) Help() string {
+	return "A datastream receives data sent with its tokens, and feeds it into a dataset."
+}
+func (*objectTypeDatastream) CanList() bool   { return true }
+func (*objectTypeDatastream) CanGet() bool    { return true }
+func (*objectTypeDatastream) CanCreate() bool { return true }
+func (*objectTypeDatastream) CanUpdate() bool { return true }
+func (*objectTypeDatastream) CanDelete() bool { return true }
+func (*objectTypeDatastream) GetPresentationLabels() []string {
+	return []string{"id", "name", "workspaceId", "disabled"}
+}
+func (ot *objectTypeDatastream) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+const gqlDatastreamFields = `id name workspaceId description disabled`
+
+var gqlListDatastream = compileGqlQuery(`query Datastream_List { datastreams { `+gqlDatastreamFields+` } }`, "data", "datastreams")
+
+func (ot *objectTypeDatastream) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListDatastream.query(cfg, op, hc, object{})
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	var ret []*ObjectInfo
+	for _, ds := range obj.(array) {
+		o := unpackObject(ds.(object), &objectDatastream{}, ot.TypeName())
+		ret = append(ret, o.GetInfo())
+	}
+	return ret, nil
+}
+
+var gqlGetDatastream = compileGqlQuery(`query Datastream_Get_Id($id: ObjectId!) { datastream(id: $id) { `+gqlDatastreamFields+` } }`, "data", "datastream")
+
+func (ot *objectTypeDatastream) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
+	obj, err := gqlGetDatastream.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return unpackObject(obj.(object), &objectDatastream{}, ot.TypeName()), nil
+}
+
+// properties that go in DatastreamInput; the workspace is an argument of
+// its own, and can't be changed
+var inputFieldsDatastream = map[string]string{
+	"name":        "name",
+	"description": "description",
+	"disabled":    "disabled",
+}
+
+var gqlCreateDatastream = compileGqlQuery(`mutation Datastream_Create($workspaceId: ObjectId!, $input: DatastreamInput!) { createDatastream(workspaceId: $workspaceId, datastream: $input) { `+gqlDatastreamFields+` } }`, "data", "createDatastream")
+
+// Create makes a datastream in the configured workspace, unless the input
+// says otherwise.
+func (ot *objectTypeDatastream) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	wsId, has := input["workspaceId"]
+	if !has {
+		ws, err := ResolveWorkspace(cfg, nil, op, hc)
+		if err != nil {
+			return nil, err
+		}
+		wsId = ws.Id
+	}
+	props := object{}
+	for k, v := range input {
+		if k != "workspaceId" {
+			props[k] = v
+		}
+	}
+	in, err := gqlInput(ot, props, inputFieldsDatastream)
+	if err != nil {
+		return nil, err
+	}
+	gqlWsId, err := toGqlValue(PropertyTypeInteger, wsId)
+	if err != nil {
+		return nil, NewObserveError(err, "property %q", "workspaceId")
+	}
+	obj, err := gqlCreateDatastream.query(cfg, op, hc, object{"workspaceId": gqlWsId, "input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectDatastream{}, ot.TypeName()), nil
+}
+
+var gqlUpdateDatastream = compileGqlQuery(`mutation Datastream_Update($id: ObjectId!, $input: DatastreamInput!) { updateDatastream(id: $id, datastream: $input) { `+gqlDatastreamFields+` } }`, "data", "updateDatastream")
+
+func (ot *objectTypeDatastream) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
+	in, err := gqlInput(ot, input, inputFieldsDatastream)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := ot.Get(cfg, op, hc, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil {
+		return nil, NewObserveError(nil, "datastream %s not found", id)
+	}
+	merged := gqlInputFromInstance(cur, inputFieldsDatastream)
+	for k, v := range in {
+		merged[k] = v
+	}
+	obj, err := gqlUpdateDatastream.query(cfg, op, hc, object{"id": id, "input": merged})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectDatastream{}, ot.TypeName()), nil
+}
+
+var gqlDeleteDatastream = compileGqlQuery(`mutation Datastream_Delete($id: ObjectId!) { deleteDatastream(id: $id) { success errorMessage } }`, "data", "deleteDatastream")
+
+func (ot *objectTypeDatastream) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	obj, err := gqlDeleteDatastream.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return err
+	}
+	return resultStatus(obj)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strconv"
+)
+
+var ErrDatastreamTokenNoDatastream = ObserveError{Msg: "a datastream token needs the datastreamId of its datastream"}
+
+func init() {
+	RegisterObjectType(ObjectTypeDatastreamtoken, &objectDatastreamtoken{})
+}
+
+// The secret of a token is only returned when the token is created; there is
+// no getting it back later.
+type objectDatastreamtoken struct {
+	Id           string  `observe:"id,id"`
+	Name         string  `observe:"name"`
+	DatastreamId int64   `observe:"datastreamId"`
+	Description  *string `observe:"description"`
+	Disabled     bool    `observe:"disabled"`
+	Secret       *string `observe:"secret,computed"`
+}
+
+var _ ObjectInstance = &objectDatastreamtoken{}
+
+func (o *objectDatastreamtoken) GetInfo() *ObjectInfo {
+	return &ObjectInfo{
+		Id:           o.Id,
+		Name:         o.Name,
+		Presentation: []string{o.Id, o.Name, strconv.FormatInt(o.DatastreamId, 10), strconv.FormatBool(o.Disabled)},
+		Object:       o,
+	}
+}
+
+func (o *objectDatastreamtoken) GetValues() []PropertyInstance {
+	props := ObjectTypeDatastreamtoken.GetProperties()
+	r := make([]PropertyInstance, len(props))
+	for i, p := range props {
+		r[i] = &propertyInstance{p, o}
+	}
+	return r
+}
+
+func (o *objectDatastreamtoken) PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error {
+	return printToYamlFromObjectInstance(op, otyp, obj)
+}
+
+type objectTypeDatastreamtoken struct{}
+
+var ObjectTypeDatastreamtoken ObjectType = &objectTypeDatastreamtoken{}
+
+func (*objectTypeDatastreamtoken) TypeName() string { return "datastreamtoken" }
+func (*objectTypeDatastreamtoken) Help() string {
+	return "A datastream token is the credential collectors use to send data to a datastream."
+}
+func (*objectTypeDatastreamtoken) CanList() bool   { return true }
+func (*objectTypeDatastreamtoken) CanGet() bool    { return true }
+func (*objectTypeDatastreamtoken) CanCreate() bool { return true }
+func (*objectTypeDatastreamtoken) CanUpdate() bool { return true }
+func (*objectTypeDatastreamtoken) CanDelete() bool { return true }
+func (*objectTypeDatastreamtoken) GetPresentationLabels() []string {
+	return []string{"id", "name", "datastreamId", "disabled"}
+}
+func (ot *objectTypeDatastreamtoken) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+const gqlDatastreamtokenFields = `id name datastreamId description disabled`
+
+var gqlListDatastreamtoken = compileGqlQuery(`query Datastreamtoken_List { datastreamTokens { `+gqlDatastreamtokenFields+` } }`, "data", "datastreamTokens")
+
+func (ot *objectTypeDatastreamtoken) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListDatastreamtoken.query(cfg, op, hc, object{})
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	var ret []*ObjectInfo
+	for _, t := range obj.(array) {
+		o := unpackObject(t.(object), &objectDatastreamtoken{}, ot.TypeName())
+		ret = append(ret, o.GetInfo())
+	}
+	return ret, nil
+}
+
+var gqlGetDatastreamtoken = compileGqlQuery(`query Datastreamtoken_Get_Id($id: String!) { datastreamToken(id: $id) { `+gqlDatastreamtokenFields+` } }`, "data", "datastreamToken")
+
+func (ot *objectTypeDatastreamtoken) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
+	obj, err := gqlGetDatastreamtoken.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return unpackObject(obj.(object), &objectDatastreamtoken{}, ot.TypeName()), nil
+}
+
+// properties that go in DatastreamTokenInput; the datastream is an argument
+// of its own, and can't be changed
+var inputFieldsDatastreamtoken = map[string]string{
+	"name":        "name",
+	"description": "description",
+	"disabled":    "disabled",
+}
+
+var gqlCreateDatastreamtoken = compileGqlQuery(`mutation Datastreamtoken_Create($datastreamId: ObjectId!, $input: DatastreamTokenInput!) { createDatastreamToken(datastreamId: $datastreamId, token: $input) { `+gqlDatastreamtokenFields+` secret } }`, "data", "createDatastreamToken")
+
+func (ot *objectTypeDatastreamtoken) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	dsId, has := input["datastreamId"]
+	if !has {
+		return nil, ErrDatastreamTokenNoDatastream
+	}
+	props := object{}
+	for k, v := range input {
+		if k != "datastreamId" {
+			props[k] = v
+		}
+	}
+	in, err := gqlInput(ot, props, inputFieldsDatastreamtoken)
+	if err != nil {
+		return nil, err
+	}
+	gqlDsId, err := toGqlValue(PropertyTypeInteger, dsId)
+	if err != nil {
+		return nil, NewObserveError(err, "property %q", "datastreamId")
+	}
+	obj, err := gqlCreateDatastreamtoken.query(cfg, op, hc, object{"datastreamId": gqlDsId, "input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectDatastreamtoken{}, ot.TypeName()), nil
+}
+
+var gqlUpdateDatastreamtoken = compileGqlQuery(`mutation Datastreamtoken_Update($id: String!, $input: DatastreamTokenInput!) { updateDatastreamToken(id: $id, token: $input) { `+gqlDatastreamtokenFields+` } }`, "data", "updateDatastreamToken")
+
+func (ot *objectTypeDatastreamtoken) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
+	in, err := gqlInput(ot, input, inputFieldsDatastreamtoken)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := ot.Get(cfg, op, hc, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil {
+		return nil, NewObserveError(nil, "datastream token %s not found", id)
+	}
+	merged := gqlInputFromInstance(cur, inputFieldsDatastreamtoken)
+	for k, v := range in {
+		merged[k] = v
+	}
+	obj, err := gqlUpdateDatastreamtoken.query(cfg, op, hc, object{"id": id, "input": merged})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectDatastreamtoken{}, ot.TypeName()), nil
+}
+
+var gqlDeleteDatastreamtoken = compileGqlQuery(`mutation Datastreamtoken_Delete($id: String!) { deleteDatastreamToken(id: $id) { success errorMessage } }`, "data", "deleteDatastreamToken")
+
+func (ot *objectTypeDatastreamtoken) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	obj, err := gqlDeleteDatastreamtoken.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return err
+	}
+	return resultStatus(obj)
+}
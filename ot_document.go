@@ -16,18 +16,18 @@ type objectDocument struct {
 }
 
 type objectDocumentMeta struct {
-	Id string
+	Id string `observe:"id,id"`
 }
 
 type objectDocumentConfig struct {
-	Name  string
-	Usage string
+	Name  string `observe:"name"`
+	Usage string `observe:"usage"`
 }
 
 type objectDocumentState struct {
-	Mimetype string
-	Size     int64
-	Url      string
+	Mimetype string `observe:"mimetype,computed"`
+	Size     int64  `observe:"size,computed"`
+	Url      string `observe:"url,computed"`
 	auditedObject
 }
 
@@ -38,6 +38,12 @@ type documentKind interface {
 	SniffMimetype(data []byte) (string, error)
 }
 
+// A documentUploader is a kind of document that isn't stored as a document,
+// and that upload hands the file to instead.
+type documentUploader interface {
+	Upload(fa FuncArgs, name string, data []byte) error
+}
+
 var docTypes = map[string]documentKind{}
 
 func addDocumenKind(dk documentKind) {
@@ -72,16 +78,6 @@ type objectTypeDocument struct{}
 
 var ObjectTypeDocument ObjectType = &objectTypeDocument{}
 
-var propertyDescDocument = []PropertyDesc{
-	{"id", PropertyTypeString, false, true, func(o any) any { return o.(*objectDocument).Id }, func(o any, v any) { o.(*objectDocument).Id = v.(string) }},
-	{"name", PropertyTypeString, false, false, func(o any) any { return o.(*objectDocument).Name }, func(o any, v any) { o.(*objectDocument).Name = v.(string) }},
-	{"usage", PropertyTypeString, false, false, func(o any) any { return o.(*objectDocument).Usage }, func(o any, v any) { o.(*objectDocument).Usage = v.(string) }},
-	{"mimetype", PropertyTypeString, true, false, func(o any) any { return o.(*objectDocument).Mimetype }, func(o any, v any) { o.(*objectDocument).Mimetype = v.(string) }},
-	{"size", PropertyTypeInteger, true, false, func(o any) any { return o.(*objectDocument).Size }, func(o any, v any) { o.(*objectDocument).Size = v.(int64) }},
-	{"url", PropertyTypeString, true, false, func(o any) any { return o.(*objectDocument).Url }, func(o any, v any) { o.(*objectDocument).Url = v.(string) }},
-	{"updatedDate", PropertyTypeString, true, false, func(o any) any { return o.(*objectDocument).UpdatedDate }, func(o any, v any) { o.(*objectDocument).UpdatedDate = v.(string) }},
-}
-
 // Currently mapping from meta/config/state tuple, to flat.
 // But we really want the tuple to be the "real" format.
 var propertyMapDocument = PropertyMap{
@@ -94,17 +90,17 @@ var propertyMapDocument = PropertyMap{
 	"updatedDate": mkpath("state.updatedDate"),
 }
 
-func (*objectTypeDocument) TypeName() string                { return "document" }
-func (*objectTypeDocument) Help() string                    { return "an uploaded auxiliary document" }
-func (*objectTypeDocument) CanList() bool                   { return true }
-func (*objectTypeDocument) CanGet() bool                    { return true }
-func (*objectTypeDocument) CanCreate() bool                 { return false }
-func (*objectTypeDocument) CanUpdate() bool                 { return false }
-func (*objectTypeDocument) CanDelete() bool                 { return true }
-func (*objectTypeDocument) GetPresentationLabels() []string { return []string{"id", "usage", "name"} }
-func (*objectTypeDocument) GetProperties() []PropertyDesc   { return propertyDescDocument }
+func (*objectTypeDocument) TypeName() string                 { return "document" }
+func (*objectTypeDocument) Help() string                     { return "an uploaded auxiliary document" }
+func (*objectTypeDocument) CanList() bool                    { return true }
+func (*objectTypeDocument) CanGet() bool                     { return true }
+func (*objectTypeDocument) CanCreate() bool                  { return false }
+func (*objectTypeDocument) CanUpdate() bool                  { return false }
+func (*objectTypeDocument) CanDelete() bool                  { return true }
+func (*objectTypeDocument) GetPresentationLabels() []string  { return []string{"id", "usage", "name"} }
+func (ot *objectTypeDocument) GetProperties() []PropertyDesc { return taggedProperties(ot) }
 
-func (ot *objectTypeDocument) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
+func (ot *objectTypeDocument) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
 	cu, err := Query(hc).Config(cfg).Output(op).Path("/v1/document/").PropMap(propertyMapDocument).GetList()
 	if err != nil || cu == nil {
 		return nil, err
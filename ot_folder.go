@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+)
+
+func init() {
+	RegisterObjectType(ObjectTypeFolder, &objectFolder{})
+}
+
+type objectFolder struct {
+	Id          int64   `observe:"id,id"`
+	Name        string  `observe:"name"`
+	WorkspaceId int64   `observe:"workspaceId"`
+	Description *string `observe:"description"`
+}
+
+var _ ObjectInstance = &objectFolder{}
+
+func (o *objectFolder) GetInfo() *ObjectInfo {
+	return &ObjectInfo{
+		Id:           strconv.FormatInt(o.Id, 10),
+		Name:         o.Name,
+		Presentation: []string{strconv.FormatInt(o.Id, 10), o.Name, strconv.FormatInt(o.WorkspaceId, 10)},
+		Object:       o,
+	}
+}
+
+func (o *objectFolder) GetValues() []PropertyInstance {
+	props := ObjectTypeFolder.GetProperties()
+	r := make([]PropertyInstance, len(props))
+	for i, p := range props {
+		r[i] = &propertyInstance{p, o}
+	}
+	return r
+}
+
+func (o *objectFolder) PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error {
+	return printToYamlFromObjectInstance(op, otyp, obj)
+}
+
+type objectTypeFolder struct{}
+
+var ObjectTypeFolder ObjectType = &objectTypeFolder{}
+
+func (*objectTypeFolder) TypeName() string { return "folder" }
+func (*objectTypeFolder) Help() string {
+	return "A folder groups the datasets, worksheets, dashboards, and monitors of a workspace."
+}
+func (*objectTypeFolder) CanList() bool   { return true }
+func (*objectTypeFolder) CanGet() bool    { return true }
+func (*objectTypeFolder) CanCreate() bool { return false }
+func (*objectTypeFolder) CanUpdate() bool { return false }
+func (*objectTypeFolder) CanDelete() bool { return false }
+func (*objectTypeFolder) GetPresentationLabels() []string {
+	return []string{"id", "name", "workspaceId"}
+}
+func (ot *objectTypeFolder) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+var gqlListFolder = compileGqlQuery(`query Folder_List { folders { id name workspaceId description } }`, "data", "folders").WithCache()
+
+func (ot *objectTypeFolder) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListFolder.queryCached(cfg, fs, op, hc, object{})
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	var ret []*ObjectInfo
+	for _, f := range obj.(array) {
+		o := unpackObject(f.(object), &objectFolder{}, ot.TypeName())
+		ret = append(ret, o.GetInfo())
+	}
+	return ret, nil
+}
+
+var gqlGetFolder = compileGqlQuery(`query Folder_Get_Id($id: ObjectId!) { folder(id: $id) { id name workspaceId description } }`, "data", "folder")
+
+func (ot *objectTypeFolder) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
+	obj, err := gqlGetFolder.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return unpackObject(obj.(object), &objectFolder{}, ot.TypeName()), nil
+}
+
+func (ot *objectTypeFolder) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	return nil, nil
+}
+
+func (ot *objectTypeFolder) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
+	return nil, nil
+}
+
+func (ot *objectTypeFolder) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	return nil
+}
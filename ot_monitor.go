@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterObjectType(ObjectTypeMonitor, &objectMonitor{})
+}
+
+// The query, rule, and actions of a monitor are nested in GraphQL, and are
+// flattened into text here; see unpackMonitor.
+type objectMonitor struct {
+	Id          int64   `observe:"id,id"`
+	Name        string  `observe:"name"`
+	WorkspaceId int64   `observe:"workspaceId"`
+	FolderId    int64   `observe:"folderId"`
+	Description *string `observe:"description"`
+	Enabled     bool    `observe:"enabled"`
+	Query       string  `observe:"query"`
+	Threshold   string  `observe:"threshold"`
+	Actions     string  `observe:"actions"`
+	MutedUntil  *string `observe:"mutedUntil,computed"`
+	MuteReason  *string `observe:"muteReason,computed"`
+	CreatedBy   *int64  `observe:"createdBy,computed"`
+}
+
+var _ ObjectInstance = &objectMonitor{}
+
+func (o *objectMonitor) GetInfo() *ObjectInfo {
+	muted := ""
+	if o.MutedUntil != nil {
+		muted = *o.MutedUntil
+	}
+	return &ObjectInfo{
+		Id:           strconv.FormatInt(o.Id, 10),
+		Name:         o.Name,
+		Presentation: []string{strconv.FormatInt(o.Id, 10), o.Name, strconv.FormatBool(o.Enabled), muted, o.Threshold},
+		Object:       o,
+	}
+}
+
+func (o *objectMonitor) GetValues() []PropertyInstance {
+	props := ObjectTypeMonitor.GetProperties()
+	r := make([]PropertyInstance, len(props))
+	for i, p := range props {
+		r[i] = &propertyInstance{p, o}
+	}
+	return r
+}
+
+func (o *objectMonitor) PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error {
+	return printToYamlFromObjectInstance(op, otyp, obj)
+}
+
+type objectTypeMonitor struct{}
+
+var ObjectTypeMonitor ObjectType = &objectTypeMonitor{}
+
+func (*objectTypeMonitor) TypeName() string { return "monitor" }
+func (*objectTypeMonitor) Help() string {
+	return "A monitor runs a query on a schedule, and notifies when its rule triggers."
+}
+func (*objectTypeMonitor) CanList() bool   { return true }
+func (*objectTypeMonitor) CanGet() bool    { return true }
+func (*objectTypeMonitor) CanCreate() bool { return false }
+func (*objectTypeMonitor) CanUpdate() bool { return false }
+func (*objectTypeMonitor) CanDelete() bool { return false }
+func (*objectTypeMonitor) GetPresentationLabels() []string {
+	return []string{"id", "name", "enabled", "mutedUntil", "threshold"}
+}
+func (ot *objectTypeMonitor) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+const gqlMonitorFields = `id name workspaceId folderId description disabled query { stages { pipeline } } rule { ruleKind lookbackTime ... on MonitorRuleThreshold { compareFunction compareValues } } actions { action { name } } mutedUntil muteReason createdBy`
+
+// unpackMonitor flattens the nested parts of a monitor: the pipelines of the
+// stages are joined, the rule is summarized as, for example, "Threshold
+// Greater 100 over 10m0s", and the actions are listed by name.
+func unpackMonitor(rsp object) *objectMonitor {
+	flat := object{}
+	for k, v := range rsp {
+		switch k {
+		case "disabled":
+			disabled, _ := v.(bool)
+			flat["enabled"] = !disabled
+		case "query":
+			var pipelines []string
+			if q, is := v.(object); is {
+				stages, _ := q["stages"].(array)
+				for _, s := range stages {
+					if p, _ := s.(object)["pipeline"].(string); p != "" {
+						pipelines = append(pipelines, p)
+					}
+				}
+			}
+			flat["query"] = strings.Join(pipelines, "\n")
+		case "rule":
+			flat["threshold"] = monitorThreshold(v)
+		case "actions":
+			var names []string
+			actions, _ := v.(array)
+			for _, a := range actions {
+				if action, is := a.(object)["action"].(object); is {
+					names = append(names, fmt.Sprint(action["name"]))
+				}
+			}
+			flat["actions"] = strings.Join(names, ", ")
+		default:
+			flat[k] = v
+		}
+	}
+	return unpackObject(flat, &objectMonitor{}, ObjectTypeMonitor.TypeName()).(*objectMonitor)
+}
+
+func monitorThreshold(v any) string {
+	rule, is := v.(object)
+	if !is {
+		return ""
+	}
+	parts := []string{fmt.Sprint(rule["ruleKind"])}
+	if cf, has := rule["compareFunction"]; has && cf != nil {
+		var values []string
+		cvs, _ := rule["compareValues"].(array)
+		for _, cv := range cvs {
+			values = append(values, fmt.Sprint(cv))
+		}
+		parts = append(parts, fmt.Sprint(cf), strings.Join(values, ","))
+	}
+	if lb, has := rule["lookbackTime"]; has && lb != nil {
+		parts = append(parts, "over", fmt.Sprint(lb))
+	}
+	return strings.Join(parts, " ")
+}
+
+var gqlListMonitor = compileGqlQuery(`query Monitor_List { monitors { `+gqlMonitorFields+` } }`, "data", "monitors")
+
+func (ot *objectTypeMonitor) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListMonitor.query(cfg, op, hc, object{})
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	var ret []*ObjectInfo
+	for _, m := range obj.(array) {
+		ret = append(ret, unpackMonitor(m.(object)).GetInfo())
+	}
+	return ret, nil
+}
+
+var gqlGetMonitor = compileGqlQuery(`query Monitor_Get_Id($id: ObjectId!) { monitor(id: $id) { `+gqlMonitorFields+` } }`, "data", "monitor")
+
+func (ot *objectTypeMonitor) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
+	obj, err := gqlGetMonitor.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return unpackMonitor(obj.(object)), nil
+}
+
+func (ot *objectTypeMonitor) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	return nil, nil
+}
+
+func (ot *objectTypeMonitor) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
+	return nil, nil
+}
+
+func (ot *objectTypeMonitor) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	return nil
+}
+
+var gqlMuteMonitor = compileGqlQuery(`mutation Monitor_Mute($id: ObjectId!, $input: MonitorMuteInput!) { muteMonitor(id: $id, input: $input) { `+gqlMonitorFields+` } }`, "data", "muteMonitor")
+
+// muteMonitor silences the monitor's notifications until endTime, an
+// RFC 3339 timestamp.
+func muteMonitor(cfg *Config, op Output, hc httpClient, id string, endTime string, reason string) (*objectMonitor, error) {
+	obj, err := gqlMuteMonitor.query(cfg, op, hc, object{"id": id, "input": object{"endTime": endTime, "reason": reason}})
+	if err != nil {
+		return nil, err
+	}
+	return unpackMonitor(obj.(object)), nil
+}
+
+var gqlUnmuteMonitor = compileGqlQuery(`mutation Monitor_Unmute($id: ObjectId!) { unmuteMonitor(id: $id) { `+gqlMonitorFields+` } }`, "data", "unmuteMonitor")
+
+func unmuteMonitor(cfg *Config, op Output, hc httpClient, id string) (*objectMonitor, error) {
+	obj, err := gqlUnmuteMonitor.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	return unpackMonitor(obj.(object)), nil
+}
+
+// monitorDefinition is what it takes to evaluate a monitor outside of Observe:
+// its query, in the shape the export API takes, and its rule.
+type monitorDefinition struct {
+	Name  string      `json:"name"`
+	Query OpalQuery   `json:"query"`
+	Rule  monitorRule `json:"rule"`
+}
+
+type monitorRule struct {
+	RuleKind        string    `json:"ruleKind"`
+	CompareFunction string    `json:"compareFunction"`
+	CompareValues   []float64 `json:"compareValues"`
+	ValueColumnName string    `json:"valueColumnName"`
+	LookbackTime    string    `json:"lookbackTime"`
+}
+
+var gqlGetMonitorDefinition = compileGqlQuery(`query Monitor_Definition($id: ObjectId!) { monitor(id: $id) { name query { outputStage stages { stageID:id input { inputName datasetId stageId } pipeline } } rule { ruleKind lookbackTime ... on MonitorRuleThreshold { compareFunction compareValues valueColumnName } } } }`, "data", "monitor")
+
+func getMonitorDefinition(cfg *Config, op Output, hc httpClient, id string) (*monitorDefinition, error) {
+	obj, err := gqlGetMonitorDefinition.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, NewObserveError(nil, "monitor %s not found", id)
+	}
+	// the response already has the field names of the structs
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var ret monitorDefinition
+	if err := json.Unmarshal(buf, &ret); err != nil {
+		return nil, NewObserveError(err, "monitor %s", id)
+	}
+	return &ret, nil
+}
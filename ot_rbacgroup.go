@@ -5,9 +5,9 @@ func init() {
 }
 
 type objectRbacgroup struct {
-	Id          string
-	Name        string
-	Description string
+	Id          string `observe:"id,id"`
+	Name        string `observe:"name"`
+	Description string `observe:"description"`
 }
 
 var _ ObjectInstance = &objectRbacgroup{}
@@ -38,28 +38,22 @@ type objectTypeRbacgroup struct{}
 
 var ObjectTypeRbacgroup ObjectType = &objectTypeRbacgroup{}
 
-var propertyDescRbacgroup = []PropertyDesc{
-	{"id", PropertyTypeString, false, true, func(o any) any { return o.(*objectRbacgroup).Id }, func(o any, v any) { o.(*objectRbacgroup).Id = v.(string) }},
-	{"name", PropertyTypeString, false, false, func(o any) any { return o.(*objectRbacgroup).Name }, func(o any, v any) { o.(*objectRbacgroup).Name = v.(string) }},
-	{"description", PropertyTypeString, false, false, func(o any) any { return o.(*objectRbacgroup).Description }, func(o any, v any) { o.(*objectRbacgroup).Description = v.(string) }},
-}
-
 func (*objectTypeRbacgroup) TypeName() string { return "rbacgroup" }
 func (*objectTypeRbacgroup) Help() string {
 	return "A group of users and/or other groups for purposes of Role-Based Access Control (RBAC)."
 }
-func (*objectTypeRbacgroup) CanList() bool                   { return true }
-func (*objectTypeRbacgroup) CanGet() bool                    { return true }
-func (*objectTypeRbacgroup) CanCreate() bool                 { return false }
-func (*objectTypeRbacgroup) CanUpdate() bool                 { return false }
-func (*objectTypeRbacgroup) CanDelete() bool                 { return false }
-func (*objectTypeRbacgroup) GetPresentationLabels() []string { return []string{"id", "name"} }
-func (*objectTypeRbacgroup) GetProperties() []PropertyDesc   { return propertyDescRbacgroup }
-
-var gqlListRbacgroup = compileGqlQuery(`query Rbacgroup_List { rbacGroups { id name description } }`, "data", "rbacGroups")
-
-func (ot *objectTypeRbacgroup) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
-	obj, err := gqlListRbacgroup.query(cfg, op, hc, object{})
+func (*objectTypeRbacgroup) CanList() bool                    { return true }
+func (*objectTypeRbacgroup) CanGet() bool                     { return true }
+func (*objectTypeRbacgroup) CanCreate() bool                  { return true }
+func (*objectTypeRbacgroup) CanUpdate() bool                  { return true }
+func (*objectTypeRbacgroup) CanDelete() bool                  { return true }
+func (*objectTypeRbacgroup) GetPresentationLabels() []string  { return []string{"id", "name"} }
+func (ot *objectTypeRbacgroup) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+var gqlListRbacgroup = compileGqlQuery(`query Rbacgroup_List { rbacGroups { id name description } }`, "data", "rbacGroups").WithCache()
+
+func (ot *objectTypeRbacgroup) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListRbacgroup.queryCached(cfg, fs, op, hc, object{})
 	if err != nil || obj == nil {
 		return nil, err
 	}
@@ -85,14 +79,57 @@ func (ot *objectTypeRbacgroup) Get(cfg *Config, op Output, hc httpClient, id str
 	return unpackObject(obj.(object), &objectRbacgroup{}, ot.TypeName()), nil
 }
 
+// properties that go in RbacGroupInput
+var inputFieldsRbacgroup = map[string]string{
+	"name":        "name",
+	"description": "description",
+}
+
+var gqlCreateRbacgroup = compileGqlQuery(`mutation Rbacgroup_Create($input: RbacGroupInput!) { createRbacGroup(input: $input) { id name description } }`, "data", "createRbacGroup")
+
 func (ot *objectTypeRbacgroup) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
-	return nil, nil
+	in, err := gqlInput(ot, input, inputFieldsRbacgroup)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := gqlCreateRbacgroup.query(cfg, op, hc, object{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectRbacgroup{}, ot.TypeName()), nil
 }
 
+var gqlUpdateRbacgroup = compileGqlQuery(`mutation Rbacgroup_Update($id: ORN!, $input: RbacGroupInput!) { updateRbacGroup(id: $id, input: $input) { id name description } }`, "data", "updateRbacGroup")
+
 func (ot *objectTypeRbacgroup) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
-	return nil, nil
+	cur, err := ot.Get(cfg, op, hc, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil {
+		return nil, NewObserveError(nil, "rbacgroup %s not found", id)
+	}
+	in, err := gqlInput(ot, input, inputFieldsRbacgroup)
+	if err != nil {
+		return nil, err
+	}
+	merged := gqlInputFromInstance(cur, inputFieldsRbacgroup)
+	for k, v := range in {
+		merged[k] = v
+	}
+	obj, err := gqlUpdateRbacgroup.query(cfg, op, hc, object{"id": id, "input": merged})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectRbacgroup{}, ot.TypeName()), nil
 }
 
+var gqlDeleteRbacgroup = compileGqlQuery(`mutation Rbacgroup_Delete($id: ORN!) { deleteRbacGroup(id: $id) { success errorMessage } }`, "data", "deleteRbacGroup")
+
 func (ot *objectTypeRbacgroup) Delete(cfg *Config, op Output, hc httpClient, id string) error {
-	return nil
+	obj, err := gqlDeleteRbacgroup.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return err
+	}
+	return resultStatus(obj)
 }
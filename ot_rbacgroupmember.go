@@ -5,11 +5,11 @@ func init() {
 }
 
 type objectRbacgroupmember struct {
-	Id            string
-	Description   string
-	GroupId       string
-	MemberGroupId *string
-	MemberUserId  *int64
+	Id            string  `observe:"id,id"`
+	Description   string  `observe:"description"`
+	GroupId       string  `observe:"groupid"`
+	MemberGroupId *string `observe:"membergroupid"`
+	MemberUserId  *int64  `observe:"memberuserid"`
 }
 
 var _ ObjectInstance = &objectRbacgroupmember{}
@@ -40,32 +40,24 @@ type objectTypeRbacgroupmember struct{}
 
 var ObjectTypeRbacgroupmember ObjectType = &objectTypeRbacgroupmember{}
 
-var propertyDescRbacgroupmember = []PropertyDesc{
-	{"id", PropertyTypeString, false, true, func(o any) any { return o.(*objectRbacgroupmember).Id }, func(o any, v any) { o.(*objectRbacgroupmember).Id = v.(string) }},
-	{"description", PropertyTypeString, false, false, func(o any) any { return o.(*objectRbacgroupmember).Description }, func(o any, v any) { o.(*objectRbacgroupmember).Description = v.(string) }},
-	{"groupid", PropertyTypeString, false, false, func(o any) any { return o.(*objectRbacgroupmember).GroupId }, func(o any, v any) { o.(*objectRbacgroupmember).GroupId = v.(string) }},
-	{"membergroupid", PropertyTypeString, false, false, func(o any) any { return maybeStringAny(o.(*objectRbacgroupmember).MemberGroupId) }, func(o any, v any) { o.(*objectRbacgroupmember).MemberGroupId = maybeAnyString(v) }},
-	{"memberuserid", PropertyTypeInteger, false, false, func(o any) any { return maybeIntAny(o.(*objectRbacgroupmember).MemberUserId) }, func(o any, v any) { o.(*objectRbacgroupmember).MemberUserId = maybeAnyInt(v) }},
-}
-
 func (*objectTypeRbacgroupmember) TypeName() string { return "rbacgroupmember" }
 func (*objectTypeRbacgroupmember) Help() string {
 	return "A member (user or group) of a group."
 }
 func (*objectTypeRbacgroupmember) CanList() bool   { return true }
 func (*objectTypeRbacgroupmember) CanGet() bool    { return true }
-func (*objectTypeRbacgroupmember) CanCreate() bool { return false }
-func (*objectTypeRbacgroupmember) CanUpdate() bool { return false }
-func (*objectTypeRbacgroupmember) CanDelete() bool { return false }
+func (*objectTypeRbacgroupmember) CanCreate() bool { return true }
+func (*objectTypeRbacgroupmember) CanUpdate() bool { return true }
+func (*objectTypeRbacgroupmember) CanDelete() bool { return true }
 func (*objectTypeRbacgroupmember) GetPresentationLabels() []string {
 	return []string{"id", "groupid", "membergroupid", "memberuserid"}
 }
-func (*objectTypeRbacgroupmember) GetProperties() []PropertyDesc { return propertyDescRbacgroupmember }
+func (ot *objectTypeRbacgroupmember) GetProperties() []PropertyDesc { return taggedProperties(ot) }
 
-var gqlListRbacgroupmember = compileGqlQuery(`query Rbacgroupmember_List { rbacGroupmembers { id description groupid:groupId membergroupid:memberGroupId memberuserid:memberUserId} }`, "data", "rbacGroupmembers")
+var gqlListRbacgroupmember = compileGqlQuery(`query Rbacgroupmember_List { rbacGroupmembers { id description groupid:groupId membergroupid:memberGroupId memberuserid:memberUserId} }`, "data", "rbacGroupmembers").WithCache()
 
-func (ot *objectTypeRbacgroupmember) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
-	obj, err := gqlListRbacgroupmember.query(cfg, op, hc, object{})
+func (ot *objectTypeRbacgroupmember) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListRbacgroupmember.queryCached(cfg, fs, op, hc, object{})
 	if err != nil || obj == nil {
 		return nil, err
 	}
@@ -78,7 +70,7 @@ func (ot *objectTypeRbacgroupmember) List(cfg *Config, op Output, hc httpClient)
 	return ret, nil
 }
 
-var gqlGetRbacgroupmember = compileGqlQuery(`query Rbacgroupmember_Get_Id($id: ORN!) { rbacGroupmember(id: $id) { id description groupId memberGroupId memberUserId } }`, "data", "rbacGroupmember")
+var gqlGetRbacgroupmember = compileGqlQuery(`query Rbacgroupmember_Get_Id($id: ORN!) { rbacGroupmember(id: $id) { id description groupid:groupId membergroupid:memberGroupId memberuserid:memberUserId } }`, "data", "rbacGroupmember")
 
 func (ot *objectTypeRbacgroupmember) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
 	obj, err := gqlGetRbacgroupmember.query(cfg, op, hc, object{"id": id})
@@ -91,14 +83,84 @@ func (ot *objectTypeRbacgroupmember) Get(cfg *Config, op Output, hc httpClient,
 	return unpackObject(obj.(object), &objectRbacgroupmember{}, ot.TypeName()), nil
 }
 
+// properties that go in RbacGroupmemberInput
+var inputFieldsRbacgroupmember = map[string]string{
+	"description":   "description",
+	"groupid":       "groupId",
+	"membergroupid": "memberGroupId",
+	"memberuserid":  "memberUserId",
+}
+
+var gqlCreateRbacgroupmember = compileGqlQuery(`mutation Rbacgroupmember_Create($input: RbacGroupmemberInput!) { createRbacGroupmember(input: $input) { id description groupid:groupId membergroupid:memberGroupId memberuserid:memberUserId } }`, "data", "createRbacGroupmember")
+
 func (ot *objectTypeRbacgroupmember) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
-	return nil, nil
+	in, err := gqlInput(ot, input, inputFieldsRbacgroupmember)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGroupmemberInput(in); err != nil {
+		return nil, err
+	}
+	obj, err := gqlCreateRbacgroupmember.query(cfg, op, hc, object{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectRbacgroupmember{}, ot.TypeName()), nil
 }
 
+var gqlUpdateRbacgroupmember = compileGqlQuery(`mutation Rbacgroupmember_Update($id: ORN!, $input: RbacGroupmemberInput!) { updateRbacGroupmember(id: $id, input: $input) { id description groupid:groupId membergroupid:memberGroupId memberuserid:memberUserId } }`, "data", "updateRbacGroupmember")
+
 func (ot *objectTypeRbacgroupmember) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
-	return nil, nil
+	cur, err := ot.Get(cfg, op, hc, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil {
+		return nil, NewObserveError(nil, "rbacgroupmember %s not found", id)
+	}
+	in, err := gqlInput(ot, input, inputFieldsRbacgroupmember)
+	if err != nil {
+		return nil, err
+	}
+	merged := gqlInputFromInstance(cur, inputFieldsRbacgroupmember)
+	for k, v := range in {
+		merged[k] = v
+	}
+	// switching between a user and a group member replaces the other one
+	if _, has := in["memberUserId"]; has {
+		delete(merged, "memberGroupId")
+	} else if _, has := in["memberGroupId"]; has {
+		delete(merged, "memberUserId")
+	}
+	if err := checkGroupmemberInput(merged); err != nil {
+		return nil, err
+	}
+	obj, err := gqlUpdateRbacgroupmember.query(cfg, op, hc, object{"id": id, "input": merged})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectRbacgroupmember{}, ot.TypeName()), nil
 }
 
+var gqlDeleteRbacgroupmember = compileGqlQuery(`mutation Rbacgroupmember_Delete($id: ORN!) { deleteRbacGroupmember(id: $id) { success errorMessage } }`, "data", "deleteRbacGroupmember")
+
 func (ot *objectTypeRbacgroupmember) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	obj, err := gqlDeleteRbacgroupmember.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return err
+	}
+	return resultStatus(obj)
+}
+
+var ErrGroupmemberNeedsGroup = ObserveError{Msg: "a group member needs a groupid"}
+var ErrGroupmemberOneMember = ObserveError{Msg: "a group member needs exactly one of memberuserid and membergroupid"}
+
+func checkGroupmemberInput(in object) error {
+	if in["groupId"] == nil {
+		return ErrGroupmemberNeedsGroup
+	}
+	if (in["memberUserId"] == nil) == (in["memberGroupId"] == nil) {
+		return ErrGroupmemberOneMember
+	}
 	return nil
 }
@@ -5,19 +5,19 @@ func init() {
 }
 
 type objectRbacstatement struct {
-	Id                string
-	Description       string
-	SubjectGroup      *string
-	SubjectUser       *int64
-	SubjectAll        *bool
-	ObjectObjectId    *int64
-	ObjectFolderId    *int64
-	ObjectWorkspaceId *int64
-	ObjectType        *string
-	ObjectName        *string
-	ObjectOwner       *bool
-	ObjectAll         *bool
-	Role              string
+	Id                string  `observe:"id,id"`
+	Description       string  `observe:"description"`
+	SubjectGroup      *string `observe:"subjectgroupid"`
+	SubjectUser       *int64  `observe:"subjectuserid"`
+	SubjectAll        *bool   `observe:"subjectAll"`
+	ObjectObjectId    *int64  `observe:"objectobjectid"`
+	ObjectFolderId    *int64  `observe:"objectfolderid"`
+	ObjectWorkspaceId *int64  `observe:"objectworkspaceid"`
+	ObjectType        *string `observe:"objecttype"`
+	ObjectName        *string `observe:"objectname"`
+	ObjectOwner       *bool   `observe:"objectowner"`
+	ObjectAll         *bool   `observe:"objectall"`
+	Role              string  `observe:"role"`
 }
 
 var _ ObjectInstance = &objectRbacstatement{}
@@ -61,110 +61,24 @@ var remapRbacstatement = remap{
 	"objectall":         "object.all",
 }
 
-var propertyDescRbacstatement = []PropertyDesc{
-	{"id", PropertyTypeString, false, true, func(o any) any { return o.(*objectRbacstatement).Id }, func(o any, v any) { o.(*objectRbacstatement).Id = v.(string) }},
-	{"description", PropertyTypeString, false, false, func(o any) any { return o.(*objectRbacstatement).Description }, func(o any, v any) { o.(*objectRbacstatement).Description = v.(string) }},
-	{"subjectgroupid", PropertyTypeString, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).SubjectGroup) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).SubjectGroup = vp
-	}},
-	{"subjectuserid", PropertyTypeInteger, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).SubjectUser) }, func(o any, v any) {
-		var vp *int64
-		if v != nil {
-			vs := v.(int64)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).SubjectUser = vp
-	}},
-	{"subjectAll", PropertyTypeBoolean, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).SubjectAll) }, func(o any, v any) {
-		var vp *bool
-		if v != nil {
-			vs := v.(bool)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).SubjectAll = vp
-	}},
-	{"objectobjectid", PropertyTypeInteger, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectObjectId) }, func(o any, v any) {
-		var vp *int64
-		if v != nil {
-			vs := v.(int64)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectObjectId = vp
-	}},
-	{"objectfolderid", PropertyTypeInteger, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectFolderId) }, func(o any, v any) {
-		var vp *int64
-		if v != nil {
-			vs := v.(int64)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectFolderId = vp
-	}},
-	{"objectworkspaceid", PropertyTypeInteger, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectWorkspaceId) }, func(o any, v any) {
-		var vp *int64
-		if v != nil {
-			vs := v.(int64)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectWorkspaceId = vp
-	}},
-	{"objecttype", PropertyTypeString, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectType) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectType = vp
-	}},
-	{"objectname", PropertyTypeString, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectName) }, func(o any, v any) {
-		var vp *string
-		if v != nil {
-			vs := v.(string)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectName = vp
-	}},
-	{"objectowner", PropertyTypeBoolean, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectOwner) }, func(o any, v any) {
-		var vp *bool
-		if v != nil {
-			vs := v.(bool)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectOwner = vp
-	}},
-	{"objectall", PropertyTypeBoolean, false, false, func(o any) any { return maybe(o.(*objectRbacstatement).ObjectAll) }, func(o any, v any) {
-		var vp *bool
-		if v != nil {
-			vs := v.(bool)
-			vp = &vs
-		}
-		o.(*objectRbacstatement).ObjectAll = vp
-	}},
-	{"role", PropertyTypeString, false, false, func(o any) any { return o.(*objectRbacstatement).Role }, func(o any, v any) { o.(*objectRbacstatement).Role = v.(string) }},
-}
-
 func (*objecttypeRbacstatement) TypeName() string { return "rbacstatement" }
 func (*objecttypeRbacstatement) Help() string {
 	return "A rule that binds some role (permission) to some object or object type for some user or group."
 }
-func (*objecttypeRbacstatement) CanList() bool                   { return true }
-func (*objecttypeRbacstatement) CanGet() bool                    { return true }
-func (*objecttypeRbacstatement) CanCreate() bool                 { return false }
-func (*objecttypeRbacstatement) CanUpdate() bool                 { return false }
-func (*objecttypeRbacstatement) CanDelete() bool                 { return false }
-func (*objecttypeRbacstatement) GetPresentationLabels() []string { return []string{"id", "name"} }
-func (*objecttypeRbacstatement) GetProperties() []PropertyDesc   { return propertyDescRbacstatement }
+func (*objecttypeRbacstatement) CanList() bool                    { return true }
+func (*objecttypeRbacstatement) CanGet() bool                     { return true }
+func (*objecttypeRbacstatement) CanCreate() bool                  { return true }
+func (*objecttypeRbacstatement) CanUpdate() bool                  { return false }
+func (*objecttypeRbacstatement) CanDelete() bool                  { return true }
+func (*objecttypeRbacstatement) GetPresentationLabels() []string  { return []string{"id", "name"} }
+func (ot *objecttypeRbacstatement) GetProperties() []PropertyDesc { return taggedProperties(ot) }
 
 var gqlListRbacstatement = compileGqlQuery(
 	`query Rbacstatement_List { rbacStatements { id description subject { userId groupId all } object { objectId folderId workspaceId type name owner all } role } }`, "data", "rbacStatements").
-	WithRemap(remapRbacstatement)
+	WithRemap(remapRbacstatement).WithCache()
 
-func (ot *objecttypeRbacstatement) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
-	obj, err := gqlListRbacstatement.query(cfg, op, hc, object{})
+func (ot *objecttypeRbacstatement) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListRbacstatement.queryCached(cfg, fs, op, hc, object{})
 	if err != nil || obj == nil {
 		return nil, err
 	}
@@ -192,14 +106,86 @@ func (ot *objecttypeRbacstatement) Get(cfg *Config, op Output, hc httpClient, id
 	return unpackObject(obj.(object), &objectRbacstatement{}, ot.TypeName()), nil
 }
 
+// The input is built from the flat properties, and then nested with
+// remapRbacstatement, so it has the same shape as what is read.
+var inputFieldsRbacstatement = map[string]string{
+	"description":       "description",
+	"subjectgroupid":    "subjectgroupid",
+	"subjectuserid":     "subjectuserid",
+	"subjectAll":        "subjectAll",
+	"objectobjectid":    "objectobjectid",
+	"objectfolderid":    "objectfolderid",
+	"objectworkspaceid": "objectworkspaceid",
+	"objecttype":        "objecttype",
+	"objectname":        "objectname",
+	"objectowner":       "objectowner",
+	"objectall":         "objectall",
+	"role":              "role",
+}
+
+var (
+	ErrRbacstatementOneSubject = ObserveError{Msg: "a statement needs exactly one subject: subjectuserid, subjectgroupid, or subjectAll"}
+	ErrRbacstatementOneObject  = ObserveError{Msg: "a statement needs exactly one object: objectobjectid, objectfolderid, objectworkspaceid, objecttype, objectowner, or objectall"}
+	ErrRbacstatementNameType   = ObserveError{Msg: "objectname can only be used with objecttype"}
+	ErrRbacstatementNeedsRole  = ObserveError{Msg: "a statement needs a role"}
+)
+
+// checkRbacstatementInput validates the flat input. The booleans only count
+// as selectors when true.
+func checkRbacstatementInput(in object) error {
+	count := func(keys ...string) int {
+		n := 0
+		for _, k := range keys {
+			if v, has := in[k]; has && v != nil && v != false {
+				n++
+			}
+		}
+		return n
+	}
+	if count("subjectuserid", "subjectgroupid", "subjectAll") != 1 {
+		return ErrRbacstatementOneSubject
+	}
+	if count("objectobjectid", "objectfolderid", "objectworkspaceid", "objecttype", "objectowner", "objectall") != 1 {
+		return ErrRbacstatementOneObject
+	}
+	if count("objectname") != 0 && count("objecttype") == 0 {
+		return ErrRbacstatementNameType
+	}
+	if count("role") != 1 {
+		return ErrRbacstatementNeedsRole
+	}
+	return nil
+}
+
+var gqlCreateRbacstatement = compileGqlQuery(
+	`mutation Rbacstatement_Create($input: RbacStatementInput!) { createRbacStatement(input: $input) { id description subject { userId groupId all } object { objectId folderId workspaceId type name owner all } role } }`, "data", "createRbacStatement").
+	WithRemap(remapRbacstatement)
+
 func (ot *objecttypeRbacstatement) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
-	return nil, nil
+	in, err := gqlInput(ot, input, inputFieldsRbacstatement)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRbacstatementInput(in); err != nil {
+		return nil, err
+	}
+	obj, err := gqlCreateRbacstatement.query(cfg, op, hc, object{"input": remapRbacstatement.nest(in)})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectRbacstatement{}, ot.TypeName()), nil
 }
 
 func (ot *objecttypeRbacstatement) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
 	return nil, nil
 }
 
+var gqlDeleteRbacstatement = compileGqlQuery(`mutation Rbacstatement_Delete($id: ORN!) { deleteRbacStatement(id: $id) { success errorMessage } }`, "data", "deleteRbacStatement")
+
 func (ot *objecttypeRbacstatement) Delete(cfg *Config, op Output, hc httpClient, id string) error {
-	return nil
+	obj, err := gqlDeleteRbacstatement.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return err
+	}
+	return resultStatus(obj)
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"strings"
 )
 
 func init() {
@@ -9,11 +10,11 @@ func init() {
 }
 
 type objectUser struct {
-	Id     int64
-	Name   string
-	Email  string
-	Status string
-	Role   string
+	Id     int64  `observe:"id,id"`
+	Name   string `observe:"name"`
+	Email  string `observe:"email"`
+	Status string `observe:"status"`
+	Role   string `observe:"role"`
 }
 
 var _ ObjectInstance = &objectUser{}
@@ -44,30 +45,22 @@ type objectTypeUser struct{}
 
 var ObjectTypeUser ObjectType = &objectTypeUser{}
 
-var propertyDescUser = []PropertyDesc{
-	{"id", PropertyTypeInteger, false, true, func(o any) any { return o.(*objectUser).Id }, func(o any, v any) { o.(*objectUser).Id = v.(int64) }},
-	{"name", PropertyTypeString, false, false, func(o any) any { return o.(*objectUser).Name }, func(o any, v any) { o.(*objectUser).Name = v.(string) }},
-	{"email", PropertyTypeString, false, false, func(o any) any { return o.(*objectUser).Email }, func(o any, v any) { o.(*objectUser).Email = v.(string) }},
-	{"status", PropertyTypeString, false, false, func(o any) any { return o.(*objectUser).Status }, func(o any, v any) { o.(*objectUser).Status = v.(string) }},
-	{"role", PropertyTypeString, false, false, func(o any) any { return o.(*objectUser).Role }, func(o any, v any) { o.(*objectUser).Role = v.(string) }},
-}
-
 func (*objectTypeUser) TypeName() string { return "user" }
 func (*objectTypeUser) Help() string {
 	return "A human or serviceaccount user of the Observe tenant."
 }
-func (*objectTypeUser) CanList() bool                   { return true }
-func (*objectTypeUser) CanGet() bool                    { return true }
-func (*objectTypeUser) CanCreate() bool                 { return false }
-func (*objectTypeUser) CanUpdate() bool                 { return false }
-func (*objectTypeUser) CanDelete() bool                 { return false }
-func (*objectTypeUser) GetPresentationLabels() []string { return []string{"id", "name", "email"} }
-func (*objectTypeUser) GetProperties() []PropertyDesc   { return propertyDescUser }
-
-var gqlListUser = compileGqlQuery(`query User_List { currentCustomer { users { id name:label email status role } } }`, "data", "currentCustomer", "users")
-
-func (ot *objectTypeUser) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
-	obj, err := gqlListUser.query(cfg, op, hc, object{})
+func (*objectTypeUser) CanList() bool                    { return true }
+func (*objectTypeUser) CanGet() bool                     { return true }
+func (*objectTypeUser) CanCreate() bool                  { return true }
+func (*objectTypeUser) CanUpdate() bool                  { return true }
+func (*objectTypeUser) CanDelete() bool                  { return false }
+func (*objectTypeUser) GetPresentationLabels() []string  { return []string{"id", "name", "email"} }
+func (ot *objectTypeUser) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+var gqlListUser = compileGqlQuery(`query User_List { currentCustomer { users { id name:label email status role } } }`, "data", "currentCustomer", "users").WithCache()
+
+func (ot *objectTypeUser) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListUser.queryCached(cfg, fs, op, hc, object{})
 	if err != nil || obj == nil {
 		return nil, err
 	}
@@ -93,12 +86,138 @@ func (ot *objectTypeUser) Get(cfg *Config, op Output, hc httpClient, id string)
 	return unpackObject(obj.(object), &objectUser{}, ot.TypeName()), nil
 }
 
+// The roles and statuses a user can be given. Users can also be in other
+// states, such as invited, but only the server puts them there.
+var (
+	userRoles    = []string{"admin", "editor", "reader"}
+	userStatuses = []string{"UserStatusActive", "UserStatusDeactivated"}
+)
+
+var (
+	ErrUserBadRole    = ObserveError{Msg: "the role must be one of " + strings.Join(userRoles, ", ")}
+	ErrUserBadStatus  = ObserveError{Msg: "the status must be one of " + strings.Join(userStatuses, ", ")}
+	ErrUserNeedsEmail = ObserveError{Msg: "a new user needs an email"}
+)
+
+// checkUserInput validates the role and status, if given, and returns the
+// input with them in canonical form: "Editor" becomes "editor", and
+// "deactivated" becomes "UserStatusDeactivated".
+func checkUserInput(input object) (object, error) {
+	ret := object{}
+	for k, v := range input {
+		ret[k] = v
+	}
+	if v, has := input["role"]; has {
+		role, _ := v.(string)
+		ret["role"] = ""
+		for _, r := range userRoles {
+			if strings.EqualFold(r, role) {
+				ret["role"] = r
+			}
+		}
+		if ret["role"] == "" {
+			return nil, NewObserveError(ErrUserBadRole, "%q", role)
+		}
+	}
+	if v, has := input["status"]; has {
+		status, _ := v.(string)
+		ret["status"] = ""
+		for _, s := range userStatuses {
+			if strings.EqualFold(s, status) || strings.EqualFold(strings.TrimPrefix(s, "UserStatus"), status) {
+				ret["status"] = s
+			}
+		}
+		if ret["status"] == "" {
+			return nil, NewObserveError(ErrUserBadStatus, "%q", status)
+		}
+	}
+	return ret, nil
+}
+
+// properties that go in UserInput
+var inputFieldsUser = map[string]string{
+	"name":   "label",
+	"email":  "email",
+	"status": "status",
+	"role":   "role",
+}
+
+// newUserInput validates the input for creating or inviting a user.
+func newUserInput(ot ObjectType, input object) (object, error) {
+	input, err := checkUserInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if email, _ := input["email"].(string); email == "" {
+		return nil, ErrUserNeedsEmail
+	}
+	return gqlInput(ot, input, inputFieldsUser)
+}
+
+var gqlCreateUser = compileGqlQuery(`mutation User_Create($input: UserInput!) { createUser(input: $input) { id name:label email status role } }`, "data", "createUser")
+
 func (ot *objectTypeUser) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
-	return nil, nil
+	in, err := newUserInput(ot, input)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := gqlCreateUser.query(cfg, op, hc, object{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectUser{}, ot.TypeName()), nil
+}
+
+var gqlInviteUser = compileGqlQuery(`mutation User_Invite($input: UserInput!) { inviteUser(input: $input) { id name:label email status role } }`, "data", "inviteUser")
+
+// inviteUser creates a user like Create, and also emails them an invitation.
+func inviteUser(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	in, err := newUserInput(ObjectTypeUser, input)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := gqlInviteUser.query(cfg, op, hc, object{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectUser{}, ObjectTypeUser.TypeName()), nil
+}
+
+var gqlUpdateUser = compileGqlQuery(`mutation User_Update($id: UserId!, $input: UserInput!) { updateUser(id: $id, input: $input) { id name:label email status role } }`, "data", "updateUser")
+
+// mergeUserInput fills in the input with the user's current properties,
+// except for the status, which is only sent when it changes: the server has
+// statuses, such as invited, that it doesn't take back.
+func mergeUserInput(cur ObjectInstance, in object) object {
+	merged := gqlInputFromInstance(cur, inputFieldsUser)
+	delete(merged, "status")
+	for k, v := range in {
+		merged[k] = v
+	}
+	return merged
 }
 
 func (ot *objectTypeUser) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
-	return nil, nil
+	input, err := checkUserInput(input)
+	if err != nil {
+		return nil, err
+	}
+	in, err := gqlInput(ot, input, inputFieldsUser)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := ot.Get(cfg, op, hc, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil {
+		return nil, NewObserveError(nil, "user %s not found", id)
+	}
+	obj, err := gqlUpdateUser.query(cfg, op, hc, object{"id": id, "input": mergeUserInput(cur, in)})
+	if err != nil {
+		return nil, err
+	}
+	return unpackObject(obj.(object), &objectUser{}, ot.TypeName()), nil
 }
 
 func (ot *objectTypeUser) Delete(cfg *Config, op Output, hc httpClient, id string) error {
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"strconv"
+)
+
+var (
+	ErrWorksheetNoName   = ObserveError{Msg: "the query file needs a '// name:' directive to name the worksheet"}
+	ErrWorksheetNoStages = ObserveError{Msg: "the query file needs '// stage:' directives for the stages of the worksheet"}
+)
+
+func init() {
+	RegisterObjectType(ObjectTypeWorksheet, &objectWorksheet{})
+}
+
+type objectWorksheet struct {
+	Id          int64  `observe:"id,id"`
+	Name        string `observe:"name"`
+	WorkspaceId int64  `observe:"workspaceId"`
+	FolderId    int64  `observe:"folderId"`
+	CreatedBy   *int64 `observe:"createdBy,computed"`
+	// the stages aren't properties; `get --opal` writes them as a query file
+	stages []queryFileStage
+}
+
+var _ ObjectInstance = &objectWorksheet{}
+var _ opalObject = &objectWorksheet{}
+
+func (o *objectWorksheet) GetInfo() *ObjectInfo {
+	return &ObjectInfo{
+		Id:           strconv.FormatInt(o.Id, 10),
+		Name:         o.Name,
+		Presentation: []string{strconv.FormatInt(o.Id, 10), o.Name, strconv.FormatInt(o.WorkspaceId, 10)},
+		Object:       o,
+	}
+}
+
+func (o *objectWorksheet) GetValues() []PropertyInstance {
+	props := ObjectTypeWorksheet.GetProperties()
+	r := make([]PropertyInstance, len(props))
+	for i, p := range props {
+		r[i] = &propertyInstance{p, o}
+	}
+	return r
+}
+
+func (o *objectWorksheet) PrintToYaml(op Output, otyp ObjectType, obj ObjectInstance) error {
+	return printToYamlFromObjectInstance(op, otyp, obj)
+}
+
+func (o *objectWorksheet) QueryFile() *queryFile {
+	return &queryFile{Name: o.Name, Stages: o.stages}
+}
+
+type objectTypeWorksheet struct{}
+
+var ObjectTypeWorksheet ObjectType = &objectTypeWorksheet{}
+
+func (*objectTypeWorksheet) TypeName() string { return "worksheet" }
+func (*objectTypeWorksheet) Help() string {
+	return "A worksheet is a sequence of OPAL stages, built up interactively in the web UI."
+}
+func (*objectTypeWorksheet) CanList() bool   { return true }
+func (*objectTypeWorksheet) CanGet() bool    { return true }
+func (*objectTypeWorksheet) CanCreate() bool { return true }
+func (*objectTypeWorksheet) CanUpdate() bool { return false }
+func (*objectTypeWorksheet) CanDelete() bool { return false }
+func (*objectTypeWorksheet) GetPresentationLabels() []string {
+	return []string{"id", "name", "workspaceId"}
+}
+func (ot *objectTypeWorksheet) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+const gqlWorksheetStageFields = `stages { id pipeline input { inputName datasetId datasetPath stageId } }`
+
+// unpackWorksheet turns the stages into those of a query file, where an input
+// is a dataset ID, a dataset path, or @ and a stage ID.
+func unpackWorksheet(rsp object) *objectWorksheet {
+	str := func(v any) string {
+		s, _ := v.(string)
+		return s
+	}
+	var stages []queryFileStage
+	if list, has := rsp["stages"].(array); has {
+		for _, item := range list {
+			st := item.(object)
+			s := queryFileStage{Id: str(st["id"]), Pipeline: str(st["pipeline"])}
+			inputs, _ := st["input"].(array)
+			for _, i := range inputs {
+				in := i.(object)
+				source := str(in["datasetPath"])
+				switch {
+				case in["datasetId"] != nil:
+					source = str(in["datasetId"])
+				case in["stageId"] != nil:
+					source = "@" + str(in["stageId"])
+				}
+				s.Inputs = append(s.Inputs, queryFileInput{str(in["inputName"]), source})
+			}
+			stages = append(stages, s)
+		}
+	}
+	delete(rsp, "stages")
+	o := unpackObject(rsp, &objectWorksheet{}, ObjectTypeWorksheet.TypeName()).(*objectWorksheet)
+	o.stages = stages
+	return o
+}
+
+var gqlListWorksheet = compileGqlQuery(`query Worksheet_List { worksheetSearch(terms: {}) { worksheets { worksheet { id name:label workspaceId folderId } } } }`, "data", "worksheetSearch", "worksheets")
+
+func (ot *objectTypeWorksheet) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListWorksheet.query(cfg, op, hc, object{})
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	var ret []*ObjectInfo
+	for _, ws := range obj.(array) {
+		if worksheet, found := ws.(object)["worksheet"]; found {
+			ws = worksheet
+		}
+		ret = append(ret, unpackWorksheet(ws.(object)).GetInfo())
+	}
+	return ret, nil
+}
+
+var gqlGetWorksheet = compileGqlQuery(`query Worksheet_Get_Id($id: ObjectId!) { worksheet(id: $id) { id name:label workspaceId folderId createdBy `+gqlWorksheetStageFields+` } }`, "data", "worksheet")
+
+func (ot *objectTypeWorksheet) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
+	obj, err := gqlGetWorksheet.query(cfg, op, hc, object{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return unpackWorksheet(obj.(object)), nil
+}
+
+var gqlSaveWorksheet = compileGqlQuery(`mutation Worksheet_Save($input: WorksheetInput!) { saveWorksheet(wks: $input) { id name:label workspaceId folderId createdBy `+gqlWorksheetStageFields+` } }`, "data", "saveWorksheet")
+
+// Create makes an empty worksheet; use `create worksheet --opal` to give it
+// stages.
+func (ot *objectTypeWorksheet) Create(cfg *Config, op Output, hc httpClient, input object) (ObjectInstance, error) {
+	return ot.save(cfg, op, hc, input, nil)
+}
+
+// CreateFromQueryFile makes a worksheet of the stages in the file. Inputs
+// are resolved as `query -f` resolves them, so a path without a workspace
+// is in the configured workspace.
+func (ot *objectTypeWorksheet) CreateFromQueryFile(fa FuncArgs, qf *queryFile) (ObjectInstance, error) {
+	if qf.Name == "" {
+		return nil, ErrWorksheetNoName
+	}
+	if len(qf.Stages) == 0 {
+		return nil, ErrWorksheetNoStages
+	}
+	q, err := queryFromFile(fa, qf)
+	if err != nil {
+		return nil, err
+	}
+	return ot.save(fa.cfg, fa.op, fa.hc, object{"name": qf.Name}, q.Stages)
+}
+
+// properties that go in WorksheetInput
+var inputFieldsWorksheet = map[string]string{
+	"name":        "label",
+	"workspaceId": "workspaceId",
+	"folderId":    "folderId",
+}
+
+// save creates a worksheet, in the configured workspace unless the input says
+// otherwise.
+func (ot *objectTypeWorksheet) save(cfg *Config, op Output, hc httpClient, input object, stages []StageQuery) (ObjectInstance, error) {
+	if _, has := input["workspaceId"]; !has {
+		ws, err := ResolveWorkspace(cfg, nil, op, hc)
+		if err != nil {
+			return nil, err
+		}
+		input["workspaceId"] = ws.Id
+	}
+	in, err := gqlInput(ot, input, inputFieldsWorksheet)
+	if err != nil {
+		return nil, err
+	}
+	gqlStages := array{}
+	for _, s := range stages {
+		inputs := array{}
+		for _, i := range s.Inputs {
+			gi := object{"inputName": i.InputName}
+			switch {
+			case i.DatasetID != nil:
+				gi["datasetId"] = strconv.FormatInt(*i.DatasetID, 10)
+			case i.StageID != nil:
+				gi["stageId"] = *i.StageID
+			case i.DatasetPath != nil:
+				gi["datasetPath"] = *i.DatasetPath
+			}
+			inputs = append(inputs, gi)
+		}
+		gqlStages = append(gqlStages, object{"id": s.StageID, "pipeline": s.Pipeline, "input": inputs})
+	}
+	in["stages"] = gqlStages
+	obj, err := gqlSaveWorksheet.query(cfg, op, hc, object{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	return unpackWorksheet(obj.(object)), nil
+}
+
+func (ot *objectTypeWorksheet) Update(cfg *Config, op Output, hc httpClient, id string, input object) (ObjectInstance, error) {
+	return nil, nil
+}
+
+func (ot *objectTypeWorksheet) Delete(cfg *Config, op Output, hc httpClient, id string) error {
+	return nil
+}
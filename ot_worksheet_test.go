@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testWorksheet = `{"id":"41000500","name":"Errors by service","workspaceId":"41000001","folderId":"41000300","createdBy":"3","stages":[` +
+	`{"id":"errors","pipeline":"filter status >= 500","input":[{"inputName":"_","datasetId":"41007104","datasetPath":null,"stageId":null}]},` +
+	`{"id":"by-service","pipeline":"statsby count(), group_by(service)","input":[{"inputName":"_","datasetId":null,"datasetPath":null,"stageId":"errors"},{"inputName":"pods","datasetId":null,"datasetPath":"Default.k8s/Pod","stageId":null}]}]}`
+
+func TestCmdListWorksheet(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"worksheetSearch":{"worksheets":[{"worksheet":{"id":"41000500","name":"Errors by service","workspaceId":"41000001"}}]}}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"list", "worksheet"}, fix.hc)
+	fix.Assert()
+	if !strings.Contains(fix.op.OutputBuf.String(), "41000500") || !strings.Contains(fix.op.OutputBuf.String(), "41000001") {
+		t.Error("unexpected output:", fix.op.OutputBuf.String())
+	}
+}
+
+func TestCmdGetWorksheetOpal(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"worksheet":` + testWorksheet + `}}`},
+	)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"get", "worksheet", "41000500", "--opal"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `// name: Errors by service
+// stage: errors
+// input: _ 41007104
+filter status >= 500
+
+// stage: by-service
+// input: _ @errors
+// input: pods Default.k8s/Pod
+statsby count(), group_by(service)
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
+
+func TestCmdCreateWorksheetOpal(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"41000001","name":"Default"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"saveWorksheet":` + testWorksheet + `}}`},
+	)
+	fix.fs.WriteFile("errors.opal", []byte(testQueryFile), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"create", "worksheet", "--opal", "errors.opal"}, fix.hc)
+	fix.Assert()
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), `object:
+  type: "worksheet"
+  id: 41000500
+  config:
+    name: "Errors by service"
+    workspaceId: 41000001
+    folderId: 41000300
+  state:
+    createdBy: 3
+`); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+	// without a name, there's nothing to call the worksheet
+	fix.fs.WriteFile("noname.opal", []byte("// stage: a\n// input: _ 41007104\nlimit 1\n"), 0644)
+	mustPanic(t, func() {
+		RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"create", "worksheet", "--opal", "noname.opal"}, fix.hc)
+	})
+	if !strings.Contains(fix.op.ErrorBuf.String(), ErrWorksheetNoName.Msg) {
+		t.Error("unexpected error output:", fix.op.ErrorBuf.String())
+	}
+}
+
+func TestCmdCreateWorksheetWorkspace(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, `{"data":{"currentUser":{"workspaces":[{"id":"41000001","name":"Default"},{"id":"41000002","name":"Other"}]}}}`},
+		testRequest{"/v1/meta", 200, `{"data":{"saveWorksheet":` + strings.Replace(testWorksheet, "41000001", "41000002", 1) + `}}`},
+	)
+	fix.fs.WriteFile("errors.opal", []byte(testQueryFile), 0644)
+	// with two workspaces, only --workspace says which one to use
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"create", "worksheet", "--workspace", "Other", "--opal", "errors.opal"}, fix.hc)
+	fix.Assert()
+	if !strings.Contains(fix.op.OutputBuf.String(), "workspaceId: 41000002") {
+		t.Error("unexpected output:", fix.op.OutputBuf.String())
+	}
+	if fix.cfg.WorkspaceIdOrName != "Other" {
+		t.Error("unexpected workspace:", fix.cfg.WorkspaceIdOrName)
+	}
+}
+
+func TestCmdCreateWorksheetInputPath(t *testing.T) {
+	const workspaces = `{"data":{"currentUser":{"workspaces":[{"id":"41000001","name":"Default"}]}}}`
+	fix := startFixture(t,
+		testRequest{"/v1/meta", 200, workspaces},
+		testRequest{"/v1/meta", 200, workspaces},
+		testRequest{"/v1/meta", 200, `{"data":{"saveWorksheet":` + testWorksheet + `}}`},
+	)
+	// as with query -f, a path without a workspace is in the configured one
+	fix.fs.WriteFile("errors.opal", []byte(strings.Replace(testQueryFile, "Default.k8s/Pod", "k8s/Pod", 1)), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"create", "worksheet", "--opal", "errors.opal"}, fix.hc)
+	fix.Assert()
+	if !strings.Contains(fix.op.DebugBuf.String(), `@pods <- datasetPath("Default.k8s/Pod")`) {
+		t.Error("unexpected debug output:", fix.op.DebugBuf.String())
+	}
+}
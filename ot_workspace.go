@@ -4,15 +4,12 @@ import (
 	"strconv"
 )
 
+// The struct, properties, and queries are generated; see schema/objecttypes.yaml.
+
 func init() {
 	RegisterObjectType(ObjectTypeWorkspace, &objectWorkspace{})
 }
 
-type objectWorkspace struct {
-	workspaceObject
-	Timezone string
-}
-
 var _ ObjectInstance = &objectWorkspace{}
 
 func (o *objectWorkspace) GetInfo() *ObjectInfo {
@@ -41,28 +38,20 @@ type objectTypeWorkspace struct{}
 
 var ObjectTypeWorkspace ObjectType = &objectTypeWorkspace{}
 
-var propertyDescWorkspace = []PropertyDesc{
-	{"id", PropertyTypeInteger, false, true, func(o any) any { return o.(*objectWorkspace).Id }, func(o any, v any) { o.(*objectWorkspace).Id = v.(int64) }},
-	{"name", PropertyTypeString, false, false, func(o any) any { return o.(*objectWorkspace).Name }, func(o any, v any) { o.(*objectWorkspace).Name = v.(string) }},
-	{"timezone", PropertyTypeString, false, false, func(o any) any { return o.(*objectWorkspace).Timezone }, func(o any, v any) { o.(*objectWorkspace).Timezone = v.(string) }},
-}
-
 func (*objectTypeWorkspace) TypeName() string { return "workspace" }
 func (*objectTypeWorkspace) Help() string {
 	return "A workspace organizes most other objects, such as datasets."
 }
-func (*objectTypeWorkspace) CanList() bool                   { return true }
-func (*objectTypeWorkspace) CanGet() bool                    { return true }
-func (*objectTypeWorkspace) CanCreate() bool                 { return false }
-func (*objectTypeWorkspace) CanUpdate() bool                 { return false }
-func (*objectTypeWorkspace) CanDelete() bool                 { return false }
-func (*objectTypeWorkspace) GetPresentationLabels() []string { return []string{"id", "name"} }
-func (*objectTypeWorkspace) GetProperties() []PropertyDesc   { return propertyDescWorkspace }
-
-var gqlListWorkspace = compileGqlQuery(`query Workspace_List { currentUser { workspaces { id name:label } } }`, "data", "currentUser", "workspaces")
-
-func (ot *objectTypeWorkspace) List(cfg *Config, op Output, hc httpClient) ([]*ObjectInfo, error) {
-	obj, err := gqlListWorkspace.query(cfg, op, hc, object{})
+func (*objectTypeWorkspace) CanList() bool                    { return true }
+func (*objectTypeWorkspace) CanGet() bool                     { return true }
+func (*objectTypeWorkspace) CanCreate() bool                  { return false }
+func (*objectTypeWorkspace) CanUpdate() bool                  { return false }
+func (*objectTypeWorkspace) CanDelete() bool                  { return false }
+func (*objectTypeWorkspace) GetPresentationLabels() []string  { return []string{"id", "name"} }
+func (ot *objectTypeWorkspace) GetProperties() []PropertyDesc { return taggedProperties(ot) }
+
+func (ot *objectTypeWorkspace) List(cfg *Config, fs fileSystem, op Output, hc httpClient) ([]*ObjectInfo, error) {
+	obj, err := gqlListWorkspace.queryCached(cfg, fs, op, hc, object{})
 	if err != nil || obj == nil {
 		return nil, err
 	}
@@ -75,8 +64,6 @@ func (ot *objectTypeWorkspace) List(cfg *Config, op Output, hc httpClient) ([]*O
 	return ret, nil
 }
 
-var gqlGetWorkspace = compileGqlQuery(`query Workspace_Get_Id($id: ObjectId!) { workspace(id: $id) { id name:label timezone } }`, "data", "workspace")
-
 func (ot *objectTypeWorkspace) Get(cfg *Config, op Output, hc httpClient, id string) (ObjectInstance, error) {
 	obj, err := gqlGetWorkspace.query(cfg, op, hc, object{"id": id})
 	if err != nil {
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// An object struct declares its properties with tags, and RegisterObjectType
+// derives the descriptions, which GetProperties returns with taggedProperties:
+//
+//	type objectThing struct {
+//		Id    int64   `observe:"id,id"`
+//		Path  string  `observe:"path,computed"`
+//		Label *string `observe:"label"`
+//	}
+//
+// The tag is the property name, followed by options: "id" for the identity
+// property, "computed" for state that can't be configured, and "orn" to
+// present a string as an ORN. Fields of type int64, string, and bool map to
+// the integer, string, and boolean property types; a pointer to one of those
+// is nullable. Embedded structs are walked in place, and fields without a tag
+// are not properties. Properties are presented in field order. The structs
+// that otgen generates are tagged the same way.
+
+var objectTypeProperties = map[string][]PropertyDesc{}
+
+// taggedProperties returns the properties RegisterObjectType derived from the
+// struct tags of the object type's template.
+func taggedProperties(ot ObjectType) []PropertyDesc {
+	return objectTypeProperties[ot.TypeName()]
+}
+
+func propertyDescsFromTags(tmpl any) ([]PropertyDesc, error) {
+	typ := reflect.TypeOf(tmpl)
+	if typ == nil || typ.Kind() != reflect.Pointer || typ.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("template must be a pointer to struct, not %v", typ)
+	}
+	var ret []PropertyDesc
+	seen := map[string]bool{}
+	var walk func(st reflect.Type, index []int) error
+	walk = func(st reflect.Type, index []int) error {
+		for i := 0; i < st.NumField(); i++ {
+			sf := st.Field(i)
+			idx := append(append([]int{}, index...), i)
+			tag, has := sf.Tag.Lookup("observe")
+			if !has {
+				if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+					if err := walk(sf.Type, idx); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if tag == "-" {
+				continue
+			}
+			if !sf.IsExported() {
+				return fmt.Errorf("field %s: tagged fields must be exported", sf.Name)
+			}
+			pd, err := propertyDescFromField(sf, tag, idx)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", sf.Name, err)
+			}
+			if seen[pd.Name] {
+				return fmt.Errorf("field %s: duplicate property %q", sf.Name, pd.Name)
+			}
+			seen[pd.Name] = true
+			ret = append(ret, pd)
+		}
+		return nil
+	}
+	if err := walk(typ.Elem(), nil); err != nil {
+		return nil, fmt.Errorf("%s: %w", typ.Elem().Name(), err)
+	}
+	return ret, nil
+}
+
+func propertyDescFromField(sf reflect.StructField, tag string, index []int) (PropertyDesc, error) {
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return PropertyDesc{}, fmt.Errorf("the tag must start with the property name")
+	}
+	pd := PropertyDesc{Name: name}
+	isOrn := false
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "":
+		case "id":
+			pd.IsId = true
+		case "computed":
+			pd.IsComputed = true
+		case "orn":
+			isOrn = true
+		default:
+			return pd, fmt.Errorf("unknown tag option %q", opt)
+		}
+	}
+	ft := sf.Type
+	nullable := ft.Kind() == reflect.Pointer
+	if nullable {
+		ft = ft.Elem()
+	}
+	switch ft.Kind() {
+	case reflect.Int64:
+		pd.Type = PropertyTypeInteger
+	case reflect.String:
+		pd.Type = PropertyTypeString
+		if isOrn {
+			pd.Type = PropertyTypeORN
+		}
+	case reflect.Bool:
+		pd.Type = PropertyTypeBoolean
+	default:
+		return pd, fmt.Errorf("unsupported property type %s", sf.Type)
+	}
+	if isOrn && pd.Type != PropertyTypeORN {
+		return pd, fmt.Errorf("only strings can be ORNs")
+	}
+	// The getters and setters behave like the hand-written ones: a nil pointer
+	// reads as nil, and setting a value of the wrong type panics.
+	pd.Getter = func(o any) any {
+		f := reflect.ValueOf(o).Elem().FieldByIndex(index)
+		if nullable {
+			if f.IsNil() {
+				return nil
+			}
+			return f.Elem().Interface()
+		}
+		return f.Interface()
+	}
+	pd.Setter = func(o any, v any) {
+		f := reflect.ValueOf(o).Elem().FieldByIndex(index)
+		if v == nil {
+			f.Set(reflect.Zero(f.Type()))
+			return
+		}
+		val := reflect.ValueOf(v)
+		if val.Type() != ft {
+			panic(fmt.Errorf("property %q is %s, not %T", name, ft, v))
+		}
+		if nullable {
+			p := reflect.New(ft)
+			p.Elem().Set(val)
+			val = p
+		}
+		f.Set(val)
+	}
+	return pd, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type tagTestBase struct {
+	Id int64 `observe:"id,id"`
+}
+
+type tagTestObject struct {
+	tagTestBase
+	Name     string  `observe:"name"`
+	Orn      string  `observe:"orn,orn,computed"`
+	Label    *string `observe:"label"`
+	Enabled  *bool   `observe:"enabled"`
+	Internal string
+}
+
+func TestPropertyDescsFromTags(t *testing.T) {
+	props, err := propertyDescsFromTags(&tagTestObject{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary []string
+	for _, p := range props {
+		s := p.Name + ":" + p.Type.TypeName()
+		if p.IsId {
+			s += ",id"
+		}
+		if p.IsComputed {
+			s += ",computed"
+		}
+		summary = append(summary, s)
+	}
+	if diff := cmp.Diff(summary, []string{"id:int,id", "name:string", "orn:orn,computed", "label:string", "enabled:boolean"}); diff != "" {
+		t.Error("unexpected properties:", diff)
+	}
+
+	o := &tagTestObject{}
+	props[0].Setter(o, int64(42))
+	props[1].Setter(o, "thing")
+	props[3].Setter(o, "a label")
+	if o.Id != 42 || o.Name != "thing" || o.Label == nil || *o.Label != "a label" {
+		t.Errorf("unexpected object after set: %+v", o)
+	}
+	if v := props[4].Getter(o); v != nil {
+		t.Errorf("expected nil for unset pointer, got %v", v)
+	}
+	if v := props[3].Getter(o); v != "a label" {
+		t.Errorf("expected dereferenced value, got %v", v)
+	}
+	props[3].Setter(o, nil)
+	if o.Label != nil {
+		t.Error("expected nil label after setting nil")
+	}
+	mustPanic(t, func() { props[1].Setter(o, int64(1)) })
+}
+
+func TestPropertyDescsFromTagsErrors(t *testing.T) {
+	for i, tc := range []struct {
+		tmpl any
+		err  string
+	}{
+		{tagTestObject{}, "must be a pointer to struct"},
+		{&struct {
+			F float64 `observe:"f"`
+		}{}, "unsupported property type float64"},
+		{&struct {
+			F int64 `observe:"f,orn"`
+		}{}, "only strings can be ORNs"},
+		{&struct {
+			F int64 `observe:"f,bogus"`
+		}{}, `unknown tag option "bogus"`},
+		{&struct {
+			F int64 `observe:",id"`
+		}{}, "must start with the property name"},
+		{&struct {
+			F int64 `observe:"f"`
+			G int64 `observe:"f"`
+		}{}, `duplicate property "f"`},
+	} {
+		_, err := propertyDescsFromTags(tc.tmpl)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: expected error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+// The rbac-dot, get, and upload commands depend on these; they used to be
+// hand-written.
+func TestTaggedObjectTypes(t *testing.T) {
+	for _, tc := range []struct {
+		ot    ObjectType
+		names string
+	}{
+		{ObjectTypeDataset, "id path workspaceId folderId name kind description managedById pathCost validFromField validToField labelField iconUrl version updatedDate createdBy"},
+		{ObjectTypeUser, "id name email status role"},
+		{ObjectTypeRbacgroupmember, "id description groupid membergroupid memberuserid"},
+		{ObjectTypeDocument, "id name usage mimetype size url updatedDate"},
+		{ObjectTypeRbacstatement, "id description subjectgroupid subjectuserid subjectAll objectobjectid objectfolderid objectworkspaceid objecttype objectname objectowner objectall role"},
+	} {
+		var names []string
+		for _, p := range tc.ot.GetProperties() {
+			names = append(names, p.Name)
+		}
+		if diff := cmp.Diff(strings.Join(names, " "), tc.names); diff != "" {
+			t.Errorf("%s: unexpected properties: %s", tc.ot.TypeName(), diff)
+		}
+	}
+}
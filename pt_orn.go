@@ -15,6 +15,8 @@ var ErrIsNotORN = ObserveError{Msg: "value is not an orn"}
 
 type propertyTypeORN struct{}
 
+var PropertyTypeORN PropertyType = &propertyTypeORN{}
+
 func (*propertyTypeORN) TypeName() string { return "orn" }
 
 func (*propertyTypeORN) Present(i any) (string, error) {
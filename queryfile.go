@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A query file is OPAL text, as `query --file` takes it, that can also hold
+// more than one stage. Directives in comments mark where each stage starts,
+// and what its inputs are:
+//
+//	// name: Errors by service
+//	// stage: errors
+//	// input: _ 41007104
+//	filter status >= 500
+//
+//	// stage: by-service
+//	// input: _ @errors
+//	statsby count(), group_by(service)
+//
+// An input is a dataset ID, a dataset path, or @ and the ID of an earlier
+// stage. The last stage is the output. A file without any stage directive is
+// a single stage, whose inputs are given with --input. The name is for
+// objects made from the file, like worksheets, and doesn't affect queries.
+
+var (
+	ErrQueryFileBeforeStage = ObserveError{Msg: "OPAL and inputs must come after a '// stage:' directive"}
+	ErrQueryFileInput       = ObserveError{Msg: "an input directive is '// input: <name> <dataset or @stage>'"}
+)
+
+const (
+	queryDirectiveName  = "name:"
+	queryDirectiveStage = "stage:"
+	queryDirectiveInput = "input:"
+)
+
+type queryFile struct {
+	Name   string
+	Stages []queryFileStage
+}
+
+type queryFileStage struct {
+	Id       string
+	Inputs   []queryFileInput
+	Pipeline string
+}
+
+type queryFileInput struct {
+	Name   string
+	Source string
+}
+
+// queryFileDirective returns the directive and its argument, if the line is
+// one.
+func queryFileDirective(line string) (string, string, bool) {
+	rest, is := strings.CutPrefix(strings.TrimSpace(line), "//")
+	if !is {
+		return "", "", false
+	}
+	rest = strings.TrimSpace(rest)
+	for _, d := range []string{queryDirectiveName, queryDirectiveStage, queryDirectiveInput} {
+		if arg, is := strings.CutPrefix(rest, d); is {
+			return d, strings.TrimSpace(arg), true
+		}
+	}
+	return "", "", false
+}
+
+// parseQueryFile returns nil stages when the text has no stage directives.
+func parseQueryFile(text string) (*queryFile, error) {
+	qf := &queryFile{}
+	var cur *queryFileStage
+	var pipeline []string
+	endStage := func() {
+		if cur != nil {
+			cur.Pipeline = strings.TrimSpace(strings.Join(pipeline, "\n"))
+			qf.Stages = append(qf.Stages, *cur)
+		}
+		pipeline = nil
+	}
+	var before []string
+	for i, line := range strings.Split(text, "\n") {
+		d, arg, is := queryFileDirective(line)
+		switch {
+		case is && d == queryDirectiveName:
+			qf.Name = arg
+		case is && d == queryDirectiveStage:
+			endStage()
+			cur = &queryFileStage{Id: arg}
+		case is && d == queryDirectiveInput:
+			name, source, ok := strings.Cut(arg, " ")
+			if !ok || strings.TrimSpace(source) == "" {
+				return nil, NewObserveError(ErrQueryFileInput, "line %d", i+1)
+			}
+			if cur == nil {
+				return nil, NewObserveError(ErrQueryFileBeforeStage, "line %d", i+1)
+			}
+			cur.Inputs = append(cur.Inputs, queryFileInput{name, strings.TrimSpace(source)})
+		case cur == nil:
+			before = append(before, line)
+		default:
+			pipeline = append(pipeline, line)
+		}
+	}
+	endStage()
+	if len(qf.Stages) > 0 && strings.TrimSpace(strings.Join(before, "\n")) != "" {
+		return nil, ErrQueryFileBeforeStage
+	}
+	return qf, nil
+}
+
+func writeQueryFile(w io.Writer, qf *queryFile) error {
+	var sb strings.Builder
+	if qf.Name != "" {
+		fmt.Fprintf(&sb, "// %s %s\n", queryDirectiveName, qf.Name)
+	}
+	for i, s := range qf.Stages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "// %s %s\n", queryDirectiveStage, s.Id)
+		for _, in := range s.Inputs {
+			fmt.Fprintf(&sb, "// %s %s %s\n", queryDirectiveInput, in.Name, in.Source)
+		}
+		if s.Pipeline != "" {
+			sb.WriteString(s.Pipeline)
+			sb.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// opalObject is an object made of OPAL stages, which `get --opal` writes as a
+// query file.
+type opalObject interface {
+	QueryFile() *queryFile
+}
+
+// opalObjectType is an object type that `create --opal` can create from a
+// query file.
+type opalObjectType interface {
+	CreateFromQueryFile(fa FuncArgs, qf *queryFile) (ObjectInstance, error)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testQueryFile = `// name: Errors by service
+// stage: errors
+// input: _ 41007104
+filter status >= 500
+// keep comments that aren't directives
+
+// stage: by-service
+// input: _ @errors
+// input: pods Default.k8s/Pod
+statsby count(), group_by(service)
+`
+
+func TestParseQueryFile(t *testing.T) {
+	qf, err := parseQueryFile(testQueryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &queryFile{
+		Name: "Errors by service",
+		Stages: []queryFileStage{
+			{"errors", []queryFileInput{{"_", "41007104"}}, "filter status >= 500\n// keep comments that aren't directives"},
+			{"by-service", []queryFileInput{{"_", "@errors"}, {"pods", "Default.k8s/Pod"}}, "statsby count(), group_by(service)"},
+		},
+	}
+	if diff := cmp.Diff(qf, want); diff != "" {
+		t.Error("unexpected query file:", diff)
+	}
+	var buf bytes.Buffer
+	if err := writeQueryFile(&buf, qf); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(buf.String(), testQueryFile); diff != "" {
+		t.Error("unexpected round trip:", diff)
+	}
+
+	// plain OPAL is a single stage, with --input
+	if qf, err := parseQueryFile("// just a comment\nlimit 10\n"); err != nil || len(qf.Stages) != 0 {
+		t.Error("expected no stages, got", qf, err)
+	}
+	if _, err := parseQueryFile("limit 10\n// stage: late\n"); err == nil || !strings.Contains(err.Error(), ErrQueryFileBeforeStage.Msg) {
+		t.Error("expected an error for OPAL before the first stage, got", err)
+	}
+	if _, err := parseQueryFile("// stage: a\n// input: _\n"); err == nil || !strings.Contains(err.Error(), ErrQueryFileInput.Msg) {
+		t.Error("expected an error for an input without a source, got", err)
+	}
+}
+
+func TestCmdQueryFileStages(t *testing.T) {
+	fix := startFixture(t,
+		testRequest{`/v1/meta/export/query\?.*`, 200, "service,count\napi,3\n"},
+	)
+	fix.fs.WriteFile("errors.opal", []byte(testQueryFile), 0644)
+	RunCommandWithConfig(fix.cfg, fix.fs, fix.op, []string{"query", "-f", "errors.opal", "--csv"}, fix.hc)
+	fix.Assert()
+	for _, want := range []string{`@_ <- datasetId(41007104)`, `@_ <- stageId("errors")`, `@pods <- datasetPath("Default.k8s/Pod")`} {
+		if !strings.Contains(fix.op.DebugBuf.String(), want) {
+			t.Error("expected", want, "in debug output:", fix.op.DebugBuf.String())
+		}
+	}
+	if diff := cmp.Diff(fix.op.OutputBuf.String(), "service,count\napi,3\n"); diff != "" {
+		t.Error("unexpected output:", diff)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// rbacGraph is what rbac-dot plots, independent of the output format. Nodes
+// and edges keep the order they were added in, so that the output is stable
+// and reads in the order the plot was built.
+type rbacGraph struct {
+	dotAttrs []string
+	clusters []rbacCluster
+	items    []rbacGraphItem
+	nodes    map[string]*rbacNode
+	edges    map[[2]string]bool
+}
+
+// A cluster groups nodes together; the attributes only apply to DOT.
+type rbacCluster struct {
+	name      string
+	label     string
+	color     string
+	nodeAttrs string
+}
+
+// Exactly one of node and edge is set.
+type rbacGraphItem struct {
+	node *rbacNode
+	edge *rbacEdge
+}
+
+type rbacNode struct {
+	id      string
+	label   string
+	kind    string // user, group, statement, all, or object
+	cluster string
+}
+
+type rbacEdge struct {
+	from   string
+	to     string
+	label  string
+	weight int
+}
+
+func newRbacGraph(dotAttrs ...string) *rbacGraph {
+	return &rbacGraph{
+		dotAttrs: dotAttrs,
+		nodes:    map[string]*rbacNode{},
+		edges:    map[[2]string]bool{},
+	}
+}
+
+func (g *rbacGraph) cluster(name, label, color, nodeAttrs string) {
+	g.clusters = append(g.clusters, rbacCluster{name, label, color, nodeAttrs})
+}
+
+// node adds a node, unless there already is one with the same ID.
+func (g *rbacGraph) node(id, label, kind, cluster string) {
+	if g.nodes[id] != nil {
+		return
+	}
+	n := &rbacNode{id, label, kind, cluster}
+	g.nodes[id] = n
+	g.items = append(g.items, rbacGraphItem{node: n})
+}
+
+// edge adds an edge, unless there already is one between the same nodes.
+func (g *rbacGraph) edge(from, to, label string, weight int) {
+	key := [2]string{from, to}
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+	g.items = append(g.items, rbacGraphItem{edge: &rbacEdge{from, to, label, weight}})
+}
+
+var rbacGraphRenderers = map[string]func(io.Writer, *rbacGraph) error{
+	"dot":        renderRbacDot,
+	"mermaid":    renderRbacMermaid,
+	"json-graph": renderRbacJSONGraph,
+}
+
+// Users and groups are boxes, like the default node shape.
+var rbacDotShapes = map[string]string{
+	"statement": "oval",
+	"all":       "doublecircle",
+	"object":    "cylinder",
+}
+
+func renderRbacDot(w io.Writer, g *rbacGraph) error {
+	var b strings.Builder
+	writeNode := func(indent string, n *rbacNode) {
+		if shape, has := rbacDotShapes[n.kind]; has && n.cluster == "" {
+			fmt.Fprintf(&b, "%s%q [label=%q shape=%s];\n", indent, n.id, n.label, shape)
+		} else {
+			fmt.Fprintf(&b, "%s%q [label=%q];\n", indent, n.id, n.label)
+		}
+	}
+	b.WriteString("digraph {\n")
+	for _, a := range g.dotAttrs {
+		fmt.Fprintf(&b, "  %s;\n", a)
+	}
+	for _, c := range g.clusters {
+		fmt.Fprintf(&b, "  subgraph cluster_%s {\n", c.name)
+		fmt.Fprintf(&b, "    label=%q;\n", c.label)
+		fmt.Fprintf(&b, "    color=%s;\n", c.color)
+		fmt.Fprintf(&b, "    node [%s];\n", c.nodeAttrs)
+		for _, it := range g.items {
+			if it.node != nil && it.node.cluster == c.name {
+				writeNode("    ", it.node)
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	for _, it := range g.items {
+		switch {
+		case it.node != nil && it.node.cluster == "":
+			writeNode("  ", it.node)
+		case it.edge != nil:
+			var attrs []string
+			if it.edge.label != "" {
+				attrs = append(attrs, fmt.Sprintf("label=%q", it.edge.label))
+			}
+			if it.edge.weight != 0 {
+				attrs = append(attrs, fmt.Sprintf("weight=%d", it.edge.weight))
+			}
+			if len(attrs) > 0 {
+				fmt.Fprintf(&b, "  %q -> %q [%s];\n", it.edge.from, it.edge.to, strings.Join(attrs, " "))
+			} else {
+				fmt.Fprintf(&b, "  %q -> %q;\n", it.edge.from, it.edge.to)
+			}
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Mermaid IDs can't be arbitrary strings, so nodes are numbered.
+var rbacMermaidShapes = map[string][2]string{
+	"user":      {"[", "]"},
+	"group":     {"{{", "}}"},
+	"statement": {"([", "])"},
+	"all":       {"((", "))"},
+	"object":    {"[(", ")]"},
+}
+
+func renderRbacMermaid(w io.Writer, g *rbacGraph) error {
+	var b strings.Builder
+	ids := map[string]string{}
+	mid := func(id string) string {
+		if m, has := ids[id]; has {
+			return m
+		}
+		ids[id] = fmt.Sprintf("n%d", len(ids))
+		return ids[id]
+	}
+	writeNode := func(indent string, n *rbacNode) {
+		shape := rbacMermaidShapes[n.kind]
+		label := strings.ReplaceAll(n.label, `"`, "#quot;")
+		fmt.Fprintf(&b, "%s%s%s\"%s\"%s\n", indent, mid(n.id), shape[0], label, shape[1])
+	}
+	b.WriteString("flowchart LR\n")
+	for _, c := range g.clusters {
+		fmt.Fprintf(&b, "  subgraph %s [\"%s\"]\n", c.name, c.label)
+		for _, it := range g.items {
+			if it.node != nil && it.node.cluster == c.name {
+				writeNode("    ", it.node)
+			}
+		}
+		b.WriteString("  end\n")
+	}
+	for _, it := range g.items {
+		switch {
+		case it.node != nil && it.node.cluster == "":
+			writeNode("  ", it.node)
+		case it.edge != nil:
+			if it.edge.label != "" {
+				fmt.Fprintf(&b, "  %s -->|%s| %s\n", mid(it.edge.from), it.edge.label, mid(it.edge.to))
+			} else {
+				fmt.Fprintf(&b, "  %s --> %s\n", mid(it.edge.from), mid(it.edge.to))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// The JSON follows the JSON Graph Format, https://jsongraphformat.info/.
+
+type jsonGraphNode struct {
+	Label    string            `json:"label"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type jsonGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Label  string `json:"label,omitempty"`
+}
+
+func renderRbacJSONGraph(w io.Writer, g *rbacGraph) error {
+	nodes := map[string]jsonGraphNode{}
+	edges := []jsonGraphEdge{}
+	for _, it := range g.items {
+		if it.node != nil {
+			nodes[it.node.id] = jsonGraphNode{it.node.label, map[string]string{"kind": it.node.kind}}
+		} else {
+			edges = append(edges, jsonGraphEdge{it.edge.from, it.edge.to, it.edge.label})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"graph": map[string]any{
+			"directed": true,
+			"nodes":    nodes,
+			"edges":    edges,
+		},
+	})
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +13,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 )
 
 func RequestPOST[Req, Resp any](cfg *Config, op Output, hc httpClient, path string, req Req, resp *Resp, headers http.Header) (error, int) {
@@ -95,6 +98,10 @@ func SiteUrl(cfg *Config, path string) *url.URL {
 		cidStr = ""
 		protocol = "http://"
 	}
+	// an explicitly configured scheme beats the guessing above
+	if cfg.Scheme != "" {
+		protocol = cfg.Scheme + "://"
+	}
 	str := fmt.Sprintf("%s%s%s%s", protocol, cidStr, cfg.SiteStr, path)
 	ret, err := url.Parse(str)
 	if err != nil {
@@ -103,6 +110,86 @@ func SiteUrl(cfg *Config, path string) *url.URL {
 	return ret
 }
 
+var ErrBadScheme = ObserveError{Msg: "the scheme must be 'http' or 'https'"}
+
+// NewHttpClient builds the client used for all requests, honoring the
+// scheme, TLS, and proxy settings in the config. With none of those set, it
+// behaves like http.DefaultClient.
+func NewHttpClient(cfg *Config, fs fileSystem) (*http.Client, error) {
+	switch cfg.Scheme {
+	case "", "http", "https":
+	default:
+		return nil, NewObserveError(ErrBadScheme, "%q", cfg.Scheme)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, NewObserveError(err, "bad proxy %q", cfg.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+	if cfg.TLSCAFile != "" {
+		data, err := fs.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, NewObserveError(err, "read CA bundle")
+		}
+		// the private CA is trusted in addition to, not instead of, the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, NewObserveError(nil, "no PEM certificates found in %q", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, NewObserveError(nil, "tls_cert_file and tls_key_file must be configured together")
+		}
+		certPEM, err := fs.ReadFile(cfg.TLSCertFile)
+		if err != nil {
+			return nil, NewObserveError(err, "read client certificate")
+		}
+		keyPEM, err := fs.ReadFile(cfg.TLSKeyFile)
+		if err != nil {
+			return nil, NewObserveError(err, "read client key")
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, NewObserveError(err, "load client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// lazyHttpClient builds the client with NewHttpClient when the first request
+// is made, so that a bad TLS or proxy setting only stops the commands that
+// make requests, and not help, or the commands that fix the profile.
+type lazyHttpClient struct {
+	cfg  *Config
+	fs   fileSystem
+	once sync.Once
+	hc   *http.Client
+	err  error
+}
+
+func (l *lazyHttpClient) Do(req *http.Request) (*http.Response, error) {
+	l.once.Do(func() {
+		l.hc, l.err = NewHttpClient(l.cfg, l.fs)
+	})
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.hc.Do(req)
+}
+
 func logHeaders(op Output, headers http.Header) {
 	var keys []string
 	for k := range headers {
@@ -254,6 +341,24 @@ func (p *pendingQuery) Delete() error {
 	return nil
 }
 
+// Send makes the request with any verb, and returns the raw response body and
+// headers, without expecting the {ok, data} envelope. Error statuses are still
+// turned into errors.
+func (p *pendingQuery) Send(verb string) ([]byte, http.Header, error) {
+	p.verifyBase()
+	hresp, err := p.queryInner(verb)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer hresp.Body.Close()
+	data, err := io.ReadAll(hresp.Body)
+	if err != nil {
+		return nil, nil, NewObserveError(err, "%s: response read", p.path)
+	}
+	p.op.Debug("response=%s\n", data)
+	return data, hresp.Header, nil
+}
+
 func (p *pendingQuery) putPostQuery(verb string) (object, error) {
 	p.verifyWithBody()
 	var ar ApiResponse
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"encoding/pem"
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -87,3 +89,69 @@ func TestQueryGet(t *testing.T) {
 		t.Fatal("expected one item:", err, o)
 	}
 }
+
+func TestSiteUrlScheme(t *testing.T) {
+	cfg := &Config{CustomerIdStr: "101", SiteStr: "observe-sandbox.com:4444"}
+	if u := SiteUrl(cfg, "/v1/meta").String(); u != "http://101.observe-sandbox.com:4444/v1/meta" {
+		t.Error("unexpected url:", u)
+	}
+	cfg.Scheme = "https"
+	if u := SiteUrl(cfg, "/v1/meta").String(); u != "https://101.observe-sandbox.com:4444/v1/meta" {
+		t.Error("unexpected url:", u)
+	}
+}
+
+func TestNewHttpClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	fs := NewFakeFs()
+	fs.WriteFile("ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0644)
+	fs.WriteFile("junk.pem", []byte("not a certificate"), 0644)
+
+	hc, err := NewHttpClient(&Config{}, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hc.Get(srv.URL); err == nil {
+		t.Error("expected untrusted certificate error")
+	}
+	for _, cfg := range []*Config{{TLSCAFile: "ca.pem"}, {TLSInsecureSkipVerify: true}} {
+		hc, err = NewHttpClient(cfg, fs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := hc.Get(srv.URL)
+		if err != nil {
+			t.Fatal("expected success:", err)
+		}
+		resp.Body.Close()
+	}
+	for i, tc := range []struct {
+		cfg  *Config
+		want string
+	}{
+		{&Config{Scheme: "ftp"}, "scheme"},
+		{&Config{TLSCAFile: "missing.pem"}, "read CA bundle"},
+		{&Config{TLSCAFile: "junk.pem"}, "no PEM certificates"},
+		{&Config{TLSCertFile: "ca.pem"}, "configured together"},
+		{&Config{Proxy: "http://[bad"}, "bad proxy"},
+	} {
+		if _, err := NewHttpClient(tc.cfg, fs); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestLazyHttpClient(t *testing.T) {
+	// a bad setting is only an error once a request is made
+	hc := &lazyHttpClient{cfg: &Config{Proxy: "http://[bad"}, fs: NewFakeFs()}
+	req, err := http.NewRequest("GET", "https://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hc.Do(req); err == nil || !strings.Contains(err.Error(), "bad proxy") {
+		t.Error("unexpected error:", err)
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 type testFixture struct {
@@ -28,7 +31,8 @@ type httpClient interface {
 }
 
 type fakeFs struct {
-	dir *map[string][]byte
+	dir    *map[string][]byte
+	mtimes *map[string]time.Time
 }
 
 // This fake file system is a thin shim to allow insulation of unit
@@ -37,17 +41,24 @@ type fakeFs struct {
 // example, there aren't even any directories!
 func NewFakeFs() fileSystem {
 	m := map[string][]byte{}
+	t := map[string]time.Time{}
 	return fakeFs{
-		dir: &m,
+		dir:    &m,
+		mtimes: &t,
 	}
 }
 
 func (f fakeFs) Stat(path string) (fs.FileInfo, error) {
-	_, ok := (*f.dir)[path]
+	file, ok := (*f.dir)[path]
 	if !ok {
 		return nil, fmt.Errorf("stat %s: no such file or directory", path)
 	}
-	return nil, nil
+	return fakeFileInfo{filepath.Base(path), int64(len(file)), (*f.mtimes)[path], false}, nil
+}
+
+// Touch sets the modification time of a file, to test what depends on age.
+func (f fakeFs) Touch(path string, t time.Time) {
+	(*f.mtimes)[path] = t
 }
 
 func (f fakeFs) ReadFile(path string) ([]byte, error) {
@@ -60,6 +71,7 @@ func (f fakeFs) ReadFile(path string) ([]byte, error) {
 
 func (f fakeFs) WriteFile(path string, b []byte, perm fs.FileMode) error {
 	(*f.dir)[path] = b
+	(*f.mtimes)[path] = time.Now()
 	return nil
 }
 
@@ -68,6 +80,19 @@ func (f fakeFs) Remove(path string) error {
 		return fmt.Errorf("unlink %s: no such file or directory", path)
 	}
 	delete((*f.dir), path)
+	delete((*f.mtimes), path)
+	return nil
+}
+
+// RemoveAll removes the file at path, and every file under it.
+func (f fakeFs) RemoveAll(path string) error {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for p := range *f.dir {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete((*f.dir), p)
+			delete((*f.mtimes), p)
+		}
+	}
 	return nil
 }
 
@@ -79,6 +104,8 @@ func (f fakeFs) Rename(oldPath, newPath string) error {
 		// This may nuke something previous -- can't be helped!
 		// This is POSIX semantics, but Windows would fail it.
 		(*f.dir)[newPath] = data
+		(*f.mtimes)[newPath] = (*f.mtimes)[oldPath]
+		delete((*f.mtimes), oldPath)
 	}
 	return nil
 }
@@ -88,6 +115,61 @@ func (f fakeFs) MkdirAll(path string, perm fs.FileMode) error {
 	return nil
 }
 
+// ReadDir makes up directories from the paths of the files under path.
+func (f fakeFs) ReadDir(path string) ([]fs.DirEntry, error) {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	seen := map[string]bool{}
+	var ret []fs.DirEntry
+	for p := range *f.dir {
+		rest, is := strings.CutPrefix(p, prefix)
+		if !is {
+			continue
+		}
+		name, _, isDir := strings.Cut(rest, "/")
+		if !seen[name] {
+			seen[name] = true
+			info := fakeFileInfo{name: name, isDir: isDir}
+			if !isDir {
+				info.size, info.modTime = int64(len((*f.dir)[p])), (*f.mtimes)[p]
+			}
+			ret = append(ret, fakeDirEntry{info})
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("open %s: no such file or directory", path)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name() < ret[j].Name() })
+	return ret, nil
+}
+
+type fakeDirEntry struct {
+	info fakeFileInfo
+}
+
+func (e fakeDirEntry) Name() string               { return e.info.name }
+func (e fakeDirEntry) IsDir() bool                { return e.info.isDir }
+func (e fakeDirEntry) Type() fs.FileMode          { return e.info.Mode().Type() }
+func (e fakeDirEntry) Info() (fs.FileInfo, error) { return e.info, nil }
+
+type fakeFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (i fakeFileInfo) Name() string { return i.name }
+func (i fakeFileInfo) Size() int64  { return i.size }
+func (i fakeFileInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+func (i fakeFileInfo) ModTime() time.Time { return i.modTime }
+func (i fakeFileInfo) IsDir() bool        { return i.isDir }
+func (i fakeFileInfo) Sys() any           { return nil }
+
 type testRequest struct {
 	path   string
 	status int
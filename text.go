@@ -351,3 +351,48 @@ func TableQuote(buf []byte) string {
 	}
 	return string(out.Bytes())
 }
+
+// A tree prints like the tree command does, with each node on a line of its
+// own, below and indented from its parent.
+type treeNode struct {
+	label    string
+	children []*treeNode
+}
+
+func (n *treeNode) add(label string) *treeNode {
+	c := &treeNode{label: label}
+	n.children = append(n.children, c)
+	return c
+}
+
+type treeGlyphs struct {
+	tee, corner, pipe, blank string
+}
+
+var (
+	treeUnicode = treeGlyphs{"├── ", "└── ", "│   ", "    "}
+	treeASCII   = treeGlyphs{"|-- ", "`-- ", "|   ", "    "}
+)
+
+func writeTree(w io.Writer, root *treeNode, g treeGlyphs) error {
+	if _, err := fmt.Fprintln(w, root.label); err != nil {
+		return err
+	}
+	return writeTreeChildren(w, root.children, "", g)
+}
+
+func writeTreeChildren(w io.Writer, kids []*treeNode, prefix string, g treeGlyphs) error {
+	for i, k := range kids {
+		branch, indent := g.tee, g.pipe
+		if i == len(kids)-1 {
+			branch, indent = g.corner, g.blank
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, k.label); err != nil {
+			return err
+		}
+		if err := writeTreeChildren(w, k.children, prefix+indent, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}